@@ -0,0 +1,446 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+)
+
+/*
+Classifier that predicts percentages for all classes like the kNNClassifierModel
+*/
+type probabilisticClassifier interface {
+	estimator[int]
+	PredictProba(x [][]float64) []map[int]float64
+}
+
+/*
+Maps the score of a binary classifier to a calibrated probability of the positive class
+*/
+type calibrator interface {
+	fit(scores []float64, isPositive []bool)
+	predict(score float64) float64
+}
+
+/*
+Platt scaling that fits a sigmoid 1 / (1 + exp(a * score + b)) to the scores
+*/
+type plattCalibrator struct {
+	a float64
+	b float64
+}
+
+/*
+Fit the sigmoid with Newton's method and backtracking line search on regularized targets (Lin, Lin and Weng 2007)
+
+	:parameter
+		*	scores: score of being positive for every sample
+		*	isPositive: whether a sample is actually positive
+	:return
+		None
+*/
+func (c *plattCalibrator) fit(scores []float64, isPositive []bool) {
+	const maxIter = 100
+	const minStep = 1e-10
+	const sigma = 1e-12
+	const eps = 1e-5
+	numPos, numNeg := 0.0, 0.0
+	for _, i := range isPositive {
+		if i {
+			numPos++
+		} else {
+			numNeg++
+		}
+	}
+	// targets are moved away from 0 and 1 to avoid overfitting
+	targets := make([]float64, len(scores))
+	for ci, i := range isPositive {
+		if i {
+			targets[ci] = (numPos + 1) / (numPos + 2)
+		} else {
+			targets[ci] = 1 / (numNeg + 2)
+		}
+	}
+	// negative log likelihood for the parameters a and b
+	objective := func(a, b float64) float64 {
+		fval := 0.0
+		for ci, i := range scores {
+			fApB := i*a + b
+			if fApB >= 0 {
+				fval += targets[ci]*fApB + math.Log1p(math.Exp(-fApB))
+			} else {
+				fval += (targets[ci]-1)*fApB + math.Log1p(math.Exp(fApB))
+			}
+		}
+		return fval
+	}
+	a, b := 0.0, math.Log((numNeg+1)/(numPos+1))
+	fval := objective(a, b)
+	for iter := 0; iter < maxIter; iter++ {
+		// gradient and hessian
+		h11, h22, h21, g1, g2 := sigma, sigma, 0.0, 0.0, 0.0
+		for ci, i := range scores {
+			fApB := i*a + b
+			p, q := 0.0, 0.0
+			if fApB >= 0 {
+				p = math.Exp(-fApB) / (1 + math.Exp(-fApB))
+				q = 1 / (1 + math.Exp(-fApB))
+			} else {
+				p = 1 / (1 + math.Exp(fApB))
+				q = math.Exp(fApB) / (1 + math.Exp(fApB))
+			}
+			d2 := p * q
+			h11 += i * i * d2
+			h22 += d2
+			h21 += i * d2
+			d1 := targets[ci] - p
+			g1 += i * d1
+			g2 += d1
+		}
+		if math.Abs(g1) < eps && math.Abs(g2) < eps {
+			break
+		}
+		det := h11*h22 - h21*h21
+		dA := -(h22*g1 - h21*g2) / det
+		dB := -(-h21*g1 + h11*g2) / det
+		gd := g1*dA + g2*dB
+		stepSize := 1.0
+		for stepSize >= minStep {
+			newA, newB := a+stepSize*dA, b+stepSize*dB
+			if newF := objective(newA, newB); newF < fval+0.0001*stepSize*gd {
+				a, b, fval = newA, newB, newF
+				break
+			}
+			stepSize /= 2
+		}
+		if stepSize < minStep {
+			break
+		}
+	}
+	c.a, c.b = a, b
+}
+
+/*
+Calibrated probability of a score
+*/
+func (c *plattCalibrator) predict(score float64) float64 {
+	return 1 / (1 + math.Exp(c.a*score+c.b))
+}
+
+/*
+Isotonic regression that fits a non decreasing step function to the outcomes ordered by their scores
+*/
+type isotonicCalibrator struct {
+	thresholds []float64
+	values     []float64
+}
+
+/*
+Fit the isotonic regression with the pool adjacent violators algorithm
+
+	:parameter
+		*	scores: score of being positive for every sample
+		*	isPositive: whether a sample is actually positive
+	:return
+		None
+*/
+func (c *isotonicCalibrator) fit(scores []float64, isPositive []bool) {
+	order := argsort(scores)
+	// samples with the same score are grouped first so that ties always share one value
+	groupScores := []float64{}
+	groupValues := []float64{}
+	groupWeights := []float64{}
+	for _, i := range order {
+		outcome := 0.0
+		if isPositive[i] {
+			outcome = 1
+		}
+		last := len(groupScores) - 1
+		if last >= 0 && scores[i] == groupScores[last] {
+			groupValues[last] = (groupValues[last]*groupWeights[last] + outcome) / (groupWeights[last] + 1)
+			groupWeights[last]++
+		} else {
+			groupScores = append(groupScores, scores[i])
+			groupValues = append(groupValues, outcome)
+			groupWeights = append(groupWeights, 1)
+		}
+	}
+	// blocks of pooled groups with their lowest and highest score, mean outcome and weight
+	blockLows := []float64{}
+	blockHighs := []float64{}
+	blockValues := []float64{}
+	blockWeights := []float64{}
+	for ci, i := range groupScores {
+		blockLows = append(blockLows, i)
+		blockHighs = append(blockHighs, i)
+		blockValues = append(blockValues, groupValues[ci])
+		blockWeights = append(blockWeights, groupWeights[ci])
+		// merge blocks as long as they violate the ordering
+		for last := len(blockValues) - 1; last > 0 && blockValues[last-1] >= blockValues[last]; last-- {
+			weight := blockWeights[last-1] + blockWeights[last]
+			blockValues[last-1] = (blockValues[last-1]*blockWeights[last-1] + blockValues[last]*blockWeights[last]) / weight
+			blockWeights[last-1] = weight
+			blockHighs[last-1] = blockHighs[last]
+			blockLows = blockLows[:last]
+			blockHighs = blockHighs[:last]
+			blockValues = blockValues[:last]
+			blockWeights = blockWeights[:last]
+		}
+	}
+	// every block is a step from its lowest to its highest score
+	c.thresholds = []float64{}
+	c.values = []float64{}
+	for ci, i := range blockValues {
+		c.thresholds = append(c.thresholds, blockLows[ci])
+		c.values = append(c.values, i)
+		if blockHighs[ci] > blockLows[ci] {
+			c.thresholds = append(c.thresholds, blockHighs[ci])
+			c.values = append(c.values, i)
+		}
+	}
+}
+
+/*
+Calibrated probability of a score by linear interpolation between the fitted steps - scores outside the fitted range get the value of the closest step
+*/
+func (c *isotonicCalibrator) predict(score float64) float64 {
+	last := len(c.thresholds) - 1
+	if score <= c.thresholds[0] {
+		return c.values[0]
+	}
+	if score >= c.thresholds[last] {
+		return c.values[last]
+	}
+	upper := sort.SearchFloat64s(c.thresholds, score)
+	lower := upper - 1
+	frac := (score - c.thresholds[lower]) / (c.thresholds[upper] - c.thresholds[lower])
+	return c.values[lower] + frac*(c.values[upper]-c.values[lower])
+}
+
+/*
+Create a calibrator for the given method
+
+	:parameter
+		*	method: which calibration should be used
+			-	sigmoid: Platt scaling
+			-	isotonic: isotonic regression
+	:return
+		*	cal: the unfitted calibrator
+*/
+func newCalibrator(method *string) calibrator {
+	switch *method {
+	case "sigmoid":
+		return &plattCalibrator{}
+	case "isotonic":
+		return &isotonicCalibrator{}
+	default:
+		fmt.Printf("Using default calibration ['sigmoid'] instead of the not implementd ['%s']\n", *method)
+		return &plattCalibrator{}
+	}
+}
+
+/*
+Classifier whose class percentages are calibrated on a fold that was held out while fitting the wrapped classifier
+*/
+type calibratedClassifier struct {
+	base        probabilisticClassifier
+	method      string
+	numFolds    int
+	classes     []int
+	calibrators map[int]calibrator
+}
+
+/*
+Wrap a classifier to calibrate its class percentages
+
+	:parameter
+		*	base: the classifier to be calibrated e.g. a kNNClassifierModel
+		*	method: which calibration should be used (sigmoid, isotonic)
+		*	numFolds: the data is split into numFolds stratified folds and one of them is held out for the calibration
+	:return
+		*	model: the unfitted calibrated classifier
+*/
+func newCalibratedClassifier(base probabilisticClassifier, method *string, numFolds *int) *calibratedClassifier {
+	return &calibratedClassifier{base: base, method: *method, numFolds: *numFolds}
+}
+
+/*
+Fit the wrapped classifier on all but the held out fold and one calibrator per class (one-vs-rest) on the held out fold
+
+	:parameter
+		*	x: vectors representing the training data
+		*	y: classes of the training data
+	:return
+		None
+*/
+func (c *calibratedClassifier) Fit(x [][]float64, y []int) {
+	if xSize, ySize := len(x), len(y); xSize != ySize {
+		log.Fatalln(fmt.Sprintf("Size of x [%d] not equal to size of y [%d]", xSize, ySize))
+	}
+	shuffle := true
+	heldOut := stratifiedKFold(y, &c.numFolds, &shuffle)[0]
+	c.base.Fit(subsetRows(x, heldOut.trainIdx), subsetRows(y, heldOut.trainIdx))
+	calibY := subsetRows(y, heldOut.testIdx)
+	proba := c.base.PredictProba(subsetRows(x, heldOut.testIdx))
+	c.classes = uniqueInts(y)
+	c.calibrators = make(map[int]calibrator, len(c.classes))
+	for ci, i := range c.classes {
+		// for binary problems only the second class is calibrated and the first gets the remaining probability
+		if len(c.classes) == 2 && ci == 0 {
+			continue
+		}
+		c.calibrators[i] = newCalibrator(&c.method)
+		c.calibrators[i].fit(probaOfClass(proba, i), isClass(calibY, i))
+	}
+}
+
+/*
+Predict the calibrated class percentages for every vector in x
+
+	:parameter
+		*	x: vectors for which the classes should be predicted
+	:return
+		*	proba: calibrated percentages for all classes per vector that sum up to 1
+*/
+func (c *calibratedClassifier) PredictProba(x [][]float64) []map[int]float64 {
+	proba := c.base.PredictProba(x)
+	calibrated := make([]map[int]float64, len(proba))
+	for ci, i := range proba {
+		calibrated[ci] = make(map[int]float64, len(c.classes))
+		if len(c.classes) == 2 {
+			positive := c.calibrators[c.classes[1]].predict(i[c.classes[1]])
+			calibrated[ci][c.classes[0]] = 1 - positive
+			calibrated[ci][c.classes[1]] = positive
+			continue
+		}
+		probaSum := 0.0
+		for _, j := range c.classes {
+			calibrated[ci][j] = c.calibrators[j].predict(i[j])
+			probaSum += calibrated[ci][j]
+		}
+		for _, j := range c.classes {
+			if probaSum > 0 {
+				calibrated[ci][j] /= probaSum
+			} else {
+				calibrated[ci][j] = 1 / float64(len(c.classes))
+			}
+		}
+	}
+	return calibrated
+}
+
+/*
+Predict the class with the highest calibrated percentage for every vector in x
+
+	:parameter
+		*	x: vectors for which the classes should be predicted
+	:return
+		*	pred: predicted classes
+*/
+func (c *calibratedClassifier) Predict(x [][]float64) []int {
+	pred := make([]int, len(x))
+	for ci, i := range c.PredictProba(x) {
+		pred[ci] = argmaxClass(i)
+	}
+	return pred
+}
+
+/*
+Create an unfitted copy of the calibrated classifier with the same parameters
+*/
+func (c *calibratedClassifier) Clone() estimator[int] {
+	return &calibratedClassifier{base: c.base.Clone().(probabilisticClassifier), method: c.method, numFolds: c.numFolds}
+}
+
+/*
+Bins of a reliability diagram with the mean predicted probability and the observed fraction of positives per bin
+*/
+type reliabilityBins struct {
+	meanPredicted    []float64
+	fractionPositive []float64
+	counts           []float64
+}
+
+/*
+Bin predicted probabilities to compare them with the observed frequency of the positive class - empty bins are left out
+
+	:parameter
+		*	scores: predicted probability of being positive for every sample
+		*	isPositive: whether a sample is actually positive
+		*	numBins: number of bins
+		*	strategy: how the bin edges are chosen
+			-	uniform: bins of equal width between 0 and 1
+			-	quantile: bins with the same number of samples
+	:return
+		*	bins: mean predicted probability, fraction of positives and number of samples per non empty bin
+*/
+func reliabilityDiagram(scores []float64, isPositive []bool, numBins *int, strategy *string) reliabilityBins {
+	if sSize, pSize := len(scores), len(isPositive); sSize != pSize {
+		log.Fatalln(fmt.Sprintf("Number of scores [%d] doesn't match the number of labels [%d]", sSize, pSize))
+	}
+	if *numBins < 1 {
+		log.Fatalln(fmt.Sprintf("Number of bins [%d] has to be at least 1", *numBins))
+	}
+	// upper edges of the bins
+	edges := make([]float64, *numBins)
+	switch *strategy {
+	case "quantile":
+		sortedScores := append([]float64{}, scores...)
+		sort.Float64s(sortedScores)
+		for i := range edges {
+			edges[i] = quantileSorted(sortedScores, float64(i+1)/float64(*numBins))
+		}
+	default:
+		if *strategy != "uniform" {
+			fmt.Printf("Using default binning ['uniform'] instead of the not implementd ['%s']\n", *strategy)
+		}
+		for i := range edges {
+			edges[i] = float64(i+1) / float64(*numBins)
+		}
+	}
+	edges[*numBins-1] = math.Inf(1)
+	predSums := make([]float64, *numBins)
+	posSums := make([]float64, *numBins)
+	counts := make([]float64, *numBins)
+	for ci, i := range scores {
+		bin := sort.Search(*numBins, func(j int) bool { return i <= edges[j] })
+		// the upper edge of the uniform bins belongs to the next bin except for the last one
+		if *strategy != "quantile" && bin < *numBins-1 && i == edges[bin] {
+			bin++
+		}
+		predSums[bin] += i
+		counts[bin]++
+		if isPositive[ci] {
+			posSums[bin]++
+		}
+	}
+	bins := reliabilityBins{}
+	for i := range counts {
+		if counts[i] > 0 {
+			bins.meanPredicted = append(bins.meanPredicted, predSums[i]/counts[i])
+			bins.fractionPositive = append(bins.fractionPositive, posSums[i]/counts[i])
+			bins.counts = append(bins.counts, counts[i])
+		}
+	}
+	return bins
+}
+
+/*
+Expected calibration error as the sample weighted mean absolute difference between predicted and observed probability over all bins
+*/
+func (b reliabilityBins) expectedCalibrationError() float64 {
+	total := *sumFloat64(b.counts)
+	ece := 0.0
+	for ci, i := range b.counts {
+		ece += i / total * math.Abs(b.meanPredicted[ci]-b.fractionPositive[ci])
+	}
+	return ece
+}
+
+/*
+Export the bins as csv with the columns mean_predicted, fraction_positive and count
+*/
+func (b reliabilityBins) writeCSV(filePath *string) {
+	writeColumnsCSV(filePath, []string{"mean_predicted", "fraction_positive", "count"}, b.meanPredicted, b.fractionPositive, b.counts)
+}
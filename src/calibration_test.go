@@ -0,0 +1,83 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIsotonicCalibratorPAVA(t *testing.T) {
+	scores := []float64{1, 2, 3, 4, 5, 6}
+	isPositive := []bool{false, true, false, true, true, false}
+	cal := &isotonicCalibrator{}
+	cal.fit(scores, isPositive)
+	want := []float64{0, 0.5, 0.5, 2. / 3, 2. / 3, 2. / 3}
+	for ci, i := range scores {
+		if got := cal.predict(i); math.Abs(got-want[ci]) > 1e-12 {
+			t.Errorf("predict(%g) = %g, want %g", i, got, want[ci])
+		}
+	}
+	// between two steps the values are interpolated
+	if got := cal.predict(1.5); math.Abs(got-0.25) > 1e-12 {
+		t.Errorf("predict(1.5) = %g, want 0.25", got)
+	}
+}
+
+func TestIsotonicCalibratorTies(t *testing.T) {
+	// the same samples in every order have to give the same calibration
+	orders := []struct {
+		scores     []float64
+		isPositive []bool
+	}{
+		{[]float64{0.1, 0.5, 0.5}, []bool{true, false, true}},
+		{[]float64{0.1, 0.5, 0.5}, []bool{true, true, false}},
+		{[]float64{0.5, 0.1, 0.5}, []bool{false, true, true}},
+		{[]float64{0.5, 0.5, 0.1}, []bool{true, false, true}},
+	}
+	for ci, i := range orders {
+		cal := &isotonicCalibrator{}
+		cal.fit(i.scores, i.isPositive)
+		for _, score := range []float64{0.1, 0.5} {
+			if got := cal.predict(score); math.Abs(got-2./3) > 1e-12 {
+				t.Errorf("order %d: predict(%g) = %g, want %g", ci, score, got, 2./3)
+			}
+		}
+	}
+}
+
+func TestIsotonicCalibratorTiedGroups(t *testing.T) {
+	// kNN vote fractions are mostly tied - every tie has to share the mean outcome of its group
+	scores := []float64{0, 0, 0, 0.5, 0.5, 0.5, 0.5, 1, 1}
+	isPositive := []bool{false, false, true, true, false, true, false, true, true}
+	cal := &isotonicCalibrator{}
+	cal.fit(scores, isPositive)
+	for _, i := range []struct{ score, want float64 }{{0, 1. / 3}, {0.5, 0.5}, {1, 1}} {
+		if got := cal.predict(i.score); math.Abs(got-i.want) > 1e-12 {
+			t.Errorf("predict(%g) = %g, want %g", i.score, got, i.want)
+		}
+	}
+}
+
+func TestReliabilityDiagram(t *testing.T) {
+	scores := []float64{0.1, 0.2, 0.5, 0.7, 0.9, 1}
+	isPositive := []bool{false, true, false, true, true, true}
+	numBins := 2
+	strategy := "uniform"
+	// 0.5 is the upper edge of the first bin and belongs to the second one
+	bins := reliabilityDiagram(scores, isPositive, &numBins, &strategy)
+	wantMean := []float64{0.15, 0.775}
+	wantFraction := []float64{0.5, 0.75}
+	wantCounts := []float64{2, 4}
+	for ci := range wantMean {
+		if math.Abs(bins.meanPredicted[ci]-wantMean[ci]) > 1e-12 || bins.fractionPositive[ci] != wantFraction[ci] || bins.counts[ci] != wantCounts[ci] {
+			t.Errorf("bin %d has mean %g, fraction %g and count %g, want %g, %g and %g", ci, bins.meanPredicted[ci], bins.fractionPositive[ci], bins.counts[ci], wantMean[ci], wantFraction[ci], wantCounts[ci])
+		}
+	}
+	numBins = 3
+	strategy = "quantile"
+	bins = reliabilityDiagram(scores, isPositive, &numBins, &strategy)
+	for ci, i := range bins.counts {
+		if i != 2 {
+			t.Errorf("quantile bin %d has %g samples, want 2", ci, i)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+/*
+Confusion matrix where counts[i][j] is the number of samples of class classes[i] that were predicted as classes[j]
+*/
+type confusionMatrix struct {
+	Classes []int   `json:"classes"`
+	Counts  [][]int `json:"counts"`
+}
+
+/*
+Precision, recall, F1 score and support of one class or an average over classes
+*/
+type classScores struct {
+	Name      string  `json:"name"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	Support   int     `json:"support"`
+}
+
+/*
+Report of all classification metrics
+*/
+type classificationReport struct {
+	Confusion        confusionMatrix `json:"confusion_matrix"`
+	PerClass         []classScores   `json:"per_class"`
+	MacroAvg         classScores     `json:"macro_avg"`
+	MicroAvg         classScores     `json:"micro_avg"`
+	WeightedAvg      classScores     `json:"weighted_avg"`
+	Accuracy         float64         `json:"accuracy"`
+	BalancedAccuracy float64         `json:"balanced_accuracy"`
+	CohenKappa       float64         `json:"cohen_kappa"`
+	MCC              float64         `json:"matthews_corrcoef"`
+}
+
+/*
+Invert the map that was used to convert string labels to int labels
+
+	:parameter
+		* labelMap: map from string label to int label as returned by genTrainTestData
+	:return
+		* labelNames: map from int label to string label
+*/
+func invertLabelMap(labelMap map[string]int) map[int]string {
+	labelNames := make(map[int]string, len(labelMap))
+	for key, value := range labelMap {
+		labelNames[value] = key
+	}
+	return labelNames
+}
+
+/*
+Calculate the confusion matrix between prediction and ground truth
+
+	:parameter
+		* prediction: predicted labels as returned by a classifier
+		* groundTruth: ground truth (correct) labels
+	:return
+		* confusion: the confusion matrix over all classes that occur in prediction or ground truth
+*/
+func newConfusionMatrix(prediction, groundTruth []int) confusionMatrix {
+	pSize := len(prediction)
+	if gTSize := len(groundTruth); pSize != gTSize {
+		log.Fatalln(fmt.Sprintf("Prediction size [%d] doesn't match the ground truth size [%d]", pSize, gTSize))
+	}
+	classes := uniqueInts(append(append([]int{}, groundTruth...), prediction...))
+	classIdx := make(map[int]int, len(classes))
+	for ci, i := range classes {
+		classIdx[i] = ci
+	}
+	counts := make([][]int, len(classes))
+	for i := range counts {
+		counts[i] = make([]int, len(classes))
+	}
+	for i := 0; i < pSize; i++ {
+		counts[classIdx[groundTruth[i]]][classIdx[prediction[i]]]++
+	}
+	return confusionMatrix{Classes: classes, Counts: counts}
+}
+
+/*
+Divide two counts and return 0 if the denominator is 0
+*/
+func safeDivide(numerator, denominator float64) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return numerator / denominator
+}
+
+/*
+Calculate the F1 score as harmonic mean of precision and recall
+*/
+func f1Score(precision, recall float64) float64 {
+	return safeDivide(2*precision*recall, precision+recall)
+}
+
+/*
+Calculate the full classification report between prediction and ground truth
+
+	:parameter
+		* prediction: predicted labels as returned by a classifier
+		* groundTruth: ground truth (correct) labels
+		* labelNames: names of the int labels as returned by invertLabelMap - nil to use the int labels as names
+	:return
+		* report: confusion matrix, per class scores, their averages and the summarizing metrics
+*/
+func newClassificationReport(prediction, groundTruth []int, labelNames map[int]string) classificationReport {
+	confusion := newConfusionMatrix(prediction, groundTruth)
+	numClasses := len(confusion.Classes)
+	total := float64(len(groundTruth))
+	// number of samples per true class, per predicted class and correctly predicted
+	trueCounts := make([]float64, numClasses)
+	predCounts := make([]float64, numClasses)
+	correct := 0.0
+	for i := 0; i < numClasses; i++ {
+		for j := 0; j < numClasses; j++ {
+			trueCounts[i] += float64(confusion.Counts[i][j])
+			predCounts[j] += float64(confusion.Counts[i][j])
+		}
+		correct += float64(confusion.Counts[i][i])
+	}
+
+	report := classificationReport{Confusion: confusion, PerClass: make([]classScores, numClasses)}
+	recallSum := 0.0
+	classesWithSupport := 0.0
+	for ci, i := range confusion.Classes {
+		name, ok := labelNames[i]
+		if !ok {
+			name = strconv.Itoa(i)
+		}
+		truePositives := float64(confusion.Counts[ci][ci])
+		precision := safeDivide(truePositives, predCounts[ci])
+		recall := safeDivide(truePositives, trueCounts[ci])
+		scores := classScores{Name: name, Precision: precision, Recall: recall, F1: f1Score(precision, recall), Support: int(trueCounts[ci])}
+		report.PerClass[ci] = scores
+
+		report.MacroAvg.Precision += scores.Precision / float64(numClasses)
+		report.MacroAvg.Recall += scores.Recall / float64(numClasses)
+		report.MacroAvg.F1 += scores.F1 / float64(numClasses)
+		report.WeightedAvg.Precision += scores.Precision * trueCounts[ci] / total
+		report.WeightedAvg.Recall += scores.Recall * trueCounts[ci] / total
+		report.WeightedAvg.F1 += scores.F1 * trueCounts[ci] / total
+		if trueCounts[ci] > 0 {
+			recallSum += recall
+			classesWithSupport++
+		}
+	}
+	report.Accuracy = correct / total
+	// for single label classification all micro averages are equal to the accuracy
+	report.MicroAvg = classScores{Name: "micro avg", Precision: report.Accuracy, Recall: report.Accuracy, F1: report.Accuracy, Support: int(total)}
+	report.MacroAvg.Name = "macro avg"
+	report.MacroAvg.Support = int(total)
+	report.WeightedAvg.Name = "weighted avg"
+	report.WeightedAvg.Support = int(total)
+	report.BalancedAccuracy = recallSum / classesWithSupport
+
+	// agreement expected by chance and the multiclass Matthews correlation coefficient
+	chanceSum := 0.0
+	predSquareSum := 0.0
+	trueSquareSum := 0.0
+	for i := 0; i < numClasses; i++ {
+		chanceSum += predCounts[i] * trueCounts[i]
+		predSquareSum += predCounts[i] * predCounts[i]
+		trueSquareSum += trueCounts[i] * trueCounts[i]
+	}
+	expectedAgreement := chanceSum / (total * total)
+	report.CohenKappa = safeDivide(report.Accuracy-expectedAgreement, 1-expectedAgreement)
+	report.MCC = safeDivide(correct*total-chanceSum, math.Sqrt((total*total-predSquareSum)*(total*total-trueSquareSum)))
+	return report
+}
+
+/*
+Format the report as text table
+*/
+func (r classificationReport) String() string {
+	builder := &strings.Builder{}
+	writer := tabwriter.NewWriter(builder, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(writer, "\tprecision\trecall\tf1-score\tsupport\t")
+	for _, i := range r.PerClass {
+		fmt.Fprintf(writer, "%s\t%.4f\t%.4f\t%.4f\t%d\t\n", i.Name, i.Precision, i.Recall, i.F1, i.Support)
+	}
+	fmt.Fprintln(writer, "\t\t\t\t\t")
+	fmt.Fprintf(writer, "accuracy\t\t\t%.4f\t%d\t\n", r.Accuracy, r.MicroAvg.Support)
+	for _, i := range []classScores{r.MacroAvg, r.MicroAvg, r.WeightedAvg} {
+		fmt.Fprintf(writer, "%s\t%.4f\t%.4f\t%.4f\t%d\t\n", i.Name, i.Precision, i.Recall, i.F1, i.Support)
+	}
+	fmt.Fprintln(writer, "\t\t\t\t\t")
+	fmt.Fprintf(writer, "balanced accuracy\t\t\t%.4f\t\t\n", r.BalancedAccuracy)
+	fmt.Fprintf(writer, "cohen kappa\t\t\t%.4f\t\t\n", r.CohenKappa)
+	fmt.Fprintf(writer, "matthews corrcoef\t\t\t%.4f\t\t\n", r.MCC)
+	writer.Flush()
+
+	// confusion matrix with the true classes as rows and the predicted classes as columns
+	builder.WriteString("\nconfusion matrix (rows: true, columns: predicted)\n")
+	writer = tabwriter.NewWriter(builder, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for _, i := range r.PerClass {
+		fmt.Fprintf(writer, "\t%s", i.Name)
+	}
+	fmt.Fprintln(writer, "\t")
+	for ci, i := range r.Confusion.Counts {
+		fmt.Fprint(writer, r.PerClass[ci].Name)
+		for _, j := range i {
+			fmt.Fprintf(writer, "\t%d", j)
+		}
+		fmt.Fprintln(writer, "\t")
+	}
+	writer.Flush()
+	return builder.String()
+}
+
+/*
+Format the report as indented JSON
+*/
+func (r classificationReport) json() string {
+	jsonReport, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		log.Fatalln("Couldn't convert the classification report to JSON\n", err)
+	}
+	return string(jsonReport)
+}
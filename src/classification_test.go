@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestClassificationReportHandComputed(t *testing.T) {
+	// confusion matrix with the rows [3 1 0], [0 2 1] and [2 0 1]
+	groundTruth := []int{0, 0, 0, 0, 1, 1, 1, 2, 2, 2}
+	prediction := []int{0, 0, 0, 1, 1, 1, 2, 0, 0, 2}
+	report := newClassificationReport(prediction, groundTruth, map[int]string{0: "a", 1: "b"})
+	wantCounts := [][]int{{3, 1, 0}, {0, 2, 1}, {2, 0, 1}}
+	for ci, i := range wantCounts {
+		for cj, j := range i {
+			if report.Confusion.Counts[ci][cj] != j {
+				t.Fatalf("confusion matrix %v, want %v", report.Confusion.Counts, wantCounts)
+			}
+		}
+	}
+	wantPerClass := []classScores{
+		{Name: "a", Precision: 3. / 5, Recall: 3. / 4, F1: 2. / 3, Support: 4},
+		{Name: "b", Precision: 2. / 3, Recall: 2. / 3, F1: 2. / 3, Support: 3},
+		{Name: "2", Precision: 1. / 2, Recall: 1. / 3, F1: 2. / 5, Support: 3},
+	}
+	close := func(a, b float64) bool { return math.Abs(a-b) < 1e-12 }
+	for ci, i := range wantPerClass {
+		got := report.PerClass[ci]
+		if got.Name != i.Name || got.Support != i.Support || !close(got.Precision, i.Precision) || !close(got.Recall, i.Recall) || !close(got.F1, i.F1) {
+			t.Errorf("class %d scores %+v, want %+v", ci, got, i)
+		}
+	}
+	for _, i := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"accuracy", report.Accuracy, 0.6},
+		{"micro F1", report.MicroAvg.F1, 0.6},
+		{"macro precision", report.MacroAvg.Precision, 53. / 90},
+		{"weighted F1", report.WeightedAvg.F1, 44. / 75},
+		{"balanced accuracy", report.BalancedAccuracy, 7. / 12},
+		// chance agreement (5 * 4 + 3 * 3 + 2 * 3) / 100 = 0.35
+		{"cohen kappa", report.CohenKappa, 5. / 13},
+		{"matthews corrcoef", report.MCC, 25 / math.Sqrt(62*66)},
+	} {
+		if !close(i.got, i.want) {
+			t.Errorf("%s %.16g, want %.16g", i.name, i.got, i.want)
+		}
+	}
+	var decoded classificationReport
+	if err := json.Unmarshal([]byte(report.json()), &decoded); err != nil || !close(decoded.MCC, report.MCC) || decoded.PerClass[2].Support != 3 {
+		t.Errorf("JSON report doesn't decode to the report: %v", err)
+	}
+	if text := report.String(); !strings.Contains(text, "cohen kappa") || !strings.Contains(text, "0.3846") {
+		t.Errorf("text report misses the cohen kappa of 0.3846:\n%s", text)
+	}
+}
+
+func TestClassificationReportPerfect(t *testing.T) {
+	labels := []int{1, 0, 1, 2}
+	report := newClassificationReport(labels, labels, nil)
+	if report.Accuracy != 1 || report.CohenKappa != 1 || math.Abs(report.MCC-1) > 1e-12 || report.MacroAvg.F1 != 1 {
+		t.Errorf("perfect prediction gives accuracy %g, kappa %g, MCC %g and macro F1 %g, want 1", report.Accuracy, report.CohenKappa, report.MCC, report.MacroAvg.F1)
+	}
+}
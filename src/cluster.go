@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"math"
 )
 
@@ -24,35 +23,17 @@ func centroid(inSlice [][]float64) []float64 {
 	return centroidSlice
 }
 
-/*
-finde the index of the smallest non- zero element in a slice
-
-	:parameter
-		*	inSlice: the slice to search in
-	:return
-		*	minIdx: index of the smallest non-zero element in the slice
-*/
-func argminNonZeroFloat(inSlice []float64) int {
-	minIdx := 0
-	minVal := inSlice[0]
-	for ci, i := range inSlice {
-		if i-0.0 > float64EqualityThreshold && i < minVal {
-			minVal = i
-			minIdx = ci
-		}
-	}
-	return minIdx
-}
-
 /*
 Find the member of the cluster that has the highest correlation to all other members
 	:parameter
 		* inSlice: slice with data of all clusters
 		* clusterMembers: (column) indices of inSlice that are in the same cluster
+		* corrType: which correlation should be used (pearson, spearman, kendall)
 	:return
 		* representative: clusterMembers member with the highest correlation to all others
 */
-func findRepresentativeForCluster(inSlice[][]float64, clusterMembers []int) *int {
+func findRepresentativeForCluster(inSlice[][]float64, clusterMembers []int, corrType *string) *int {
+	correlationFunction := selectCorrelationFunction(corrType)
 	// number of members in the cluster
 	dim := len(clusterMembers)	
 	// all correlations of all against all cluster members
@@ -70,7 +51,7 @@ func findRepresentativeForCluster(inSlice[][]float64, clusterMembers []int) *int
 				corrMat[ci][cj] = 1.
 			} else {
 				// calculate correlation between clusterMembers[ci] and clusterMembers[cj]
-				iCorr := math.Abs(corrCoef(inSlice, &i, &j))
+				iCorr := math.Abs(correlationFunction(inSlice, &i, &j))
 				corrMat[ci][cj] = iCorr 
 				corrMat[cj][ci] =  iCorr
 			}
@@ -100,26 +81,34 @@ Hierarchical clustering using the centroids of each cluster for distance calcula
 		*	cluster: indices of members of clusters in their own slice
 */
 func hierachicalClustering(inSlice [][]float64, distType *string, maxIter *int, maxDist *float64) [][]int {
+	cluster, _ := hierachicalClusteringMerges(inSlice, distType, maxIter, maxDist)
+	return cluster
+}
+
+/*
+Hierarchical clustering using the centroids of each cluster for distance calculation that also records every merge
+
+	:parameter
+		*	inSlice: slice to be clusterd
+		*	distType: which distance metric should be used (euclidean, manhattan, hamming, braycurtis)
+		*	maxIter: maximum number of iterations to find clusters
+		*	maxDist: maximum distance between clusters to be allowed to merge
+	:return
+		*	cluster: indices of members of clusters in their own slice
+		*	merges: all merges in the order they were made
+*/
+func hierachicalClusteringMerges(inSlice [][]float64, distType *string, maxIter *int, maxDist *float64) ([][]int, []clusterMerge) {
 	// selecting the distance function
-	distanceFunction := euclideanDist
-	switch *distType {
-	case "manhattan":
-		distanceFunction = manhattanDist
-	case "hamming":
-		distanceFunction = hammingDist
-	case "braycurtis":
-		distanceFunction = braycurtisDiss
-	case "euclidean":
-		distanceFunction = euclideanDist
-	default:
-		fmt.Printf("Using default distance metric ['euclidean'] instead of the not implementd ['%s']", *distType)
-		distanceFunction = euclideanDist
-	}
+	distanceFunction := selectDistanceFunction(distType)
 	// storage for the indices of the clusters
 	cluster := make([][]int, len(inSlice))
+	// id of every cluster as used in the linkage matrix
+	ids := make([]int, len(inSlice))
 	for ci := range inSlice {
 		cluster[ci] = []int{ci}
+		ids[ci] = ci
 	}
+	merges := []clusterMerge{}
 	interCount := 0
 	prevClusterNum := 0
 	for {
@@ -138,18 +127,29 @@ func hierachicalClustering(inSlice [][]float64, distType *string, maxIter *int,
 		partner2 := 0
 		for cj, j := range centroidsOfCluster {
 			dist := distanceFunction(centroidsOfCluster, j)
-			minDistIdx := argminNonZeroFloat(dist)
-			if mDist := dist[minDistIdx]; mDist < minDist && minDistIdx != cj && mDist <= *maxDist {
+			// identical points have a distance of 0 but are still different clusters
+			minDistIdx := argminExcluding(dist, cj)
+			if minDistIdx == -1 {
+				continue
+			}
+			if mDist := dist[minDistIdx]; mDist < minDist && mDist <= *maxDist {
 				minDist = mDist
 				partner1 = cj
 				partner2 = minDistIdx
 			}
 		}
 		if partner1 > 0 || partner2 > 0 {
+			first, second := ids[partner1], ids[partner2]
+			if second < first {
+				first, second = second, first
+			}
+			merges = append(merges, clusterMerge{first: first, second: second, dist: minDist, size: len(cluster[partner1]) + len(cluster[partner2])})
 			// merge cluster
 			cluster[partner1] = append(cluster[partner1], cluster[partner2]...)
+			ids[partner1] = len(inSlice) + len(merges) - 1
 			// remove second partner of the merged cluster from the stored clusters since it's not in the merged clusters
 			cluster = append(cluster[:partner2], cluster[partner2+1:]...)
+			ids = append(ids[:partner2], ids[partner2+1:]...)
 			// stop if all are in on cluster or if the maxIter is reached
 		}
 		if len(cluster) == 1 || interCount == *maxIter || prevClusterNum == len(cluster) {
@@ -158,7 +158,7 @@ func hierachicalClustering(inSlice [][]float64, distType *string, maxIter *int,
 		prevClusterNum = len(cluster)
 		interCount++
 	}
-	return cluster
+	return cluster, merges
 }
 
 /*
@@ -167,16 +167,18 @@ calculate the average correlation between two clusters
 	:parameter
 		*	inSlice: slice with data of features
 		*	cluster1, cluster2: indices of feature in the same cluster
+		*	corrType: which correlation should be used (pearson, spearman, kendall)
 	:return
 		*	totalCorr: the average correlation between the to clusters
 */
-func ClusterCorrelation(inSlice [][]float64, cluster1, cluster2 []int) float64 {
+func ClusterCorrelation(inSlice [][]float64, cluster1, cluster2 []int, corrType *string) float64 {
+	correlationFunction := selectCorrelationFunction(corrType)
 	totalCorr := 0.0
 	clusterMembers := 0
 	for _, i := range cluster1 {
 		for _, j := range cluster2 {
 			if i != j {
-				interCorr := corrCoef(inSlice, &i, &j)
+				interCorr := correlationFunction(inSlice, &i, &j)
 				totalCorr += math.Abs(interCorr)
 				clusterMembers++
 			}
@@ -192,14 +194,41 @@ Hierarchical clustering of features based on the mean correlation between all fe
 		*	inSlice: slice to be clusterd
 		*	maxIter: maximum number of iterations to find clusters
 		*	minCorr: minimum correlation to be merged
+		*	corrType: which correlation should be used
+			-	pearson
+			-	spearman
+			-	kendall
+	:return
+		*	cluster: indices of members of clusters in their own slice
+*/
+func hierarchicalCorrelationClustering(inSlice [][]float64, maxIter *int, minCorr *float64, corrType *string) [][]int {
+	corrMat := CorrelationMatrix(inSlice, corrType)
+	return hierarchicalCorrelationClusteringMatrix(corrMat, maxIter, minCorr)
+}
+
+/*
+Hierarchical clustering of features based on the mean absolute correlation between all features in a cluster
+using an already computed correlation matrix - constant features stop the program like in corrCoef
+
+	:parameter
+		*	corrMat: correlation matrix as returned by CorrelationMatrix
+		*	maxIter: maximum number of iterations to find clusters
+		*	minCorr: minimum correlation to be merged
 	:return
 		*	cluster: indices of members of clusters in their own slice
 */
-func hierarchicalCorrelationClustering(inSlice [][]float64, maxIter *int, minCorr *float64) [][]int {
+func hierarchicalCorrelationClusteringMatrix(corrMat [][]float64, maxIter *int, minCorr *float64) [][]int {
+	assertNoConstantFeatures(corrMat)
 	// storage for the indices of the clusters
-	cluster := make([][]int, len(inSlice[0]))
-	for ci := range inSlice[0] {
+	cluster := make([][]int, len(corrMat))
+	// sum of the absolute correlations between all members of two clusters
+	corrSums := make([][]float64, len(corrMat))
+	for ci, i := range corrMat {
 		cluster[ci] = []int{ci}
+		corrSums[ci] = make([]float64, len(i))
+		for cj, j := range i {
+			corrSums[ci][cj] = math.Abs(j)
+		}
 	}
 
 	prevClusterNum := len(cluster)
@@ -209,9 +238,9 @@ func hierarchicalCorrelationClustering(inSlice [][]float64, maxIter *int, minCor
 		maxCorr := 0.0
 		partner1 := 0
 		partner2 := 0
-		for ci, i := range cluster {
-			for cj, j := range cluster {
-				if mCorr := ClusterCorrelation(inSlice, i, j); mCorr > maxCorr && ci != cj {
+		for ci := range cluster {
+			for cj := ci + 1; cj < len(cluster); cj++ {
+				if mCorr := corrSums[ci][cj] / float64(len(cluster[ci])*len(cluster[cj])); mCorr > maxCorr {
 					maxCorr = mCorr
 					partner1 = ci
 					partner2 = cj
@@ -224,8 +253,16 @@ func hierarchicalCorrelationClustering(inSlice [][]float64, maxIter *int, minCor
 		if partner1 > 0 || partner2 > 0 {
 			// merge cluster
 			cluster[partner1] = append(cluster[partner1], cluster[partner2]...)
+			for ci := range corrSums {
+				corrSums[partner1][ci] += corrSums[partner2][ci]
+				corrSums[ci][partner1] = corrSums[partner1][ci]
+			}
 			// remove second partner of the merged cluster from the stored clusters since it's not in the merged clusters
 			cluster = append(cluster[:partner2], cluster[partner2+1:]...)
+			corrSums = append(corrSums[:partner2], corrSums[partner2+1:]...)
+			for ci, i := range corrSums {
+				corrSums[ci] = append(i[:partner2], i[partner2+1:]...)
+			}
 			// stop if all are in on cluster or if the maxIter is reached
 		}
 		if len(cluster) == 1 || interCount == *maxIter || prevClusterNum == len(cluster) {
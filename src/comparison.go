@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+/*
+Macro averaged F1 score as metric for crossValidate and bootstrapCI
+*/
+func macroF1(prediction, groundTruth []int) float64 {
+	return newClassificationReport(prediction, groundTruth, nil).MacroAvg.F1
+}
+
+/*
+McNemar's test whether two classifiers have the same error rate on the same test samples
+
+	:parameter
+		*	predictionA, predictionB: predictions of the two classifiers
+		*	groundTruth: ground truth (correct) labels
+		*	exact: true for the exact binomial test (recommended for less than 25 discordant pairs) - false for the chi-square test with continuity correction
+	:return
+		*	result: number of samples only classifier A predicted correctly (exact) or the chi-square statistic and the p-value
+*/
+func mcNemarTest(predictionA, predictionB, groundTruth []int, exact *bool) testResult {
+	assertEqualLengthInt(predictionA, groundTruth)
+	assertEqualLengthInt(predictionB, groundTruth)
+	// discordant pairs
+	onlyA, onlyB := 0, 0
+	for ci, i := range groundTruth {
+		correctA := predictionA[ci] == i
+		correctB := predictionB[ci] == i
+		if correctA && !correctB {
+			onlyA++
+		} else if correctB && !correctA {
+			onlyB++
+		}
+	}
+	n := onlyA + onlyB
+	if n == 0 {
+		return testResult{statistic: 0, pValue: 1}
+	}
+	if *exact {
+		// two sided binomial test with p = 0.5
+		smaller := onlyA
+		if onlyB < smaller {
+			smaller = onlyB
+		}
+		p := 0.0
+		for i := 0; i <= smaller; i++ {
+			lgN, _ := math.Lgamma(float64(n + 1))
+			lgI, _ := math.Lgamma(float64(i + 1))
+			lgNI, _ := math.Lgamma(float64(n - i + 1))
+			p += math.Exp(lgN - lgI - lgNI - float64(n)*math.Ln2)
+		}
+		return testResult{statistic: float64(onlyA), pValue: math.Min(1, 2*p)}
+	}
+	diff := math.Max(math.Abs(float64(onlyA-onlyB))-1, 0)
+	statistic := diff * diff / float64(n)
+	return testResult{statistic: statistic, pValue: chiSquareSF(statistic, 1), df: []float64{1}}
+}
+
+/*
+5x2cv paired t-test (Dietterich 1998) whether two estimators perform equally well - five repetitions of a shuffled 2-fold cross-validation
+
+	:parameter
+		*	estA, estB: the two estimators
+		*	x: vectors representing the data
+		*	y: labels of the data
+		*	metric: function scoring a prediction against the ground truth like multiclassAccuracy
+	:return
+		*	result: t statistic, two sided p-value and 5 degrees of freedom
+*/
+func fiveByTwoCVTest[T label](estA, estB estimator[T], x [][]float64, y []T, metric func(prediction, groundTruth []T) float64) testResult {
+	numFolds := 2
+	shuffle := true
+	firstDiff := 0.0
+	varianceSum := 0.0
+	for i := 0; i < 5; i++ {
+		folds := kFold(len(x), &numFolds, &shuffle)
+		scoresA := crossValidate(estA, x, y, folds, metric).foldScores
+		scoresB := crossValidate(estB, x, y, folds, metric).foldScores
+		diff1 := scoresA[0] - scoresB[0]
+		diff2 := scoresA[1] - scoresB[1]
+		if i == 0 {
+			firstDiff = diff1
+		}
+		mean := (diff1 + diff2) / 2
+		varianceSum += (diff1-mean)*(diff1-mean) + (diff2-mean)*(diff2-mean)
+	}
+	if varianceSum == 0 {
+		return zeroVarianceResult(firstDiff, []float64{5})
+	}
+	t := firstDiff / math.Sqrt(varianceSum/5)
+	return testResult{statistic: t, pValue: studentTTwoSidedP(t, 5), df: []float64{5}}
+}
+
+/*
+Corrected resampled t-test (Nadeau and Bengio 2003) on the fold scores of two estimators that were evaluated on the same folds - the variance is corrected for the overlap of the training sets
+
+	:parameter
+		*	scoresA, scoresB: scores of the two estimators on every fold
+		*	testTrainRatio: number of test samples divided by the number of training samples per fold
+	:return
+		*	result: t statistic, two sided p-value and k - 1 degrees of freedom
+*/
+func correctedResampledTTest(scoresA, scoresB []float64, testTrainRatio *float64) testResult {
+	assertEqualLengthFloat(scoresA, scoresB)
+	k := float64(len(scoresA))
+	if k < 2 {
+		log.Fatalln(fmt.Sprintf("The corrected resampled t-test needs at least 2 folds but got [%d]", len(scoresA)))
+	}
+	diff := make([]float64, len(scoresA))
+	for ci, i := range scoresA {
+		diff[ci] = i - scoresB[ci]
+	}
+	mean, variance := meanSampleVariance(diff)
+	df := k - 1
+	if variance == 0 {
+		return zeroVarianceResult(mean, []float64{df})
+	}
+	t := mean / math.Sqrt((1/k+*testTrainRatio)*variance)
+	return testResult{statistic: t, pValue: studentTTwoSidedP(t, df), df: []float64{df}}
+}
+
+/*
+Compare the cross-validation results of two estimators that were evaluated on the same folds with the corrected resampled t-test
+
+	:parameter
+		*	resultA, resultB: results of crossValidate for the two estimators
+		*	folds: the folds both were evaluated on
+	:return
+		*	result: t statistic, two sided p-value and k - 1 degrees of freedom
+*/
+func compareCVResults(resultA, resultB cvResult, folds []fold) testResult {
+	numTest, numTrain := 0, 0
+	for _, i := range folds {
+		numTest += len(i.testIdx)
+		numTrain += len(i.trainIdx)
+	}
+	testTrainRatio := float64(numTest) / float64(numTrain)
+	return correctedResampledTTest(resultA.foldScores, resultB.foldScores, &testTrainRatio)
+}
+
+/*
+Draw n indices with replacement
+*/
+func bootstrapIndices(n int) []int {
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = rand.Intn(n)
+	}
+	return indices
+}
+
+/*
+Percentile confidence interval of bootstrap estimates
+*/
+func percentileInterval(estimates []float64, confidence *float64) (float64, float64) {
+	sort.Float64s(estimates)
+	alpha := (1 - *confidence) / 2
+	return quantileSorted(estimates, alpha), quantileSorted(estimates, 1-alpha)
+}
+
+/*
+Bootstrap percentile confidence interval of a metric by resampling the test samples
+
+	:parameter
+		*	prediction: predicted labels or values
+		*	groundTruth: ground truth (correct) labels or values
+		*	metric: the metric like multiclassAccuracy, macroF1, maeScore or mseScore
+		*	numResamples: number of bootstrap resamples e.g. 1000
+		*	confidence: confidence level e.g. 0.95
+	:return
+		*	estimate: the metric on all samples
+		*	lower, upper: bounds of the confidence interval
+*/
+func bootstrapCI[T label](prediction, groundTruth []T, metric func(prediction, groundTruth []T) float64, numResamples *int, confidence *float64) (float64, float64, float64) {
+	if pSize, gTSize := len(prediction), len(groundTruth); pSize != gTSize {
+		log.Fatalln(fmt.Sprintf("Prediction size [%d] doesn't match the ground truth size [%d]", pSize, gTSize))
+	}
+	estimates := make([]float64, *numResamples)
+	parallelFor(*numResamples, func(i int) {
+		indices := bootstrapIndices(len(prediction))
+		estimates[i] = metric(subsetRows(prediction, indices), subsetRows(groundTruth, indices))
+	})
+	lower, upper := percentileInterval(estimates, confidence)
+	return metric(prediction, groundTruth), lower, upper
+}
+
+/*
+Bootstrap percentile confidence interval of the difference of a metric between two models evaluated on the same samples - the resamples are paired so that an interval excluding 0 indicates a real difference
+
+	:parameter
+		*	predictionA, predictionB: predictions of the two models
+		*	groundTruth: ground truth (correct) labels or values
+		*	metric: the metric like multiclassAccuracy, macroF1, maeScore or mseScore
+		*	numResamples: number of bootstrap resamples e.g. 1000
+		*	confidence: confidence level e.g. 0.95
+	:return
+		*	difference: metric of A minus metric of B on all samples
+		*	lower, upper: bounds of the confidence interval of the difference
+*/
+func bootstrapDifferenceCI[T label](predictionA, predictionB, groundTruth []T, metric func(prediction, groundTruth []T) float64, numResamples *int, confidence *float64) (float64, float64, float64) {
+	if len(predictionA) != len(groundTruth) || len(predictionB) != len(groundTruth) {
+		log.Fatalln(fmt.Sprintf("Prediction sizes [%d] and [%d] don't match the ground truth size [%d]", len(predictionA), len(predictionB), len(groundTruth)))
+	}
+	estimates := make([]float64, *numResamples)
+	parallelFor(*numResamples, func(i int) {
+		indices := bootstrapIndices(len(groundTruth))
+		truth := subsetRows(groundTruth, indices)
+		estimates[i] = metric(subsetRows(predictionA, indices), truth) - metric(subsetRows(predictionB, indices), truth)
+	})
+	lower, upper := percentileInterval(estimates, confidence)
+	return metric(predictionA, groundTruth) - metric(predictionB, groundTruth), lower, upper
+}
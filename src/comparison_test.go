@@ -0,0 +1,97 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMcNemarTest(t *testing.T) {
+	// A is right on sample 0 only and B on the samples 1 to 9 only - the remaining samples are concordant
+	groundTruth := make([]int, 15)
+	predictionA := make([]int, 15)
+	predictionB := make([]int, 15)
+	for i := 1; i < 10; i++ {
+		predictionA[i] = 1
+	}
+	predictionB[0] = 1
+	predictionA[12], predictionB[12] = 1, 1
+	exact := true
+	result := mcNemarTest(predictionA, predictionB, groundTruth, &exact)
+	// two sided binomial probability of at most 1 success in 10 trials
+	if result.statistic != 1 || math.Abs(result.pValue-22./1024) > 1e-12 {
+		t.Errorf("exact McNemar test %v, want statistic 1 and p-value %g", result, 22./1024)
+	}
+	exact = false
+	result = mcNemarTest(predictionA, predictionB, groundTruth, &exact)
+	// (|1 - 9| - 1)^2 / 10 with a chi-square p-value with 1 df
+	if math.Abs(result.statistic-4.9) > 1e-12 || math.Abs(result.pValue-math.Erfc(math.Sqrt(4.9/2))) > 1e-6 {
+		t.Errorf("chi-square McNemar test %v, want statistic 4.9 and p-value %g", result, math.Erfc(math.Sqrt(4.9/2)))
+	}
+	if result := mcNemarTest(predictionA, predictionA, groundTruth, &exact); result.pValue != 1 {
+		t.Errorf("McNemar test of equal predictions %v, want p-value 1", result)
+	}
+}
+
+func TestCorrectedResampledTTest(t *testing.T) {
+	// differences 0.1 0.2 0.1 with the mean 2 / 15 and sample variance 1 / 300
+	ratio := 0.5
+	result := correctedResampledTTest([]float64{0.8, 0.9, 0.7}, []float64{0.7, 0.7, 0.6}, &ratio)
+	want := 2. / 15 / math.Sqrt((1./3+0.5)/300)
+	// t distribution with 2 df: p = 1 - |t| / sqrt(t^2 + 2)
+	if math.Abs(result.statistic-want) > 1e-9 || math.Abs(result.pValue-(1-want/math.Sqrt(want*want+2))) > 1e-6 || result.df[0] != 2 {
+		t.Errorf("corrected resampled t-test %v, want statistic %g with 2 df", result, want)
+	}
+	// without the correction the statistic is the one of the paired t-test
+	ratio = 0
+	if paired := pairedTTest([]float64{0.8, 0.9, 0.7}, []float64{0.7, 0.7, 0.6}); math.Abs(correctedResampledTTest([]float64{0.8, 0.9, 0.7}, []float64{0.7, 0.7, 0.6}, &ratio).statistic-paired.statistic) > 1e-9 {
+		t.Errorf("uncorrected statistic differs from the paired t-test %v", paired)
+	}
+	numFolds := 5
+	shuffle := false
+	folds := kFold(10, &numFolds, &shuffle)
+	compared := compareCVResults(cvResult{foldScores: []float64{1, 2, 3, 4, 5}}, cvResult{foldScores: []float64{1, 1, 1, 1, 1}}, folds)
+	ratio = 2. / 8
+	if direct := correctedResampledTTest([]float64{1, 2, 3, 4, 5}, []float64{1, 1, 1, 1, 1}, &ratio); compared.statistic != direct.statistic {
+		t.Errorf("comparing the cv results gives %v, want %v with the test train ratio of the folds", compared, direct)
+	}
+	if result := correctedResampledTTest([]float64{0.5, 0.5}, []float64{0.5, 0.5}, &ratio); result.statistic != 0 || result.pValue != 1 {
+		t.Errorf("equal scores give %v, want statistic 0 and p-value 1", result)
+	}
+}
+
+/*
+Classifier that predicts the other of two classes than the wrapped estimator
+*/
+type testInvertedClassifier struct {
+	estimator[int]
+}
+
+func (c testInvertedClassifier) Predict(x [][]float64) []int {
+	prediction := c.estimator.Predict(x)
+	for ci, i := range prediction {
+		prediction[ci] = 1 - i
+	}
+	return prediction
+}
+
+func (c testInvertedClassifier) Clone() estimator[int] {
+	return testInvertedClassifier{c.estimator.Clone()}
+}
+
+func TestFiveByTwoCVTest(t *testing.T) {
+	x, blobs := testBlobs([]int{20, 20}, 2, 73)
+	k := 1
+	distType := "euclidean"
+	scaleDist := false
+	scalerType := "none"
+	classifier := newKNNClassifierModel(&k, &distType, &scaleDist, &scalerType)
+	result := fiveByTwoCVTest[int](classifier, classifier.Clone(), x, blobs, multiclassAccuracy)
+	if result.statistic != 0 || result.pValue != 1 || result.df[0] != 5 {
+		t.Errorf("equal estimators give %v, want statistic 0, p-value 1 and 5 df", result)
+	}
+	// the separated blobs are always classified correctly and always wrong when inverted
+	result = fiveByTwoCVTest[int](classifier, testInvertedClassifier{classifier.Clone()}, x, blobs, multiclassAccuracy)
+	if !math.IsInf(result.statistic, 1) || result.pValue != 0 {
+		t.Errorf("perfect against always wrong estimator gives %v, want an infinite statistic and p-value 0", result)
+	}
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+)
+
+/*
+Correlation functions that can be selected by their name
+*/
+var correlationFunctions = map[string]func([][]float64, *int, *int) float64{
+	"pearson":  corrCoef,
+	"spearman": spearmanCorr,
+	"kendall":  kendallTau,
+}
+
+/*
+Select the correlation function for a given correlation type
+
+	:parameter
+		*	corrType: which correlation should be used
+			-	pearson
+			-	spearman
+			-	kendall
+	:return
+		*	correlationFunction: function calculating the correlation between two columns of a slice
+*/
+func selectCorrelationFunction(corrType *string) func([][]float64, *int, *int) float64 {
+	correlationFunction, ok := correlationFunctions[*corrType]
+	if !ok {
+		fmt.Printf("Using default correlation ['pearson'] instead of the not implementd ['%s']\n", *corrType)
+		correlationFunction = corrCoef
+	}
+	return correlationFunction
+}
+
+/*
+Extract one column of a slice
+
+	:parameter
+		*	inSlice: slice containing the data
+		*	colInd: index (zero indexed) of the column
+	:return
+		*	col: the values of the column
+*/
+func column(inSlice [][]float64, colInd int) []float64 {
+	col := make([]float64, len(inSlice))
+	for ci, i := range inSlice {
+		col[ci] = i[colInd]
+	}
+	return col
+}
+
+/*
+Rank values where tied values get the average of the ranks they span
+
+	:parameter
+		*	values: the values to be ranked
+	:return
+		*	ranks: rank of every value starting at 1
+*/
+func rankAverage(values []float64) []float64 {
+	order := argsort(values)
+	ranks := make([]float64, len(values))
+	for i := 0; i < len(order); {
+		// find the end of the group of tied values
+		j := i + 1
+		for j < len(order) && values[order[j]] == values[order[i]] {
+			j++
+		}
+		// ranks i+1 to j averaged
+		avgRank := float64(i+j+1) / 2
+		for t := i; t < j; t++ {
+			ranks[order[t]] = avgRank
+		}
+		i = j
+	}
+	return ranks
+}
+
+/*
+Calculate the Pearson correlation coefficient between two vectors
+
+	:parameter
+		*	x, y: the vectors
+	:return
+		*	corr: correlation coefficient - NaN if one of the vectors is constant
+*/
+func pearsonVec(x, y []float64) float64 {
+	n := float64(len(x))
+	meanX := *sumFloat64(x) / n
+	meanY := *sumFloat64(y) / n
+	covXY, varX, varY := 0.0, 0.0, 0.0
+	for ci, i := range x {
+		dx := i - meanX
+		dy := y[ci] - meanY
+		covXY += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX < float64EqualityThreshold || varY < float64EqualityThreshold {
+		return math.NaN()
+	}
+	return covXY / math.Sqrt(varX*varY)
+}
+
+/*
+Calculate the Spearman rank correlation coefficient between two vectors as Pearson correlation of their average ranks
+
+	:parameter
+		*	x, y: the vectors
+	:return
+		*	corr: rank correlation coefficient - NaN if one of the vectors is constant
+*/
+func spearmanVec(x, y []float64) float64 {
+	return pearsonVec(rankAverage(x), rankAverage(y))
+}
+
+/*
+Sort values in place with merge sort and count the number of exchanges (pairs with values[i] > values[j] for i < j)
+
+	:parameter
+		*	values: the values to be sorted
+		*	buffer: slice of the same size used for merging
+	:return
+		*	swaps: number of exchanges
+*/
+func mergeSortSwaps(values, buffer []float64) int {
+	n := len(values)
+	if n < 2 {
+		return 0
+	}
+	mid := n / 2
+	swaps := mergeSortSwaps(values[:mid], buffer[:mid]) + mergeSortSwaps(values[mid:], buffer[mid:])
+	i, j, k := 0, mid, 0
+	for i < mid && j < n {
+		if values[j] < values[i] {
+			buffer[k] = values[j]
+			// all remaining values of the left half are bigger
+			swaps += mid - i
+			j++
+		} else {
+			buffer[k] = values[i]
+			i++
+		}
+		k++
+	}
+	k += copy(buffer[k:], values[i:mid])
+	copy(buffer[k:], values[j:n])
+	copy(values, buffer[:n])
+	return swaps
+}
+
+/*
+Count the pairs of tied values in already sorted values
+
+	:parameter
+		*	sortedValues: the sorted values
+	:return
+		*	tiedPairs: number of pairs with equal values
+*/
+func tiedPairs(sortedValues []float64) float64 {
+	pairs := 0.0
+	run := 1.0
+	for i := 1; i <= len(sortedValues); i++ {
+		if i < len(sortedValues) && sortedValues[i] == sortedValues[i-1] {
+			run++
+		} else {
+			pairs += run * (run - 1) / 2
+			run = 1
+		}
+	}
+	return pairs
+}
+
+/*
+Calculate Kendall's tau-b between two vectors in O(n log n) (Knight 1966)
+
+	:parameter
+		*	x, y: the vectors
+	:return
+		*	tau: Kendall's tau-b - NaN if one of the vectors is constant
+*/
+func kendallVec(x, y []float64) float64 {
+	n := len(x)
+	// sort by x and within ties of x by y
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if x[order[i]] != x[order[j]] {
+			return x[order[i]] < x[order[j]]
+		}
+		return y[order[i]] < y[order[j]]
+	})
+	sortedX := make([]float64, n)
+	sortedY := make([]float64, n)
+	for ci, i := range order {
+		sortedX[ci] = x[i]
+		sortedY[ci] = y[i]
+	}
+	// pairs tied in x and pairs tied in x and y
+	tiesX := tiedPairs(sortedX)
+	tiesXY := 0.0
+	run := 1.0
+	for i := 1; i <= n; i++ {
+		if i < n && sortedX[i] == sortedX[i-1] && sortedY[i] == sortedY[i-1] {
+			run++
+		} else {
+			tiesXY += run * (run - 1) / 2
+			run = 1
+		}
+	}
+	// every exchange needed to sort y is a discordant pair
+	swaps := float64(mergeSortSwaps(sortedY, make([]float64, n)))
+	tiesY := tiedPairs(sortedY)
+	totalPairs := float64(n) * float64(n-1) / 2
+	if totalPairs == tiesX || totalPairs == tiesY {
+		return math.NaN()
+	}
+	concordantMinusDiscordant := totalPairs - tiesX - tiesY + tiesXY - 2*swaps
+	return concordantMinusDiscordant / math.Sqrt((totalPairs-tiesX)*(totalPairs-tiesY))
+}
+
+/*
+Calculate the Spearman rank correlation coefficient for two (colIndX and colIndY) columns in inSlice
+
+	:parameter
+		*	inSlice: slice containing the data
+		*	colIndX, colIndY: indices (zero indexed) of the columns for which the correlation should be computed
+	:return
+		*	corr: Spearman rank correlation between data in column colIndX and colIndY
+*/
+func spearmanCorr(inSlice [][]float64, colIndX, colIndY *int) float64 {
+	corr := spearmanVec(column(inSlice, *colIndX), column(inSlice, *colIndY))
+	if math.IsNaN(corr) {
+		log.Fatalln(fmt.Sprintf("Couldn't calculate correlation between feature [%d] and [%d] - one of them is constant", *colIndX, *colIndY))
+	}
+	return corr
+}
+
+/*
+Calculate Kendall's tau-b for two (colIndX and colIndY) columns in inSlice
+
+	:parameter
+		*	inSlice: slice containing the data
+		*	colIndX, colIndY: indices (zero indexed) of the columns for which the correlation should be computed
+	:return
+		*	tau: Kendall's tau-b between data in column colIndX and colIndY
+*/
+func kendallTau(inSlice [][]float64, colIndX, colIndY *int) float64 {
+	tau := kendallVec(column(inSlice, *colIndX), column(inSlice, *colIndY))
+	if math.IsNaN(tau) {
+		log.Fatalln(fmt.Sprintf("Couldn't calculate correlation between feature [%d] and [%d] - one of them is constant", *colIndX, *colIndY))
+	}
+	return tau
+}
+
+/*
+Center and normalize a vector so that the dot product of two normalized vectors is their Pearson correlation
+
+	:parameter
+		*	values: the vector
+	:return
+		*	normalized: (values - mean) / sqrt(sum((values - mean)^2)) - nil if values are constant
+*/
+func normalizeForCorrelation(values []float64) []float64 {
+	mean := *sumFloat64(values) / float64(len(values))
+	normalized := make([]float64, len(values))
+	squareSum := 0.0
+	for ci, i := range values {
+		normalized[ci] = i - mean
+		squareSum += normalized[ci] * normalized[ci]
+	}
+	if squareSum < float64EqualityThreshold {
+		return nil
+	}
+	norm := math.Sqrt(squareSum)
+	for ci := range normalized {
+		normalized[ci] /= norm
+	}
+	return normalized
+}
+
+/*
+Calculate the correlations between all features in one parallel pass over the column-major data
+
+	:parameter
+		*	inSlice: slice containing the data with one vector per sample
+		*	corrType: which correlation should be used
+			-	pearson
+			-	spearman
+			-	kendall
+	:return
+		*	corrMat: symmetric matrix with the correlation between feature i and j at [i][j] - NaN for constant features
+*/
+func CorrelationMatrix(inSlice [][]float64, corrType *string) [][]float64 {
+	columns := columnMajor(inSlice)
+	numFeatures := len(columns)
+	if _, ok := correlationFunctions[*corrType]; !ok {
+		selectCorrelationFunction(corrType)
+		pearson := "pearson"
+		corrType = &pearson
+	}
+	// pairwise correlation of the prepared columns
+	var corr func(i, j int) float64
+	constant := make([]bool, numFeatures)
+	if *corrType == "kendall" {
+		for ci, i := range columns {
+			constant[ci] = normalizeForCorrelation(i) == nil
+		}
+		corr = func(i, j int) float64 {
+			return kendallVec(columns[i], columns[j])
+		}
+	} else {
+		// Spearman is the Pearson correlation of the ranks
+		if *corrType == "spearman" {
+			parallelFor(numFeatures, func(i int) {
+				columns[i] = rankAverage(columns[i])
+			})
+		}
+		parallelFor(numFeatures, func(i int) {
+			columns[i] = normalizeForCorrelation(columns[i])
+		})
+		for ci, i := range columns {
+			constant[ci] = i == nil
+		}
+		corr = func(i, j int) float64 {
+			dot := 0.0
+			for ck, k := range columns[i] {
+				dot += k * columns[j][ck]
+			}
+			return dot
+		}
+	}
+	constantFeatures := []int{}
+	for ci, i := range constant {
+		if i {
+			constantFeatures = append(constantFeatures, ci)
+		}
+	}
+	if len(constantFeatures) > 0 {
+		fmt.Printf("Features %v are constant - their correlations are NaN\n", constantFeatures)
+	}
+
+	corrMat := make([][]float64, numFeatures)
+	for i := range corrMat {
+		corrMat[i] = make([]float64, numFeatures)
+	}
+	parallelFor(numFeatures, func(i int) {
+		if constant[i] {
+			for j := range corrMat[i] {
+				corrMat[i][j] = math.NaN()
+				corrMat[j][i] = math.NaN()
+			}
+			return
+		}
+		corrMat[i][i] = 1
+		for j := i + 1; j < numFeatures; j++ {
+			if constant[j] {
+				continue
+			}
+			iCorr := corr(i, j)
+			corrMat[i][j] = iCorr
+			corrMat[j][i] = iCorr
+		}
+	})
+	return corrMat
+}
+
+/*
+Stop if a correlation matrix contains constant features since their correlation is not defined
+
+	:parameter
+		*	corrMat: correlation matrix as returned by CorrelationMatrix
+	:return
+		None
+*/
+func assertNoConstantFeatures(corrMat [][]float64) {
+	for ci, i := range corrMat {
+		if math.IsNaN(i[ci]) {
+			log.Fatalln(fmt.Sprintf("Feature [%d] is constant - correlation calculation is not possible", ci))
+		}
+	}
+}
+
+/*
+Write a correlation matrix to a csv file with the feature names as header and first column
+
+	:parameter
+		*	corrMat: correlation matrix as returned by CorrelationMatrix
+		*	featureNames: names of the features - nil to use their indices
+		*	filePath: path of the csv file to be created
+	:return
+		None
+*/
+func writeCorrelationMatrixCSV(corrMat [][]float64, featureNames []string, filePath *string) {
+	if featureNames == nil {
+		featureNames = make([]string, len(corrMat))
+		for i := range featureNames {
+			featureNames[i] = strconv.Itoa(i)
+		}
+	}
+	file, err := os.Create(*filePath)
+	if err != nil {
+		log.Fatalln(fmt.Sprintf("Couldn't create file at [%s]\n", *filePath), err)
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	writer.Write(append([]string{""}, featureNames...))
+	for ci, i := range corrMat {
+		line := []string{featureNames[ci]}
+		for _, j := range i {
+			line = append(line, strconv.FormatFloat(j, 'g', -1, 64))
+		}
+		writer.Write(line)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Fatalln(fmt.Sprintf("Couldn't write to csv at [%s]\n", *filePath), err)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestRankAverage(t *testing.T) {
+	got := rankAverage([]float64{30, 10, 20, 20, 50, 20})
+	want := []float64{5, 1, 3, 3, 6, 3}
+	for ci, i := range want {
+		if got[ci] != i {
+			t.Fatalf("ranks %v, want %v", got, want)
+		}
+	}
+}
+
+func TestCorrelationReferenceValues(t *testing.T) {
+	// reference values from SciPy's pearsonr, spearmanr and kendalltau
+	for _, i := range []struct {
+		name string
+		corr func(x, y []float64) float64
+		x, y []float64
+		want float64
+	}{
+		{"pearson", pearsonVec, []float64{43, 21, 25, 42, 57, 59}, []float64{99, 65, 79, 75, 87, 81}, 0.5298089018901744},
+		{"spearman", spearmanVec, []float64{1, 2, 3, 4, 5}, []float64{5, 6, 7, 8, 7}, 0.8207826816681233},
+		{"kendall", kendallVec, []float64{12, 2, 1, 12, 2}, []float64{1, 4, 7, 1, 0}, -0.47140452079103173},
+		{"kendall without ties", kendallVec, []float64{1, 2, 3, 4, 5}, []float64{3, 4, 1, 2, 5}, 0.2},
+	} {
+		if got := i.corr(i.x, i.y); math.Abs(got-i.want) > 1e-12 {
+			t.Errorf("%s: %.16g, want %.16g", i.name, got, i.want)
+		}
+	}
+}
+
+func TestKendallMatchesPairCount(t *testing.T) {
+	// the O(n log n) tau-b has to equal counting all pairs
+	rng := rand.New(rand.NewSource(23))
+	x := make([]float64, 200)
+	y := make([]float64, 200)
+	for i := range x {
+		x[i] = float64(rng.Intn(15))
+		y[i] = float64(rng.Intn(10)) + x[i]/3
+	}
+	concordant, discordant, tiesX, tiesY := 0.0, 0.0, 0.0, 0.0
+	for i := range x {
+		for j := i + 1; j < len(x); j++ {
+			sign := (x[i] - x[j]) * (y[i] - y[j])
+			switch {
+			case sign > 0:
+				concordant++
+			case sign < 0:
+				discordant++
+			}
+			if x[i] == x[j] {
+				tiesX++
+			}
+			if y[i] == y[j] {
+				tiesY++
+			}
+		}
+	}
+	totalPairs := float64(len(x)*(len(x)-1)) / 2
+	want := (concordant - discordant) / math.Sqrt((totalPairs-tiesX)*(totalPairs-tiesY))
+	if got := kendallVec(x, y); math.Abs(got-want) > 1e-12 {
+		t.Errorf("tau-b %.16g, want %.16g", got, want)
+	}
+}
+
+func TestCorrelationConstant(t *testing.T) {
+	x := []float64{1, 2, 3, 4}
+	constant := []float64{2, 2, 2, 2}
+	for name, corr := range map[string]func(x, y []float64) float64{"pearson": pearsonVec, "spearman": spearmanVec, "kendall": kendallVec} {
+		if got := corr(x, constant); !math.IsNaN(got) {
+			t.Errorf("%s with a constant vector is %g, want NaN", name, got)
+		}
+	}
+}
+
+func TestCorrelationMatrixMatchesPairs(t *testing.T) {
+	rng := rand.New(rand.NewSource(29))
+	x := make([][]float64, 50)
+	for i := range x {
+		base := rng.NormFloat64()
+		x[i] = []float64{base, base + rng.NormFloat64()*0.3, float64(rng.Intn(4)), rng.NormFloat64(), 1}
+	}
+	for corrType, corr := range map[string]func(x, y []float64) float64{"pearson": pearsonVec, "spearman": spearmanVec, "kendall": kendallVec} {
+		corrMat := CorrelationMatrix(x, &corrType)
+		for i := 0; i < 5; i++ {
+			for j := 0; j < 5; j++ {
+				want := 1.0
+				if i != j || i == 4 {
+					want = corr(column(x, i), column(x, j))
+				}
+				got := corrMat[i][j]
+				if math.IsNaN(want) != math.IsNaN(got) || math.Abs(got-want) > 1e-9 {
+					t.Errorf("%s: correlation of [%d] and [%d] is %g, want %g", corrType, i, j, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestCorrelationClusteringMatrix(t *testing.T) {
+	rng := rand.New(rand.NewSource(31))
+	x := make([][]float64, 100)
+	for i := range x {
+		a, b := rng.NormFloat64(), rng.NormFloat64()
+		x[i] = []float64{a, b, a + rng.NormFloat64()*0.1, b + rng.NormFloat64()*0.1, -a}
+	}
+	corrType := "pearson"
+	maxIter := 10
+	minCorr := 0.8
+	clusters := hierarchicalCorrelationClusteringMatrix(CorrelationMatrix(x, &corrType), &maxIter, &minCorr)
+	if got := testClusterSizes(clusters); len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Errorf("clusters %v, want {0, 2, 4} and {1, 3}", clusters)
+	}
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+/*
+Types that can be used as labels - int for classification and float64 for regression
+*/
+type label interface {
+	int | float64
+}
+
+/*
+Model that can be trained on features and labels and predict labels for new features
+*/
+type estimator[T label] interface {
+	Fit(x [][]float64, y []T)
+	Predict(x [][]float64) []T
+	Clone() estimator[T]
+}
+
+/*
+Estimator that can compute leave-one-out predictions without refitting for every sample
+*/
+type leaveOneOutEstimator[T label] interface {
+	leaveOneOutPredict(x [][]float64, y []T) []T
+}
+
+/*
+Indices of the samples used for training and testing in one fold
+*/
+type fold struct {
+	trainIdx []int
+	testIdx  []int
+}
+
+/*
+Scores of a cross-validation run
+*/
+type cvResult struct {
+	foldScores []float64
+	mean       float64
+	std        float64
+}
+
+/*
+Format the mean, standard deviation and the scores of the individual folds
+*/
+func (r cvResult) String() string {
+	scores := make([]string, len(r.foldScores))
+	for ci, i := range r.foldScores {
+		scores[ci] = fmt.Sprintf("%.4f", i)
+	}
+	return fmt.Sprintf("%.4f +/- %.4f [%s]", r.mean, r.std, strings.Join(scores, ", "))
+}
+
+/*
+Create the indices 0 to n-1, optionally in random order
+
+	:parameter
+		*	n: number of indices
+		*	shuffle: true to shuffle the indices
+	:return
+		*	indices: the (shuffled) indices
+*/
+func permutation(n int, shuffle bool) []int {
+	if shuffle {
+		return rand.Perm(n)
+	}
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = i
+	}
+	return indices
+}
+
+/*
+Create folds where the training indices are all indices that are not in the test set of the fold
+
+	:parameter
+		*	n: number of samples
+		*	testSets: indices of the test samples for each fold
+	:return
+		*	folds: the folds with their training and test indices
+*/
+func foldsFromTestSets(n int, testSets [][]int) []fold {
+	folds := make([]fold, len(testSets))
+	for ci, i := range testSets {
+		inTest := make([]bool, n)
+		for _, j := range i {
+			inTest[j] = true
+		}
+		trainIdx := make([]int, 0, n-len(i))
+		for j := 0; j < n; j++ {
+			if !inTest[j] {
+				trainIdx = append(trainIdx, j)
+			}
+		}
+		testIdx := append([]int{}, i...)
+		sort.Ints(testIdx)
+		folds[ci] = fold{trainIdx: trainIdx, testIdx: testIdx}
+	}
+	return folds
+}
+
+/*
+Split n samples into k folds of (nearly) equal size
+
+	:parameter
+		*	n: number of samples
+		*	k: number of folds
+		*	shuffle: true to shuffle the samples before splitting
+	:return
+		*	folds: the k folds
+*/
+func kFold(n int, k *int, shuffle *bool) []fold {
+	if *k < 2 || *k > n {
+		log.Fatalln(fmt.Sprintf("Number of folds [%d] has to be between 2 and the number of samples [%d]", *k, n))
+	}
+	indices := permutation(n, *shuffle)
+	testSets := make([][]int, *k)
+	start := 0
+	for i := 0; i < *k; i++ {
+		// the first n % k folds get one sample more
+		foldSize := n / *k
+		if i < n%*k {
+			foldSize++
+		}
+		testSets[i] = indices[start : start+foldSize]
+		start += foldSize
+	}
+	return foldsFromTestSets(n, testSets)
+}
+
+/*
+Split samples into k folds where each fold has (nearly) the same class distribution as the whole data set
+
+	:parameter
+		*	y: classes of all samples
+		*	k: number of folds
+		*	shuffle: true to shuffle the samples of each class before splitting
+	:return
+		*	folds: the k folds
+*/
+func stratifiedKFold(y []int, k *int, shuffle *bool) []fold {
+	n := len(y)
+	if *k < 2 || *k > n {
+		log.Fatalln(fmt.Sprintf("Number of folds [%d] has to be between 2 and the number of samples [%d]", *k, n))
+	}
+	// indices of the samples of each class
+	classMembers := make(map[int][]int)
+	for ci, i := range y {
+		classMembers[i] = append(classMembers[i], ci)
+	}
+	classes := make([]int, 0, len(classMembers))
+	for i := range classMembers {
+		classes = append(classes, i)
+	}
+	sort.Ints(classes)
+	// distribute the members of the classes one after another over the folds
+	testSets := make([][]int, *k)
+	pos := 0
+	for _, i := range classes {
+		members := classMembers[i]
+		if len(members) < *k {
+			fmt.Printf("Class [%d] has only [%d] members which is less than the number of folds [%d]\n", i, len(members), *k)
+		}
+		if *shuffle {
+			rand.Shuffle(len(members), func(a, b int) {
+				members[a], members[b] = members[b], members[a]
+			})
+		}
+		for _, j := range members {
+			testSets[pos%*k] = append(testSets[pos%*k], j)
+			pos++
+		}
+	}
+	return foldsFromTestSets(n, testSets)
+}
+
+/*
+Repeat shuffled k-fold splitting several times
+
+	:parameter
+		*	n: number of samples
+		*	k: number of folds
+		*	repeats: how often the k-fold splitting should be repeated
+	:return
+		*	folds: k * repeats folds
+*/
+func repeatedKFold(n int, k *int, repeats *int) []fold {
+	shuffle := true
+	folds := []fold{}
+	for i := 0; i < *repeats; i++ {
+		folds = append(folds, kFold(n, k, &shuffle)...)
+	}
+	return folds
+}
+
+/*
+Create one fold per sample where this sample is the only test sample
+
+	:parameter
+		*	n: number of samples
+	:return
+		*	folds: n folds
+*/
+func leaveOneOut(n int) []fold {
+	testSets := make([][]int, n)
+	for i := range testSets {
+		testSets[i] = []int{i}
+	}
+	return foldsFromTestSets(n, testSets)
+}
+
+/*
+Summarize the scores of all folds
+
+	:parameter
+		*	foldScores: score of each fold
+	:return
+		*	result: the scores with their mean and standard deviation
+*/
+func newCVResult(foldScores []float64) cvResult {
+	mean, std := meanStd(foldScores)
+	return cvResult{foldScores: foldScores, mean: mean, std: std}
+}
+
+/*
+Mean absolute error as metric for crossValidate
+*/
+func maeScore(prediction, groundTruth []float64) float64 {
+	return *mae(prediction, groundTruth)
+}
+
+/*
+Mean squared error as metric for crossValidate
+*/
+func mseScore(prediction, groundTruth []float64) float64 {
+	return *mse(prediction, groundTruth)
+}
+
+/*
+Cross-validate an estimator by fitting a copy of it on the training part of every fold in parallel and scoring its prediction on the test part
+
+	:parameter
+		*	est: the estimator to be evaluated
+		*	x: vectors representing the data
+		*	y: labels of the data
+		*	folds: the folds as returned by kFold, stratifiedKFold, repeatedKFold, leaveOneOut or timeSeriesSplit
+		*	metric: function scoring a prediction against the ground truth like multiclassAccuracy, maeScore or mseScore
+	:return
+		*	result: score of each fold and their mean and standard deviation
+*/
+func crossValidate[T label](est estimator[T], x [][]float64, y []T, folds []fold, metric func(prediction, groundTruth []T) float64) cvResult {
+	if xSize, ySize := len(x), len(y); xSize != ySize {
+		log.Fatalln(fmt.Sprintf("Size of x [%d] not equal to size of y [%d]", xSize, ySize))
+	}
+	foldScores := make([]float64, len(folds))
+	parallelFor(len(folds), func(i int) {
+		model := est.Clone()
+		model.Fit(subsetRows(x, folds[i].trainIdx), subsetRows(y, folds[i].trainIdx))
+		pred := model.Predict(subsetRows(x, folds[i].testIdx))
+		foldScores[i] = metric(pred, subsetRows(y, folds[i].testIdx))
+	})
+	return newCVResult(foldScores)
+}
+
+/*
+Leave-one-out cross-validation that uses the fast leave-one-out prediction of the estimator if it has one
+
+	:parameter
+		*	est: the estimator to be evaluated
+		*	x: vectors representing the data
+		*	y: labels of the data
+		*	metric: function scoring a prediction against the ground truth like multiclassAccuracy, maeScore or mseScore
+	:return
+		*	result: score of each left out sample and their mean and standard deviation
+*/
+func leaveOneOutCV[T label](est estimator[T], x [][]float64, y []T, metric func(prediction, groundTruth []T) float64) cvResult {
+	looEst, ok := est.(leaveOneOutEstimator[T])
+	if !ok {
+		return crossValidate(est, x, y, leaveOneOut(len(x)), metric)
+	}
+	pred := looEst.leaveOneOutPredict(x, y)
+	foldScores := make([]float64, len(pred))
+	for i := range pred {
+		foldScores[i] = metric(pred[i:i+1], y[i:i+1])
+	}
+	return newCVResult(foldScores)
+}
+
+/*
+Walk-forward splitting of time ordered samples where every fold tests on the samples following its training window
+
+	:parameter
+		*	n: number of samples
+		*	nSplits: number of folds
+		*	testSize: number of test samples per fold - 0 to use n / (nSplits + 1)
+		*	gap: number of samples between the end of the training window and the start of the test samples
+		*	maxTrainSize: maximum size of the training window - 0 for an expanding window that always starts at the first sample
+	:return
+		*	folds: the nSplits folds in chronological order
+*/
+func timeSeriesSplit(n int, nSplits *int, testSize *int, gap *int, maxTrainSize *int) []fold {
+	if *nSplits < 1 {
+		log.Fatalln(fmt.Sprintf("Number of splits [%d] has to be at least 1", *nSplits))
+	}
+	foldSize := *testSize
+	if foldSize <= 0 {
+		foldSize = n / (*nSplits + 1)
+	}
+	firstTestStart := n - *nSplits*foldSize
+	if firstTestStart-*gap <= 0 || foldSize == 0 {
+		log.Fatalln(fmt.Sprintf("Too many splits [%d] with test size [%d] and gap [%d] for [%d] samples", *nSplits, foldSize, *gap, n))
+	}
+	folds := make([]fold, *nSplits)
+	for i := 0; i < *nSplits; i++ {
+		testStart := firstTestStart + i*foldSize
+		trainEnd := testStart - *gap
+		trainStart := 0
+		if *maxTrainSize > 0 && trainEnd-*maxTrainSize > 0 {
+			trainStart = trainEnd - *maxTrainSize
+		}
+		folds[i] = fold{
+			trainIdx: permutation(trainEnd, false)[trainStart:],
+			testIdx:  permutation(testStart+foldSize, false)[testStart:],
+		}
+	}
+	return folds
+}
+
+/*
+Create a forecasting data set from a time series where the features of each sample are the preceding values
+
+	:parameter
+		*	series: the time ordered values
+		*	lags: number of preceding values used as features
+		*	horizon: how many steps ahead the target lies (1 for the next value)
+	:return
+		*	features: the lagged values for each sample (oldest first)
+		*	targets: the value horizon steps after the last lagged value
+*/
+func laggedFeatures(series []float64, lags *int, horizon *int) ([][]float64, []float64) {
+	numSamples := len(series) - *lags - *horizon + 1
+	if *lags < 1 || *horizon < 1 || numSamples < 1 {
+		log.Fatalln(fmt.Sprintf("Series of length [%d] is too short for [%d] lags and a horizon of [%d]", len(series), *lags, *horizon))
+	}
+	features := make([][]float64, numSamples)
+	targets := make([]float64, numSamples)
+	for i := 0; i < numSamples; i++ {
+		features[i] = append([]float64{}, series[i:i+*lags]...)
+		targets[i] = series[i+*lags+*horizon-1]
+	}
+	return features, targets
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+/*
+Check that the test sets of the folds are disjoint, cover all samples and that every training set is the complement of its test set
+*/
+func testPartitionFolds(t *testing.T, name string, n int, folds []fold) {
+	t.Helper()
+	seen := make([]int, n)
+	for ci, i := range folds {
+		inTest := make([]bool, n)
+		for _, j := range i.testIdx {
+			seen[j]++
+			inTest[j] = true
+		}
+		if len(i.trainIdx)+len(i.testIdx) != n {
+			t.Errorf("%s: fold %d has %d training and %d test samples, want %d in total", name, ci, len(i.trainIdx), len(i.testIdx), n)
+		}
+		for _, j := range i.trainIdx {
+			if inTest[j] {
+				t.Errorf("%s: sample %d is in the training and the test set of fold %d", name, j, ci)
+			}
+		}
+	}
+	for ci, i := range seen {
+		if i != 1 {
+			t.Errorf("%s: sample %d is in %d test sets, want 1", name, ci, i)
+		}
+	}
+}
+
+func TestKFoldPartitions(t *testing.T) {
+	k := 4
+	for _, shuffle := range []bool{false, true} {
+		folds := kFold(10, &k, &shuffle)
+		if len(folds) != k {
+			t.Fatalf("%d folds, want %d", len(folds), k)
+		}
+		testPartitionFolds(t, "kFold", 10, folds)
+		// the first 10 % 4 folds get one sample more
+		for ci, i := range []int{3, 3, 2, 2} {
+			if len(folds[ci].testIdx) != i {
+				t.Errorf("shuffle %t: fold %d has %d test samples, want %d", shuffle, ci, len(folds[ci].testIdx), i)
+			}
+		}
+	}
+	testPartitionFolds(t, "leaveOneOut", 7, leaveOneOut(7))
+	repeats := 3
+	folds := repeatedKFold(10, &k, &repeats)
+	if len(folds) != k*repeats {
+		t.Fatalf("%d repeated folds, want %d", len(folds), k*repeats)
+	}
+	for i := 0; i < repeats; i++ {
+		testPartitionFolds(t, "repeatedKFold", 10, folds[i*k:(i+1)*k])
+	}
+}
+
+func TestStratifiedKFold(t *testing.T) {
+	// 12 samples of class 0, 6 of class 1 and 3 of class 2
+	y := []int{}
+	for ci, i := range []int{12, 6, 3} {
+		for j := 0; j < i; j++ {
+			y = append(y, ci)
+		}
+	}
+	k := 3
+	shuffle := true
+	folds := stratifiedKFold(y, &k, &shuffle)
+	testPartitionFolds(t, "stratifiedKFold", len(y), folds)
+	for ci, i := range folds {
+		counts := make([]int, 3)
+		for _, j := range i.testIdx {
+			counts[y[j]]++
+		}
+		if counts[0] != 4 || counts[1] != 2 || counts[2] != 1 {
+			t.Errorf("fold %d has class counts %v, want [4 2 1]", ci, counts)
+		}
+	}
+}
+
+func TestLeaveOneOutCVMatchesRefitting(t *testing.T) {
+	// the fast leave-one-out prediction has to equal refitting without every sample
+	x, blobs := testBlobs([]int{15, 15}, 2, 71)
+	// overlapping blobs so that some samples are misclassified
+	for ci := range x {
+		x[ci][0] /= 4
+	}
+	yReg := make([]float64, len(x))
+	for ci, i := range x {
+		yReg[ci] = i[0] + i[1]
+	}
+	k := 1
+	distType := "euclidean"
+	scaleDist := false
+	scalerType := "none"
+	classifier := newKNNClassifierModel(&k, &distType, &scaleDist, &scalerType)
+	fast := leaveOneOutCV[int](classifier, x, blobs, multiclassAccuracy)
+	refit := crossValidate[int](classifier, x, blobs, leaveOneOut(len(x)), multiclassAccuracy)
+	if fast.mean != refit.mean {
+		t.Errorf("leave-one-out accuracy %g, refitting gives %g", fast.mean, refit.mean)
+	}
+	k = 3
+	regressor := newKNNRegressorModel(&k, &distType, &scaleDist, &scalerType)
+	fastReg := leaveOneOutCV[float64](regressor, x, yReg, maeScore)
+	refitReg := crossValidate[float64](regressor, x, yReg, leaveOneOut(len(x)), maeScore)
+	if math.Abs(fastReg.mean-refitReg.mean) > 1e-12 {
+		t.Errorf("leave-one-out MAE %g, refitting gives %g", fastReg.mean, refitReg.mean)
+	}
+}
+
+func TestArgmaxClassTies(t *testing.T) {
+	// ties have to go to the smallest class whatever the map order is
+	for i := 0; i < 20; i++ {
+		if got := argmaxClass(map[int]float64{4: 0.4, 2: 0.4, 7: 0.2, 3: 0.4}); got != 2 {
+			t.Fatalf("argmax of a tie is %d, want 2", got)
+		}
+	}
+}
+
+func TestTimeSeriesSplit(t *testing.T) {
+	nSplits := 3
+	testSize := 0
+	gap := 1
+	maxTrainSize := 0
+	// 10 / (3 + 1) = 2 test samples per fold so that the first test set starts at 10 - 3 * 2 = 4
+	folds := timeSeriesSplit(10, &nSplits, &testSize, &gap, &maxTrainSize)
+	want := []fold{
+		{trainIdx: []int{0, 1, 2}, testIdx: []int{4, 5}},
+		{trainIdx: []int{0, 1, 2, 3, 4}, testIdx: []int{6, 7}},
+		{trainIdx: []int{0, 1, 2, 3, 4, 5, 6}, testIdx: []int{8, 9}},
+	}
+	testEqualFolds(t, "expanding", folds, want)
+	gap = 0
+	maxTrainSize = 3
+	testSize = 1
+	folds = timeSeriesSplit(10, &nSplits, &testSize, &gap, &maxTrainSize)
+	want = []fold{
+		{trainIdx: []int{4, 5, 6}, testIdx: []int{7}},
+		{trainIdx: []int{5, 6, 7}, testIdx: []int{8}},
+		{trainIdx: []int{6, 7, 8}, testIdx: []int{9}},
+	}
+	testEqualFolds(t, "sliding", folds, want)
+}
+
+/*
+Check that the folds have exactly the wanted training and test indices
+*/
+func testEqualFolds(t *testing.T, name string, got, want []fold) {
+	t.Helper()
+	equal := func(a, b []int) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for ci, i := range a {
+			if b[ci] != i {
+				return false
+			}
+		}
+		return true
+	}
+	if len(got) != len(want) {
+		t.Fatalf("%s: %d folds, want %d", name, len(got), len(want))
+	}
+	for ci, i := range want {
+		if !equal(got[ci].trainIdx, i.trainIdx) || !equal(got[ci].testIdx, i.testIdx) {
+			t.Errorf("%s: fold %d is %v, want %v", name, ci, got[ci], i)
+		}
+	}
+}
+
+func TestLaggedFeatures(t *testing.T) {
+	series := []float64{1, 2, 3, 4, 5, 6}
+	lags := 2
+	horizon := 2
+	features, targets := laggedFeatures(series, &lags, &horizon)
+	wantFeatures := [][]float64{{1, 2}, {2, 3}, {3, 4}}
+	wantTargets := []float64{4, 5, 6}
+	if len(features) != len(wantFeatures) {
+		t.Fatalf("%d samples, want %d", len(features), len(wantFeatures))
+	}
+	for ci, i := range wantFeatures {
+		if features[ci][0] != i[0] || features[ci][1] != i[1] || targets[ci] != wantTargets[ci] {
+			t.Errorf("sample %d is %v -> %g, want %v -> %g", ci, features[ci], targets[ci], i, wantTargets[ci])
+		}
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"os"
@@ -53,29 +54,124 @@ Creates a scaler to scale individual features to be in the range between 0 an 1
 		* scaler: function that scales a slice based on the minimum and maximum values of features in inSlice [(x-xmin)/(xmax-xmin)]
 */
 func minMaxScaler(inSlice [][]float64) func([][]float64) {
-	vectorSize := len(inSlice[0])
-	// storage for the min and max values for each feature (column)
-	minVals := make([]float64, vectorSize)
-	maxVals := make([]float64, vectorSize)
-	for ci, i := range inSlice[0] {
-		minVals[ci] = i
-		maxVals[ci] = i
-	}
-	// find biggest / smallest values for each feature
-	for _, i := range inSlice {
-		for cj, j := range i {
-			minVals[cj] = math.Min(minVals[cj], j)
-			maxVals[cj] = math.Max(maxVals[cj], j)
+	return minMaxScalerFromMoments(columnMoments(inSlice))
+}
+
+/*
+Creates a min max scaler from already accumulated statistics of the features
+
+	:parameter
+		* moments: accumulated statistics of every feature
+	:return
+		* scaler: function that scales a slice based on the minimum and maximum values of the features [(x-xmin)/(xmax-xmin)] - features without range are scaled by [x-xmin]
+*/
+func minMaxScalerFromMoments(moments []welford) func([][]float64) {
+	minVals := make([]float64, len(moments))
+	maxVals := make([]float64, len(moments))
+	for ci, i := range moments {
+		minVals[ci], maxVals[ci] = i.min, i.max
+	}
+	return func(sliceToScale [][]float64) {
+		for _, i := range sliceToScale {
+			for cj := range i {
+				if maxVals[cj] > minVals[cj] {
+					i[cj] = (i[cj] - minVals[cj]) / (maxVals[cj] - minVals[cj])
+				} else {
+					// constant features are only shifted to 0
+					i[cj] = i[cj] - minVals[cj]
+				}
+			}
 		}
 	}
+}
+
+/*
+Creates a scaler to scale individual features to have a mean of 0 and a standard deviation of 1
+
+	:parameter
+		* inSlice: the data that should be scaled where each vector represents one data point
+	:return
+		* scaler: function that scales a slice based on the mean and standard deviation of features in inSlice [(x-mean)/std]
+*/
+func standardScaler(inSlice [][]float64) func([][]float64) {
+	return standardScalerFromMoments(columnMoments(inSlice))
+}
+
+/*
+Creates a standard scaler from already accumulated statistics of the features
+
+	:parameter
+		* moments: accumulated statistics of every feature
+	:return
+		* scaler: function that scales a slice based on the mean and (population) standard deviation of the features [(x-mean)/std]
+*/
+func standardScalerFromMoments(moments []welford) func([][]float64) {
+	means := make([]float64, len(moments))
+	stds := make([]float64, len(moments))
+	for ci, i := range moments {
+		means[ci], stds[ci] = i.mean, i.std(0)
+	}
 	return func(sliceToScale [][]float64) {
-		for ci, i := range inSlice {
+		for _, i := range sliceToScale {
 			for cj := range i {
-				sliceToScale[ci][cj] = (sliceToScale[ci][cj] - minVals[cj]) / (maxVals[cj] - minVals[cj])
+				if stds[cj] > 0 {
+					i[cj] = (i[cj] - means[cj]) / stds[cj]
+				} else {
+					// constant features are only centered
+					i[cj] = i[cj] - means[cj]
+				}
 			}
 		}
 	}
 }
+
+/*
+Accumulate the statistics of every feature (column) of a slice
+
+	:parameter
+		* inSlice: the data where each vector represents one data point
+	:return
+		* moments: statistics of every feature - NaN values are skipped
+*/
+func columnMoments(inSlice [][]float64) []welford {
+	moments := make([]welford, len(inSlice[0]))
+	for _, i := range inSlice {
+		for cj, j := range i {
+			moments[cj].add(j)
+		}
+	}
+	return moments
+}
+
+/*
+Scalers that can be selected by their name
+*/
+var scalerFunctions = map[string]func([][]float64) func([][]float64){
+	"minmax":   minMaxScaler,
+	"standard": standardScaler,
+}
+
+/*
+Select the function that creates a scaler for a given scaler type
+
+	:parameter
+		* scalerType: which scaler should be used
+			- none
+			- minmax
+			- standard
+	:return
+		* newScaler: function that creates a scaler from the data it is given - nil for no scaling
+*/
+func selectScaler(scalerType *string) func([][]float64) func([][]float64) {
+	if *scalerType == "none" || *scalerType == "" {
+		return nil
+	}
+	newScaler, ok := scalerFunctions[*scalerType]
+	if !ok {
+		fmt.Printf("Not scaling the features since the scaler ['%s'] is not implemented\n", *scalerType)
+	}
+	return newScaler
+}
 /*
 Generate training data from a give csv file and scale it 
 	:parameter
@@ -83,16 +179,17 @@ Generate training data from a give csv file and scale it
 		* testFrac: how much of the data should be used for testing (between 0 and 1)
 		* catConv: true to convert categorical data to integer labels for the labels - not needed when labels are already integers in the csv
 		* firstLineLabels: true if the first line in the csv file is a header
-		* useScaler: true to scale the features to be within the range of 0 to 1
+		* useScaler: true to scale the features to be within the range of 0 to 1 of the training data
+		* shuffle: true to shuffle the data before splitting - false keeps the order of the file so that the last lines are the test data (e.g. for time series)
 	:return
 		* trainDSFeatures: training features
 		* trainDSLabel: training labels
 		* testDSFeatures: test features
 		* testDSLabel: test labels
 		* labelMap: map to convert that was used to convert string labels to int labels
-		* &scaler: the scaler function used to scale the data
+		* &scaler: the scaler function used to scale the data - fitted on the training split only so that the test data stays unseen
 		*/
-func genTrainTestData(filePath *string, testFrac *float64, catConv *bool, firstLineLabels *bool, useScaler *bool) ([][]float64, []int, [][]float64, []int, map[string]int, *func([][]float64)) {
+func genTrainTestData(filePath *string, testFrac *float64, catConv *bool, firstLineLabels *bool, useScaler *bool, shuffle *bool) ([][]float64, []int, [][]float64, []int, map[string]int, *func([][]float64)) {
 	// read raw csv
 	_, lines := readCsvFile(filePath, firstLineLabels)
 	// number of lines in the csv
@@ -150,17 +247,20 @@ func genTrainTestData(filePath *string, testFrac *float64, catConv *bool, firstL
 	for ci, i := range labels {
 		labelsInt[ci] = labelMap[i]
 	}
-	// scale all features to be within 0, 1
-	scaler := minMaxScaler(features)
-	if *useScaler {
-		scaler(features)
-	}
 	// randomly shuffle the dataset
-	shuffleDatasetFloatString(features, labelsInt)
+	if *shuffle {
+		shuffleDatasetFloatString(features, labelsInt)
+	}
 	// split the dataset
 	border := int(float64(numLines) * *testFrac)
 	trainDSFeatures, trainDSLabel := features[:border], labelsInt[:border]
 	testDSFeatures, testDSLabel := features[border:], labelsInt[border:]
+	// scale all features to be within 0, 1 based on the training data only so that no information of the test data leaks into the training
+	scaler := minMaxScaler(trainDSFeatures)
+	if *useScaler {
+		scaler(trainDSFeatures)
+		scaler(testDSFeatures)
+	}
 	return trainDSFeatures, trainDSLabel, testDSFeatures, testDSLabel, labelMap, &scaler
 }
 
@@ -175,56 +275,71 @@ Search for features that do not change and create a new csv only containing non
 		* newSlice: inSlice with removed constant columns
 */
 func nonConstantCSV(oldFilePath, newFilePath *string, header *bool) {
-	oldHeader, oldCSV := readCsvFile(oldFilePath, header)
-	// number of data points int the slice
-	sliceSize := len(oldCSV)
-	// number of features per data point
-	numFeatures := len(oldCSV[0])
+	// find the constant features in a first pass without reading the whole file into memory
+	chunkSize := 10000
+	oldHeader, constant := streamConstantColumns(oldFilePath, header, &chunkSize)
+	numFeatures := len(constant)
 	constantFeatures := []int{}
 	notConstantFeatures := []int{}
-	// slice containing the non constant data
-	newSlice := make([][]string, sliceSize)
-	for f:=0;f<numFeatures;f++{
-		firstVal := oldCSV[0][f]
-		constant := true
-		for i:=1;i<sliceSize;i++{
-			// if a different entry to the first entry is found -> not constant
-			if oldCSV[i][f] != firstVal {
-				constant = false
-			}
-		} 
-		if constant {
-			constantFeatures = append(constantFeatures, f)
+	for ci, i := range constant {
+		if i {
+			constantFeatures = append(constantFeatures, ci)
 		} else {
-			notConstantFeatures = append(notConstantFeatures, f)
-			for cj, j := range oldCSV {
-				newSlice[cj] = append(newSlice[cj], j[f])
-			}
+			notConstantFeatures = append(notConstantFeatures, ci)
 		}
 	}
-	
 	// header of the non constant features
-	newHeader := make([]string, len(notConstantFeatures))
-	for ci, i := range notConstantFeatures {
-		newHeader[ci] = oldHeader[i]
+	newHeader := []string{}
+	if *header {
+		for _, i := range notConstantFeatures {
+			newHeader = append(newHeader, oldHeader[i])
+		}
+	}
+	oldFile, err := os.Open(*oldFilePath)
+	if err != nil {
+		log.Fatalln(fmt.Sprintf("Unable to open input file [%s]\n", *oldFilePath), err)
+	}
+	defer oldFile.Close()
+	csvReader := csv.NewReader(oldFile)
+	if *header {
+		csvReader.Read()
 	}
 	// create a file
-    file, err := os.Create(*newFilePath)
-    if err != nil {
-        log.Fatalln(fmt.Sprintf("Couldn't create file at [%s]\n", *newFilePath), err)
-    }
-	// write to file
-    defer file.Close()
+	file, err := os.Create(*newFilePath)
+	if err != nil {
+		log.Fatalln(fmt.Sprintf("Couldn't create file at [%s]\n", *newFilePath), err)
+	}
+	// copy the non constant features line by line
+	defer file.Close()
 	writer := csv.NewWriter(file)
-	writer.Write(newHeader)
-	writer.WriteAll(newSlice)
-	defer writer.Flush()
+	if *header {
+		writer.Write(newHeader)
+	}
+	newLine := make([]string, len(notConstantFeatures))
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalln(fmt.Sprintf("Unable to parse file as CSV for [%s]\n", *oldFilePath), err)
+		}
+		for ci, i := range notConstantFeatures {
+			newLine[ci] = record[i]
+		}
+		writer.Write(newLine)
+	}
+	writer.Flush()
 	if err := writer.Error(); err != nil {
 		log.Fatalln(fmt.Sprintf("Couldn't write to csv at [%s]\n", *newFilePath), err)
 	}
 	fmt.Println("**log**")
 	fmt.Printf("From [%d] [%d] features were removed\nRemoved features:\n", numFeatures, len(constantFeatures))
 	for _, i := range constantFeatures {
-		fmt.Printf("%s, ", oldHeader[i])
+		if *header {
+			fmt.Printf("%s, ", oldHeader[i])
+		} else {
+			fmt.Printf("%d, ", i)
+		}
 	}
 }
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenTrainTestDataUnshuffled(t *testing.T) {
+	// without shuffling the last lines of the file are the test data
+	filePath := filepath.Join(t.TempDir(), "series.csv")
+	content := "label,a,b\n0,1,10\n1,2,10\n0,3,10\n1,4,10\n0,5,20\n"
+	if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	testFrac := 0.6
+	catConv := false
+	firstLineLabels := true
+	useScaler := true
+	shuffle := false
+	trainX, trainY, testX, testY, _, _ := genTrainTestData(&filePath, &testFrac, &catConv, &firstLineLabels, &useScaler, &shuffle)
+	if len(trainX) != 3 || len(testX) != 2 || trainY[2] != 0 || testY[0] != 1 {
+		t.Fatalf("split into %v %v and %v %v, want the first 3 lines for training", trainX, trainY, testX, testY)
+	}
+	// the scaler only knows the training lines - the first feature ranges from 1 to 3 and the second is constant
+	want := [][]float64{{1.5, 0}, {2, 10}}
+	for ci, i := range want {
+		if testX[ci][0] != i[0] || testX[ci][1] != i[1] {
+			t.Errorf("scaled test features %v, want %v", testX, want)
+			break
+		}
+	}
+}
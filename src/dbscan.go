@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+)
+
+/*
+DBSCAN density based clustering (Ester et al. 1996) - clusters are connected dense regions and vectors in sparse regions are noise
+*/
+type dbscan struct {
+	eps      float64
+	minPts   int
+	distType string
+	// set by Fit
+	x           [][]float64
+	index       *neighborIndex
+	labels      []int
+	core        []bool
+	numClusters int
+}
+
+/*
+Create an unfitted DBSCAN clustering
+
+	:parameter
+		*	eps: radius of the neighborhood of a vector
+		*	minPts: minimum number of vectors in the neighborhood (including the vector itself) for a vector to be a core point
+		*	distType: which distance metric should be used (euclidean, manhattan, hamming, braycurtis)
+	:return
+		*	model: the unfitted model
+*/
+func newDBSCAN(eps *float64, minPts *int, distType *string) *dbscan {
+	if *eps <= 0 || *minPts < 1 {
+		log.Fatalln(fmt.Sprintf("eps [%f] has to be positive and minPts [%d] at least 1", *eps, *minPts))
+	}
+	chosenDist := *distType
+	if _, ok := distanceFunctions[chosenDist]; !ok {
+		selectDistanceFunction(distType)
+		chosenDist = "euclidean"
+	}
+	return &dbscan{eps: *eps, minPts: *minPts, distType: chosenDist}
+}
+
+/*
+Cluster the data - core points have at least minPts vectors within eps, border points are within eps of a core point and all other vectors are noise (label -1)
+
+	:parameter
+		*	x: vectors that should be clustered
+	:return
+		None
+*/
+func (m *dbscan) Fit(x [][]float64) {
+	m.x = x
+	m.index = newNeighborIndex(x, &m.distType)
+	// region queries of all vectors in parallel
+	neighbors := make([][]int, len(x))
+	parallelFor(len(x), func(i int) {
+		neighbors[i], _ = m.index.radiusQuery(x[i], m.eps)
+	})
+	m.core = make([]bool, len(x))
+	for ci, i := range neighbors {
+		m.core[ci] = len(i) >= m.minPts
+	}
+	m.labels = make([]int, len(x))
+	for i := range m.labels {
+		m.labels[i] = -1
+	}
+	m.numClusters = 0
+	for ci := range x {
+		if !m.core[ci] || m.labels[ci] != -1 {
+			continue
+		}
+		// expand the cluster from this core point - only core points extend it
+		m.labels[ci] = m.numClusters
+		queue := []int{ci}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, i := range neighbors[current] {
+				if m.labels[i] != -1 {
+					continue
+				}
+				m.labels[i] = m.numClusters
+				if m.core[i] {
+					queue = append(queue, i)
+				}
+			}
+		}
+		m.numClusters++
+	}
+}
+
+/*
+Cluster the data and return the cluster of every vector
+
+	:parameter
+		*	x: vectors that should be clustered
+	:return
+		*	labels: cluster of every vector - -1 for noise
+*/
+func (m *dbscan) FitPredict(x [][]float64) []int {
+	m.Fit(x)
+	return m.labels
+}
+
+/*
+Type of every vector of the fitted data
+
+	:parameter
+		None
+	:return
+		*	pointTypes: core, border or noise for every vector
+*/
+func (m *dbscan) pointTypes() []string {
+	pointTypes := make([]string, len(m.labels))
+	for ci, i := range m.labels {
+		switch {
+		case m.core[ci]:
+			pointTypes[ci] = "core"
+		case i >= 0:
+			pointTypes[ci] = "border"
+		default:
+			pointTypes[ci] = "noise"
+		}
+	}
+	return pointTypes
+}
+
+/*
+Assign new vectors to the cluster of their closest core point within eps
+
+	:parameter
+		*	x: vectors that should be assigned
+	:return
+		*	labels: cluster of every vector - -1 if no core point is within eps
+*/
+func (m *dbscan) Predict(x [][]float64) []int {
+	if m.index == nil {
+		log.Fatalln("DBSCAN has to be fitted before predicting")
+	}
+	labels := make([]int, len(x))
+	parallelFor(len(x), func(i int) {
+		labels[i] = -1
+		closest := math.Inf(1)
+		neighbors, dists := m.index.radiusQuery(x[i], m.eps)
+		for cj, j := range neighbors {
+			if m.core[j] && dists[cj] < closest {
+				labels[i], closest = m.labels[j], dists[cj]
+			}
+		}
+	})
+	return labels
+}
+
+/*
+Create an unfitted copy of the model with the same parameters
+*/
+func (m *dbscan) Clone() *dbscan {
+	return &dbscan{eps: m.eps, minPts: m.minPts, distType: m.distType}
+}
+
+/*
+Distances of all vectors to their k-th nearest neighbor for a k-distance plot to choose eps - eps is usually chosen at the knee of the curve with k = minPts - 1
+
+	:parameter
+		*	x: vectors that should be clustered
+		*	k: which neighbor (the vector itself is not counted)
+		*	distType: which distance metric should be used (euclidean, manhattan, hamming, braycurtis)
+	:return
+		*	kDists: k-distances sorted from large to small
+		*	kneeEps: k-distance at the knee - the point of the curve farthest from the line between its first and last point
+*/
+func kDistances(x [][]float64, k *int, distType *string) ([]float64, float64) {
+	if *k < 1 || *k >= len(x) {
+		log.Fatalln(fmt.Sprintf("k [%d] has to be between 1 and the number of samples - 1 [%d]", *k, len(x)-1))
+	}
+	index := newNeighborIndex(x, distType)
+	kDists := make([]float64, len(x))
+	parallelFor(len(x), func(i int) {
+		_, dists := index.kNearest(x[i], *k+1)
+		kDists[i] = dists[*k]
+	})
+	sort.Sort(sort.Reverse(sort.Float64Slice(kDists)))
+	// knee with the largest distance to the chord through the normalized curve
+	last := len(kDists) - 1
+	span := kDists[0] - kDists[last]
+	if span == 0 || last == 0 {
+		return kDists, kDists[0]
+	}
+	knee, kneeDist := 0, -1.0
+	for ci, i := range kDists {
+		chordX := float64(ci) / float64(last)
+		curveY := (i - kDists[last]) / span
+		// the chord goes from (0, 1) to (1, 0)
+		if dist := (chordX + curveY - 1) / math.Sqrt2; math.Abs(dist) > kneeDist {
+			knee, kneeDist = ci, math.Abs(dist)
+		}
+	}
+	return kDists, kDists[knee]
+}
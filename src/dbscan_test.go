@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestDBSCANRecoversBlobs(t *testing.T) {
+	x, blobs := testBlobs([]int{40, 60, 80}, 2, 61)
+	// isolated vectors between the blobs have to end up as noise
+	outliers := [][]float64{{5, -5}, {-5, 5}, {15, 25}}
+	x = append(x, outliers...)
+	eps := 1.5
+	minPts := 5
+	distType := "euclidean"
+	m := newDBSCAN(&eps, &minPts, &distType)
+	labels := m.FitPredict(x)
+	if !testMatchesBlobs(labels[:len(blobs)], blobs) {
+		t.Errorf("labels %v don't match the blobs", labels[:len(blobs)])
+	}
+	pointTypes := m.pointTypes()
+	for ci := len(blobs); ci < len(x); ci++ {
+		if labels[ci] != -1 || pointTypes[ci] != "noise" {
+			t.Errorf("outlier %v has label %d and type %s, want noise", x[ci], labels[ci], pointTypes[ci])
+		}
+	}
+	pred := m.Predict([][]float64{{0, 0}, {10, 10}, {20, 20}, {5, 5}})
+	if pred[0] != labels[0] || pred[1] != labels[40] || pred[2] != labels[100] || pred[3] != -1 {
+		t.Errorf("predicted %v, want the blob labels and -1 between the blobs", pred)
+	}
+}
+
+func TestKDistances(t *testing.T) {
+	x := [][]float64{{0}, {1}, {3}, {7}}
+	k := 1
+	distType := "euclidean"
+	kDists, _ := kDistances(x, &k, &distType)
+	want := []float64{4, 2, 1, 1}
+	for ci, i := range want {
+		if kDists[ci] != i {
+			t.Fatalf("k-distances %v, want %v", kDists, want)
+		}
+	}
+}
+
+func TestDBSCANBorderPoints(t *testing.T) {
+	// with eps 0.6 and minPts 3 the inner vectors of both groups are core points and the ends border points - 2.6 is only close to the border point 2
+	x := [][]float64{{0}, {0.5}, {1}, {1.5}, {2}, {2.6}, {10}, {10.5}, {11}}
+	eps := 0.6
+	minPts := 3
+	distType := "euclidean"
+	m := newDBSCAN(&eps, &minPts, &distType)
+	labels := m.FitPredict(x)
+	wantLabels := []int{0, 0, 0, 0, 0, -1, 1, 1, 1}
+	wantTypes := []string{"border", "core", "core", "core", "border", "noise", "border", "core", "border"}
+	pointTypes := m.pointTypes()
+	for ci := range x {
+		if labels[ci] != wantLabels[ci] || pointTypes[ci] != wantTypes[ci] {
+			t.Errorf("vector %v has label %d and type %s, want %d and %s", x[ci], labels[ci], pointTypes[ci], wantLabels[ci], wantTypes[ci])
+		}
+	}
+	// border points don't extend the cluster to new vectors either
+	pred := m.Predict([][]float64{{1.9}, {2.55}, {10.9}})
+	if pred[0] != 0 || pred[1] != -1 || pred[2] != 1 {
+		t.Errorf("predicted %v, want [0 -1 1]", pred)
+	}
+}
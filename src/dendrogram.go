@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+Convert merges to a SciPy compatible linkage matrix
+
+	:parameter
+		*	merges: merges as returned by agglomerate
+	:return
+		*	linkageMat: one row [first cluster, second cluster, distance, number of samples] per merge
+*/
+func mergesToLinkage(merges []clusterMerge) [][]float64 {
+	linkageMat := make([][]float64, len(merges))
+	for ci, i := range merges {
+		linkageMat[ci] = []float64{float64(i.first), float64(i.second), i.dist, float64(i.size)}
+	}
+	return linkageMat
+}
+
+/*
+Convert a complete linkage matrix back to merges and check that it is valid
+
+	:parameter
+		*	linkageMat: SciPy compatible linkage matrix with n - 1 rows for n samples
+	:return
+		*	merges: the merges of the linkage matrix
+*/
+func linkageToMerges(linkageMat [][]float64) []clusterMerge {
+	n := len(linkageMat) + 1
+	sizes := make([]int, 2*n-1)
+	for i := 0; i < n; i++ {
+		sizes[i] = 1
+	}
+	merges := make([]clusterMerge, len(linkageMat))
+	for ci, i := range linkageMat {
+		if len(i) != 4 {
+			log.Fatalln(fmt.Sprintf("Row [%d] of the linkage matrix has [%d] instead of 4 entries", ci, len(i)))
+		}
+		first, second := int(i[0]), int(i[1])
+		if first < 0 || second < 0 || first >= n+ci || second >= n+ci || first == second {
+			log.Fatalln(fmt.Sprintf("Row [%d] of the linkage matrix merges the invalid clusters [%d] and [%d]", ci, first, second))
+		}
+		sizes[n+ci] = sizes[first] + sizes[second]
+		if int(i[3]) != sizes[n+ci] {
+			log.Fatalln(fmt.Sprintf("Row [%d] of the linkage matrix has size [%d] but its clusters have [%d] members", ci, int(i[3]), sizes[n+ci]))
+		}
+		merges[ci] = clusterMerge{first: first, second: second, dist: i[2], size: sizes[n+ci]}
+	}
+	return merges
+}
+
+/*
+Agglomerative clustering that returns the complete hierarchy as linkage matrix
+
+	:parameter
+		*	inSlice: slice to be clustered
+		*	distType: which distance metric should be used (euclidean, manhattan, hamming, braycurtis) - centroid, median and ward need euclidean
+		*	linkage: which linkage should be used (single, complete, average, weighted, centroid, median, ward)
+	:return
+		*	linkageMat: SciPy compatible linkage matrix
+*/
+func agglomerativeLinkage(inSlice [][]float64, distType *string, linkage *string) [][]float64 {
+	if isinString(squaredLinkages, *linkage) && *distType != "euclidean" {
+		fmt.Printf("Linkage ['%s'] is only defined for euclidean distances and not for ['%s']\n", *linkage, *distType)
+	}
+	maxDist := math.Inf(1)
+	numClusters := 1
+	return mergesToLinkage(agglomerate(pairwiseDistances(inSlice, distType), linkage, &maxDist, &numClusters))
+}
+
+/*
+Centroid based hierarchical clustering (see hierachicalClustering) that returns the complete hierarchy as linkage matrix
+
+	:parameter
+		*	inSlice: slice to be clustered
+		*	distType: which distance metric should be used (euclidean, manhattan, hamming, braycurtis)
+	:return
+		*	linkageMat: SciPy compatible linkage matrix
+*/
+func hierachicalClusteringLinkage(inSlice [][]float64, distType *string) [][]float64 {
+	maxIter := len(inSlice)
+	maxDist := math.Inf(1)
+	_, merges := hierachicalClusteringMerges(inSlice, distType, &maxIter, &maxDist)
+	return mergesToLinkage(merges)
+}
+
+/*
+Hierarchical clustering of features by their mean absolute correlation (see hierarchicalCorrelationClusteringMatrix) that returns the complete hierarchy as linkage matrix
+The mean absolute correlation of two clusters is the average linkage of the distance 1 - |correlation| so the height of a merge is 1 - its mean absolute correlation
+
+	:parameter
+		*	corrMat: correlation matrix as returned by CorrelationMatrix - constant features stop the program
+	:return
+		*	linkageMat: SciPy compatible linkage matrix
+*/
+func hierarchicalCorrelationLinkage(corrMat [][]float64) [][]float64 {
+	assertNoConstantFeatures(corrMat)
+	distMat := make([][]float64, len(corrMat))
+	for ci, i := range corrMat {
+		distMat[ci] = make([]float64, len(i))
+		for cj, j := range i {
+			distMat[ci][cj] = 1 - math.Abs(j)
+		}
+	}
+	linkage := "average"
+	maxDist := math.Inf(1)
+	numClusters := 1
+	return mergesToLinkage(agglomerate(distMat, &linkage, &maxDist, &numClusters))
+}
+
+/*
+Cut the hierarchy into a given number of clusters by undoing the last merges
+
+	:parameter
+		*	linkageMat: SciPy compatible linkage matrix
+		*	numClusters: number of clusters between 1 and the number of samples
+	:return
+		*	cluster: indices of members of clusters in their own slice
+*/
+func cutTreeCount(linkageMat [][]float64, numClusters *int) [][]int {
+	merges := linkageToMerges(linkageMat)
+	n := len(merges) + 1
+	if *numClusters < 1 || *numClusters > n {
+		log.Fatalln(fmt.Sprintf("Number of clusters [%d] has to be between 1 and the number of samples [%d]", *numClusters, n))
+	}
+	return clustersFromMerges(n, merges[:n-*numClusters])
+}
+
+/*
+Cut the hierarchy at a height so that no cluster contains a merge above it - for hierarchies with inversions (centroid, median) the highest merge within a subtree is used
+
+	:parameter
+		*	linkageMat: SciPy compatible linkage matrix
+		*	height: the height at which the tree is cut
+	:return
+		*	cluster: indices of members of clusters in their own slice
+*/
+func cutTreeHeight(linkageMat [][]float64, height *float64) [][]int {
+	merges := linkageToMerges(linkageMat)
+	n := len(merges) + 1
+	// highest merge in the subtree of every cluster
+	subtreeMax := make([]float64, 2*n-1)
+	members := make(map[int][]int, n)
+	for i := 0; i < n; i++ {
+		members[i] = []int{i}
+	}
+	for ci, i := range merges {
+		subtreeMax[n+ci] = math.Max(i.dist, math.Max(subtreeMax[i.first], subtreeMax[i.second]))
+		if subtreeMax[n+ci] <= *height {
+			members[n+ci] = append(members[i.first], members[i.second]...)
+			delete(members, i.first)
+			delete(members, i.second)
+		}
+	}
+	cluster := make([][]int, 0, len(members))
+	for _, i := range members {
+		sort.Ints(i)
+		cluster = append(cluster, i)
+	}
+	sort.Slice(cluster, func(i, j int) bool { return cluster[i][0] < cluster[j][0] })
+	return cluster
+}
+
+/*
+Calculate the cophenetic distances - the height of the merge at which two samples first end up in the same cluster
+
+	:parameter
+		*	linkageMat: SciPy compatible linkage matrix
+	:return
+		*	copheneticMat: symmetric matrix with the cophenetic distance between sample i and j at [i][j]
+*/
+func copheneticDistances(linkageMat [][]float64) [][]float64 {
+	merges := linkageToMerges(linkageMat)
+	n := len(merges) + 1
+	copheneticMat := make([][]float64, n)
+	for i := range copheneticMat {
+		copheneticMat[i] = make([]float64, n)
+	}
+	members := make(map[int][]int, n)
+	for i := 0; i < n; i++ {
+		members[i] = []int{i}
+	}
+	for ci, i := range merges {
+		for _, j := range members[i.first] {
+			for _, k := range members[i.second] {
+				copheneticMat[j][k] = i.dist
+				copheneticMat[k][j] = i.dist
+			}
+		}
+		members[n+ci] = append(members[i.first], members[i.second]...)
+		delete(members, i.first)
+		delete(members, i.second)
+	}
+	return copheneticMat
+}
+
+/*
+Cophenetic correlation coefficient - how well the hierarchy preserves the original distances
+
+	:parameter
+		*	linkageMat: SciPy compatible linkage matrix
+		*	distMat: distance matrix the hierarchy was built from
+	:return
+		*	corr: Pearson correlation between the cophenetic and the original distances of all pairs
+*/
+func copheneticCorrelation(linkageMat [][]float64, distMat [][]float64) float64 {
+	copheneticMat := copheneticDistances(linkageMat)
+	if len(distMat) != len(copheneticMat) {
+		log.Fatalln(fmt.Sprintf("Distance matrix has [%d] instead of [%d] samples", len(distMat), len(copheneticMat)))
+	}
+	cophenetic := []float64{}
+	original := []float64{}
+	for i := range distMat {
+		for j := i + 1; j < len(distMat); j++ {
+			cophenetic = append(cophenetic, copheneticMat[i][j])
+			original = append(original, distMat[i][j])
+		}
+	}
+	return pearsonVec(cophenetic, original)
+}
+
+/*
+Node of a dendrogram - leaves are the samples
+*/
+type dendrogramNode struct {
+	Name     string            `json:"name,omitempty"`
+	Height   float64           `json:"height"`
+	Size     int               `json:"size"`
+	Children []*dendrogramNode `json:"children,omitempty"`
+}
+
+/*
+Build the tree of a linkage matrix
+
+	:parameter
+		*	linkageMat: SciPy compatible linkage matrix
+		*	labels: names of the samples - nil to use their indices
+	:return
+		*	root: root node of the tree
+*/
+func buildDendrogram(linkageMat [][]float64, labels []string) *dendrogramNode {
+	merges := linkageToMerges(linkageMat)
+	n := len(merges) + 1
+	if labels == nil {
+		labels = make([]string, n)
+		for i := range labels {
+			labels[i] = strconv.Itoa(i)
+		}
+	}
+	if len(labels) != n {
+		log.Fatalln(fmt.Sprintf("Number of labels [%d] not equal to the number of samples [%d]", len(labels), n))
+	}
+	nodes := make([]*dendrogramNode, 2*n-1)
+	for ci, i := range labels {
+		nodes[ci] = &dendrogramNode{Name: i, Size: 1}
+	}
+	for ci, i := range merges {
+		nodes[n+ci] = &dendrogramNode{Height: i.dist, Size: i.size, Children: []*dendrogramNode{nodes[i.first], nodes[i.second]}}
+	}
+	return nodes[2*n-2]
+}
+
+/*
+Escape a label for the Newick format by quoting it if it contains special characters
+*/
+func newickLabel(label string) string {
+	if strings.ContainsAny(label, " ()[]':;,") {
+		return "'" + strings.ReplaceAll(label, "'", "''") + "'"
+	}
+	return label
+}
+
+/*
+Export the hierarchy in the Newick format - branch lengths are the height differences between parent and child
+
+	:parameter
+		*	linkageMat: SciPy compatible linkage matrix
+		*	labels: names of the samples - nil to use their indices
+	:return
+		*	newick: the tree in Newick format
+*/
+func newickTree(linkageMat [][]float64, labels []string) string {
+	builder := &strings.Builder{}
+	var write func(node *dendrogramNode, parentHeight float64)
+	write = func(node *dendrogramNode, parentHeight float64) {
+		if node.Children == nil {
+			builder.WriteString(newickLabel(node.Name))
+		} else {
+			builder.WriteString("(")
+			for ci, i := range node.Children {
+				if ci > 0 {
+					builder.WriteString(",")
+				}
+				write(i, node.Height)
+			}
+			builder.WriteString(")")
+		}
+		if !math.IsNaN(parentHeight) {
+			builder.WriteString(":" + strconv.FormatFloat(parentHeight-node.Height, 'g', -1, 64))
+		}
+	}
+	write(buildDendrogram(linkageMat, labels), math.NaN())
+	builder.WriteString(";")
+	return builder.String()
+}
+
+/*
+Export the hierarchy as nested JSON with name, height, size and children of every node
+
+	:parameter
+		*	linkageMat: SciPy compatible linkage matrix
+		*	labels: names of the samples - nil to use their indices
+	:return
+		*	jsonTree: the tree as indented JSON
+*/
+func dendrogramJSON(linkageMat [][]float64, labels []string) string {
+	jsonTree, err := json.MarshalIndent(buildDendrogram(linkageMat, labels), "", "  ")
+	if err != nil {
+		log.Fatalln("Couldn't convert the dendrogram to JSON\n", err)
+	}
+	return string(jsonTree)
+}
@@ -0,0 +1,394 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// entries of a csv file that are counted as missing values
+var missingValues = []string{"", "NA", "NaN", "nan", "?"}
+
+/*
+A value of a column and how often it occurs
+*/
+type categoryCount struct {
+	Value    string  `json:"value"`
+	Count    int     `json:"count"`
+	Fraction float64 `json:"fraction"`
+}
+
+/*
+Summary statistics of one column - the numeric statistics are NaN for non numeric columns
+*/
+type columnProfile struct {
+	Name          string
+	Count         int
+	Missing       int
+	Numeric       bool
+	Mean          float64
+	Std           float64
+	Min           float64
+	Q1            float64
+	Median        float64
+	Q3            float64
+	Max           float64
+	Skewness      float64
+	Kurtosis      float64
+	Distinct      int
+	TopCategories []categoryCount
+	Constant      bool
+	NearConstant  bool
+}
+
+/*
+Profile of a whole data set with the class balance of the label column
+*/
+type datasetProfile struct {
+	NumRows      int
+	Columns      []columnProfile
+	LabelColumn  string
+	LabelBalance []categoryCount
+}
+
+/*
+Count how often every value occurs and return the counts from most to least frequent
+
+	:parameter
+		* values: the values to be counted
+	:return
+		* counts: every distinct value with its count and fraction of all values
+*/
+func countCategories(values []string) []categoryCount {
+	countMap := make(map[string]int)
+	for _, i := range values {
+		countMap[i]++
+	}
+	counts := make([]categoryCount, 0, len(countMap))
+	for key, value := range countMap {
+		counts = append(counts, categoryCount{Value: key, Count: value, Fraction: float64(value) / float64(len(values))})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Value < counts[j].Value
+	})
+	return counts
+}
+
+/*
+Calculate the bias corrected sample skewness and excess kurtosis
+
+	:parameter
+		* values: the values
+		* mean: mean of the values
+	:return
+		* skewness: sample skewness (NaN for less than 3 values)
+		* kurtosis: sample excess kurtosis (NaN for less than 4 values)
+*/
+func skewnessKurtosis(values []float64, mean float64) (float64, float64) {
+	n := float64(len(values))
+	m2, m3, m4 := 0.0, 0.0, 0.0
+	for _, i := range values {
+		d := i - mean
+		m2 += d * d
+		m3 += d * d * d
+		m4 += d * d * d * d
+	}
+	m2, m3, m4 = m2/n, m3/n, m4/n
+	skewness, kurtosis := math.NaN(), math.NaN()
+	if m2 == 0 {
+		return skewness, kurtosis
+	}
+	if n >= 3 {
+		skewness = m3 / math.Pow(m2, 1.5) * math.Sqrt(n*(n-1)) / (n - 2)
+	}
+	if n >= 4 {
+		kurtosis = ((n+1)*(m4/(m2*m2)-3) + 6) * (n - 1) / ((n - 2) * (n - 3))
+	}
+	return skewness, kurtosis
+}
+
+/*
+Profile a single column of a csv file
+
+	:parameter
+		* name: name of the column
+		* values: all entries of the column
+		* topN: number of most frequent values to report
+		* nearConstantFrac: fraction of the most frequent value above which a column is near constant
+	:return
+		* profile: the column profile
+*/
+func describeColumn(name string, values []string, topN *int, nearConstantFrac *float64) columnProfile {
+	profile := columnProfile{Name: name, Numeric: true}
+	present := []string{}
+	numbers := []float64{}
+	for _, i := range values {
+		if isinString(missingValues, i) {
+			profile.Missing++
+			continue
+		}
+		present = append(present, i)
+		if profile.Numeric {
+			if number, err := strconv.ParseFloat(i, 64); err == nil {
+				numbers = append(numbers, number)
+			} else {
+				profile.Numeric = false
+			}
+		}
+	}
+	profile.Count = len(present)
+	categories := countCategories(present)
+	profile.Distinct = len(categories)
+	if len(categories) > *topN {
+		profile.TopCategories = categories[:*topN]
+	} else {
+		profile.TopCategories = categories
+	}
+	profile.Constant = profile.Distinct <= 1
+	profile.NearConstant = profile.Constant || categories[0].Fraction >= *nearConstantFrac
+
+	profile.Mean, profile.Std, profile.Min, profile.Max = math.NaN(), math.NaN(), math.NaN(), math.NaN()
+	profile.Q1, profile.Median, profile.Q3 = math.NaN(), math.NaN(), math.NaN()
+	profile.Skewness, profile.Kurtosis = math.NaN(), math.NaN()
+	if !profile.Numeric || len(numbers) == 0 {
+		profile.Numeric = profile.Numeric && len(numbers) > 0
+		return profile
+	}
+	sort.Float64s(numbers)
+	n := float64(len(numbers))
+	mean, std := meanStd(numbers)
+	profile.Mean = mean
+	if n > 1 {
+		// sample standard deviation
+		profile.Std = std * math.Sqrt(n/(n-1))
+	}
+	profile.Min, profile.Max = numbers[0], numbers[len(numbers)-1]
+	profile.Q1 = quantileSorted(numbers, 0.25)
+	profile.Median = quantileSorted(numbers, 0.5)
+	profile.Q3 = quantileSorted(numbers, 0.75)
+	profile.Skewness, profile.Kurtosis = skewnessKurtosis(numbers, mean)
+	return profile
+}
+
+/*
+Profile all columns of already read csv records in parallel
+
+	:parameter
+		* headLine: header of the file - empty to name the columns by their index
+		* records: lines of the csv file
+		* labelCol: index of the label column whose class balance should be reported - -1 for none
+		* topN: number of most frequent values to report per column
+		* nearConstantFrac: fraction of the most frequent value above which a column is near constant
+	:return
+		* profile: profile of every column and the label balance
+*/
+func describeRecords(headLine []string, records [][]string, labelCol *int, topN *int, nearConstantFrac *float64) datasetProfile {
+	numCols := len(records[0])
+	names := make([]string, numCols)
+	for i := range names {
+		if i < len(headLine) {
+			names[i] = headLine[i]
+		} else {
+			names[i] = strconv.Itoa(i)
+		}
+	}
+	profile := datasetProfile{NumRows: len(records), Columns: make([]columnProfile, numCols)}
+	parallelFor(numCols, func(i int) {
+		values := make([]string, len(records))
+		for cj, j := range records {
+			values[cj] = j[i]
+		}
+		profile.Columns[i] = describeColumn(names[i], values, topN, nearConstantFrac)
+	})
+	if *labelCol >= 0 && *labelCol < numCols {
+		labels := make([]string, len(records))
+		for ci, i := range records {
+			labels[ci] = i[*labelCol]
+		}
+		profile.LabelColumn = names[*labelCol]
+		profile.LabelBalance = countCategories(labels)
+	}
+	return profile
+}
+
+/*
+Profile all columns of a csv file
+
+	:parameter
+		* filePath: path to the csv file
+		* header: true if there is a header
+		* labelCol: index of the label column whose class balance should be reported - -1 for none
+		* topN: number of most frequent values to report per column
+		* nearConstantFrac: fraction of the most frequent value above which a column is near constant
+	:return
+		* profile: profile of every column and the label balance
+*/
+func describeCSV(filePath *string, header *bool, labelCol *int, topN *int, nearConstantFrac *float64) datasetProfile {
+	headLine, records := readCsvFile(filePath, header)
+	if len(records) == 0 {
+		log.Fatalln(fmt.Sprintf("No data in [%s]", *filePath))
+	}
+	return describeRecords(headLine, records, labelCol, topN, nearConstantFrac)
+}
+
+/*
+Format the top categories as value:count pairs
+*/
+func formatCategories(categories []categoryCount) string {
+	formatted := make([]string, len(categories))
+	for ci, i := range categories {
+		formatted[ci] = fmt.Sprintf("%s:%d", i.Value, i.Count)
+	}
+	return strings.Join(formatted, " ")
+}
+
+/*
+Flags of a column as they are shown in the text and csv output
+*/
+func (c columnProfile) flag() string {
+	if c.Constant {
+		return "constant"
+	}
+	if c.NearConstant {
+		return "near-constant"
+	}
+	return ""
+}
+
+// header of the text and csv output
+var profileHeader = []string{"column", "count", "missing", "mean", "std", "min", "25%", "50%", "75%", "max", "skew", "kurt", "distinct", "top", "flag"}
+
+/*
+Values of a column as they are shown in the text and csv output
+*/
+func (c columnProfile) fields() []string {
+	formatNumber := func(value float64) string {
+		if math.IsNaN(value) {
+			return ""
+		}
+		return strconv.FormatFloat(value, 'g', 6, 64)
+	}
+	return []string{
+		c.Name, strconv.Itoa(c.Count), strconv.Itoa(c.Missing),
+		formatNumber(c.Mean), formatNumber(c.Std), formatNumber(c.Min), formatNumber(c.Q1), formatNumber(c.Median),
+		formatNumber(c.Q3), formatNumber(c.Max), formatNumber(c.Skewness), formatNumber(c.Kurtosis),
+		strconv.Itoa(c.Distinct), formatCategories(c.TopCategories), c.flag(),
+	}
+}
+
+/*
+Format the profile as text table followed by the label balance
+*/
+func (p datasetProfile) String() string {
+	builder := &strings.Builder{}
+	fmt.Fprintf(builder, "rows: %d\n", p.NumRows)
+	writer := tabwriter.NewWriter(builder, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(profileHeader, "\t"))
+	for _, i := range p.Columns {
+		fmt.Fprintln(writer, strings.Join(i.fields(), "\t"))
+	}
+	writer.Flush()
+	if p.LabelBalance != nil {
+		fmt.Fprintf(builder, "\nlabel balance of [%s]\n", p.LabelColumn)
+		writer = tabwriter.NewWriter(builder, 0, 0, 2, ' ', 0)
+		for _, i := range p.LabelBalance {
+			fmt.Fprintf(writer, "%s\t%d\t%.4f\n", i.Value, i.Count, i.Fraction)
+		}
+		writer.Flush()
+	}
+	return builder.String()
+}
+
+/*
+Write the column profiles as csv
+
+	:parameter
+		* w: where the csv should be written to
+	:return
+		None
+*/
+func (p datasetProfile) writeCSV(w io.Writer) {
+	writer := csv.NewWriter(w)
+	writer.Write(profileHeader)
+	for _, i := range p.Columns {
+		writer.Write(i.fields())
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Fatalln("Couldn't write the profile as csv\n", err)
+	}
+}
+
+/*
+Format the profile as indented JSON - statistics that are not defined (NaN) are written as null
+*/
+func (p datasetProfile) json() string {
+	// NaN can't be represented in JSON
+	jsonNumber := func(value float64) interface{} {
+		if math.IsNaN(value) {
+			return nil
+		}
+		return value
+	}
+	columns := make([]map[string]interface{}, len(p.Columns))
+	for ci, i := range p.Columns {
+		columns[ci] = map[string]interface{}{
+			"name": i.Name, "count": i.Count, "missing": i.Missing, "numeric": i.Numeric,
+			"mean": jsonNumber(i.Mean), "std": jsonNumber(i.Std), "min": jsonNumber(i.Min),
+			"q1": jsonNumber(i.Q1), "median": jsonNumber(i.Median), "q3": jsonNumber(i.Q3), "max": jsonNumber(i.Max),
+			"skewness": jsonNumber(i.Skewness), "kurtosis": jsonNumber(i.Kurtosis),
+			"distinct": i.Distinct, "top_categories": i.TopCategories,
+			"constant": i.Constant, "near_constant": i.NearConstant,
+		}
+	}
+	jsonProfile, err := json.MarshalIndent(map[string]interface{}{
+		"num_rows":      p.NumRows,
+		"columns":       columns,
+		"label_column":  p.LabelColumn,
+		"label_balance": p.LabelBalance,
+	}, "", "  ")
+	if err != nil {
+		log.Fatalln("Couldn't convert the profile to JSON\n", err)
+	}
+	return string(jsonProfile)
+}
+
+/*
+Run the describe command: describe <csv file> [text|csv|json] - the file needs a header and the labels in the first column
+
+	:parameter
+		* args: the command line arguments after describe
+	:return
+		None
+*/
+func runDescribe(args []string) {
+	filePath := args[0]
+	format := "text"
+	if len(args) > 1 {
+		format = args[1]
+	}
+	header := true
+	labelCol := 0
+	topN := 5
+	nearConstantFrac := 0.95
+	profile := describeCSV(&filePath, &header, &labelCol, &topN, &nearConstantFrac)
+	switch format {
+	case "csv":
+		profile.writeCSV(os.Stdout)
+	case "json":
+		fmt.Println(profile.json())
+	default:
+		fmt.Print(profile)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestDescribeRecords(t *testing.T) {
+	headLine := []string{"label", "x", "s", "c"}
+	records := [][]string{
+		{"a", "1", "foo", "5"},
+		{"a", "2", "bar", "5"},
+		{"b", "3", "foo", "5"},
+		{"a", "4", "NA", "5"},
+	}
+	labelCol := 0
+	topN := 1
+	nearConstantFrac := 0.75
+	profile := describeRecords(headLine, records, &labelCol, &topN, &nearConstantFrac)
+	if profile.NumRows != 4 || len(profile.Columns) != 4 {
+		t.Fatalf("profile of %d rows and %d columns, want 4 and 4", profile.NumRows, len(profile.Columns))
+	}
+	x := profile.Columns[1]
+	for _, i := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"mean", x.Mean, 2.5},
+		{"sample std", x.Std, math.Sqrt(5. / 3)},
+		{"min", x.Min, 1},
+		{"Q1", x.Q1, 1.75},
+		{"median", x.Median, 2.5},
+		{"Q3", x.Q3, 3.25},
+		{"max", x.Max, 4},
+		{"skewness", x.Skewness, 0},
+		{"excess kurtosis", x.Kurtosis, -1.2},
+	} {
+		if math.Abs(i.got-i.want) > 1e-12 {
+			t.Errorf("x %s %g, want %g", i.name, i.got, i.want)
+		}
+	}
+	if !x.Numeric || x.Constant || x.NearConstant || x.Distinct != 4 {
+		t.Errorf("x profile %+v, want a numeric column with 4 distinct values", x)
+	}
+	s := profile.Columns[2]
+	if s.Numeric || s.Count != 3 || s.Missing != 1 || !math.IsNaN(s.Mean) || len(s.TopCategories) != 1 || s.TopCategories[0].Value != "foo" || s.TopCategories[0].Count != 2 {
+		t.Errorf("s profile %+v, want a categorical column with one missing value and foo as top category", s)
+	}
+	if c := profile.Columns[3]; !c.Constant || c.flag() != "constant" || c.Std != 0 {
+		t.Errorf("c profile %+v, want a constant column", c)
+	}
+	if label := profile.Columns[0]; label.Constant || label.flag() != "near-constant" {
+		t.Errorf("label column flagged as [%s], want near-constant", label.flag())
+	}
+	if profile.LabelColumn != "label" || profile.LabelBalance[0].Value != "a" || profile.LabelBalance[0].Fraction != 0.75 {
+		t.Errorf("label balance of [%s] is %v, want a with 0.75 first", profile.LabelColumn, profile.LabelBalance)
+	}
+
+	// undefined statistics are null in the JSON output and empty in the text output
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(profile.json()), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if mean := decoded["columns"].([]interface{})[2].(map[string]interface{})["mean"]; mean != nil {
+		t.Errorf("JSON mean of a categorical column is %v, want null", mean)
+	}
+	builder := &strings.Builder{}
+	profile.writeCSV(builder)
+	lines := strings.Split(strings.TrimSpace(builder.String()), "\n")
+	if len(lines) != 5 || lines[3] != "s,3,1,,,,,,,,,,2,foo:2," {
+		t.Errorf("csv output %q, want a header, 4 columns and empty numeric fields for s", lines)
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+)
+
+// maximum number of iterations and relative precision of the series and continued fractions
+const distributionMaxIter = 500
+const distributionEps = 1e-15
+
+/*
+Evaluate the continued fraction of the regularized incomplete beta function with the modified Lentz method
+
+	:parameter
+		*	a, b: shape parameters
+		*	x: upper limit of the integral
+	:return
+		*	cf: value of the continued fraction
+*/
+func betaContinuedFraction(a, b, x float64) float64 {
+	const tiny = 1e-300
+	qab := a + b
+	qap := a + 1
+	qam := a - 1
+	c := 1.0
+	d := 1 - qab*x/qap
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= distributionMaxIter; m++ {
+		fm := float64(m)
+		m2 := 2 * fm
+		// even step
+		aa := fm * (b - fm) * x / ((qam + m2) * (a + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+		// odd step
+		aa = -(a + fm) * (qab + fm) * x / ((a + m2) * (qap + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < distributionEps {
+			break
+		}
+	}
+	return h
+}
+
+/*
+Calculate the regularized incomplete beta function I_x(a, b)
+
+	:parameter
+		*	x: upper limit of the integral between 0 and 1
+		*	a, b: positive shape parameters
+	:return
+		*	ix: the regularized incomplete beta function
+*/
+func regIncBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lgab, _ := math.Lgamma(a + b)
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log1p(-x))
+	// the continued fraction converges fast for x < (a + 1) / (a + b + 2)
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(a, b, x) / a
+	}
+	return 1 - front*betaContinuedFraction(b, a, 1-x)/b
+}
+
+/*
+Calculate the regularized lower and upper incomplete gamma functions P(a, x) and Q(a, x)
+
+	:parameter
+		*	a: positive shape parameter
+		*	x: upper (for P) or lower (for Q) limit of the integral
+	:return
+		*	p: regularized lower incomplete gamma function
+		*	q: regularized upper incomplete gamma function 1 - p
+*/
+func regIncGamma(a, x float64) (float64, float64) {
+	if x <= 0 {
+		return 0, 1
+	}
+	lga, _ := math.Lgamma(a)
+	front := math.Exp(-x + a*math.Log(x) - lga)
+	if x < a+1 {
+		// series representation
+		ap := a
+		sum := 1 / a
+		del := sum
+		for n := 0; n < distributionMaxIter; n++ {
+			ap++
+			del *= x / ap
+			sum += del
+			if math.Abs(del) < math.Abs(sum)*distributionEps {
+				break
+			}
+		}
+		p := sum * front
+		return p, 1 - p
+	}
+	// continued fraction representation with the modified Lentz method
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1; i <= distributionMaxIter; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < distributionEps {
+			break
+		}
+	}
+	q := front * h
+	return 1 - q, q
+}
+
+/*
+Cumulative distribution function of the standard normal distribution
+*/
+func normalCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+/*
+Quantile function (inverse CDF) of the standard normal distribution
+
+	:parameter
+		*	p: probability between 0 and 1
+	:return
+		*	x: value with normalCDF(x) = p
+*/
+func normalQuantile(p float64) float64 {
+	return -math.Sqrt2 * math.Erfcinv(2*p)
+}
+
+/*
+Cumulative distribution function of Student's t distribution
+
+	:parameter
+		*	t: the value
+		*	df: degrees of freedom
+	:return
+		*	p: probability of a value <= t
+*/
+func studentTCDF(t, df float64) float64 {
+	tail := 0.5 * regIncBeta(df/(df+t*t), df/2, 0.5)
+	if t > 0 {
+		return 1 - tail
+	}
+	return tail
+}
+
+/*
+Two sided p-value of Student's t distribution
+
+	:parameter
+		*	t: the test statistic
+		*	df: degrees of freedom
+	:return
+		*	p: probability of a value at least as extreme as |t|
+*/
+func studentTTwoSidedP(t, df float64) float64 {
+	return regIncBeta(df/(df+t*t), df/2, 0.5)
+}
+
+/*
+Quantile function (inverse CDF) of Student's t distribution
+
+	:parameter
+		*	p: probability between 0 and 1
+		*	df: degrees of freedom
+	:return
+		*	t: value with studentTCDF(t, df) = p
+*/
+func studentTQuantile(p, df float64) float64 {
+	return invertCDF(func(t float64) float64 { return studentTCDF(t, df) }, p, normalQuantile(p))
+}
+
+/*
+Cumulative distribution function of the chi-square distribution
+
+	:parameter
+		*	x: the value
+		*	df: degrees of freedom
+	:return
+		*	p: probability of a value <= x
+*/
+func chiSquareCDF(x, df float64) float64 {
+	p, _ := regIncGamma(df/2, x/2)
+	return p
+}
+
+/*
+Survival function (1 - CDF) of the chi-square distribution that stays accurate for small probabilities
+
+	:parameter
+		*	x: the value
+		*	df: degrees of freedom
+	:return
+		*	p: probability of a value > x
+*/
+func chiSquareSF(x, df float64) float64 {
+	_, q := regIncGamma(df/2, x/2)
+	return q
+}
+
+/*
+Survival function (1 - CDF) of the F distribution
+
+	:parameter
+		*	f: the value
+		*	df1, df2: degrees of freedom of the numerator and the denominator
+	:return
+		*	p: probability of a value > f
+*/
+func fisherFSF(f, df1, df2 float64) float64 {
+	if f <= 0 {
+		return 1
+	}
+	return regIncBeta(df2/(df2+df1*f), df2/2, df1/2)
+}
+
+/*
+Find the value of a continuous and increasing CDF for a given probability by bracketing and bisection
+
+	:parameter
+		*	cdf: the cumulative distribution function
+		*	p: the probability between 0 and 1
+		*	start: initial guess of the value
+	:return
+		*	x: value with cdf(x) = p
+*/
+func invertCDF(cdf func(float64) float64, p, start float64) float64 {
+	if p <= 0 || p >= 1 {
+		log.Fatalln(fmt.Sprintf("Probability [%f] has to be between 0 and 1 (exclusive)", p))
+	}
+	// bracket the value
+	lower, upper := start-1, start+1
+	for cdf(lower) > p {
+		lower -= 2 * (upper - lower)
+	}
+	for cdf(upper) < p {
+		upper += 2 * (upper - lower)
+	}
+	for i := 0; i < 200 && upper-lower > 1e-12*math.Max(1, math.Abs(lower)); i++ {
+		mid := (lower + upper) / 2
+		if cdf(mid) < p {
+			lower = mid
+		} else {
+			upper = mid
+		}
+	}
+	return (lower + upper) / 2
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistributionReferenceValues(t *testing.T) {
+	// closed forms and tabulated quantiles
+	for _, i := range []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"regIncBeta(0.3, 1, 1)", regIncBeta(0.3, 1, 1), 0.3},
+		{"regIncBeta(0.5, 2, 3)", regIncBeta(0.5, 2, 3), 0.6875},
+		{"regIncBeta(0.9, 2, 3)", regIncBeta(0.9, 2, 3), 0.9963},
+		{"normalCDF(1.96)", normalCDF(1.96), 0.9750021048517796},
+		{"normalQuantile(0.975)", normalQuantile(0.975), 1.959963984540054},
+		{"studentTCDF(1, 1)", studentTCDF(1, 1), 0.75},
+		{"studentTCDF(-1, 1)", studentTCDF(-1, 1), 0.25},
+		{"studentTCDF(1, 2)", studentTCDF(1, 2), 0.7886751345948129},
+		{"studentTTwoSidedP(2.2281388519649385, 10)", studentTTwoSidedP(2.2281388519649385, 10), 0.05},
+		{"studentTQuantile(0.975, 10)", studentTQuantile(0.975, 10), 2.2281388519649385},
+		{"chiSquareCDF(3, 2)", chiSquareCDF(3, 2), 1 - math.Exp(-1.5)},
+		{"chiSquareSF(3.841458820694124, 1)", chiSquareSF(3.841458820694124, 1), 0.05},
+		{"chiSquareSF(60, 2)", chiSquareSF(60, 2), math.Exp(-30)},
+		{"fisherFSF(4.964602743635634, 1, 10)", fisherFSF(4.964602743635634, 1, 10), 0.05},
+	} {
+		if math.Abs(i.got-i.want) > 1e-9*math.Abs(i.want) {
+			t.Errorf("%s = %.16g, want %.16g", i.name, i.got, i.want)
+		}
+	}
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+)
+
+/*
+Condensed (upper triangle) distance matrix - stores the n*(n-1)/2 distances of i < j in row major order like SciPy's pdist
+Distances can be stored as float32 to halve the memory (50000 samples need about 5GB instead of 10GB)
+*/
+type condensedDistances struct {
+	n        int
+	values   []float64
+	values32 []float32
+}
+
+/*
+Create an empty condensed distance matrix
+
+	:parameter
+		*	n: number of samples
+		*	useFloat32: whether the distances should be stored as float32
+	:return
+		*	condensed: matrix with all distances 0
+*/
+func newCondensedDistances(n int, useFloat32 *bool) *condensedDistances {
+	size := n * (n - 1) / 2
+	if *useFloat32 {
+		return &condensedDistances{n: n, values32: make([]float32, size)}
+	}
+	return &condensedDistances{n: n, values: make([]float64, size)}
+}
+
+/*
+Calculate the condensed distance matrix of all vectors in parallel
+
+	:parameter
+		*	inSlice: vectors for which the distances should be computed
+		*	distType: which distance metric should be used (euclidean, manhattan, hamming, braycurtis)
+		*	useFloat32: whether the distances should be stored as float32
+	:return
+		*	condensed: the condensed distance matrix
+*/
+func condensedPairwiseDistances(inSlice [][]float64, distType *string, useFloat32 *bool) *condensedDistances {
+	distanceFunction := selectDistanceFunction(distType)
+	condensed := newCondensedDistances(len(inSlice), useFloat32)
+	// every row i writes its own segment i+1...n-1 so rows can be computed in parallel
+	parallelFor(len(inSlice), func(i int) {
+		if i == len(inSlice)-1 {
+			return
+		}
+		start := condensed.index(i, i+1)
+		for ci, j := range distanceFunction(inSlice[i+1:], inSlice[i]) {
+			if condensed.values32 != nil {
+				condensed.values32[start+ci] = float32(j)
+			} else {
+				condensed.values[start+ci] = j
+			}
+		}
+	})
+	return condensed
+}
+
+/*
+Condense a square distance matrix
+
+	:parameter
+		*	distMat: symmetric distance matrix as returned by pairwiseDistances
+		*	useFloat32: whether the distances should be stored as float32
+	:return
+		*	condensed: the condensed distance matrix
+*/
+func condenseMatrix(distMat [][]float64, useFloat32 *bool) *condensedDistances {
+	condensed := newCondensedDistances(len(distMat), useFloat32)
+	for ci, i := range distMat {
+		if len(i) != len(distMat) {
+			log.Fatalln(fmt.Sprintf("Distance matrix has to be square but row [%d] has [%d] instead of [%d] entries", ci, len(i), len(distMat)))
+		}
+		for j := ci + 1; j < len(i); j++ {
+			condensed.set(ci, j, i[j])
+		}
+	}
+	return condensed
+}
+
+/*
+Position of the distance between i and j in the condensed storage - i and j have to differ
+*/
+func (c *condensedDistances) index(i, j int) int {
+	if i > j {
+		i, j = j, i
+	}
+	return c.n*i - i*(i+1)/2 + j - i - 1
+}
+
+/*
+Distance between sample i and j - 0 for i == j
+*/
+func (c *condensedDistances) at(i, j int) float64 {
+	if i == j {
+		return 0
+	}
+	if c.values32 != nil {
+		return float64(c.values32[c.index(i, j)])
+	}
+	return c.values[c.index(i, j)]
+}
+
+/*
+Set the distance between sample i and j (i != j)
+*/
+func (c *condensedDistances) set(i, j int, dist float64) {
+	if c.values32 != nil {
+		c.values32[c.index(i, j)] = float32(dist)
+	} else {
+		c.values[c.index(i, j)] = dist
+	}
+}
+
+/*
+Copy of the matrix for algorithms that update the distances in place
+*/
+func (c *condensedDistances) clone() *condensedDistances {
+	cloned := &condensedDistances{n: c.n}
+	if c.values32 != nil {
+		cloned.values32 = append([]float32{}, c.values32...)
+	} else {
+		cloned.values = append([]float64{}, c.values...)
+	}
+	return cloned
+}
+
+/*
+Stop if a distance is not defined (NaN) like the Bray-Curtis dissimilarity of two vectors that are all 0
+*/
+func (c *condensedDistances) assertDefined() {
+	for i := 0; i < c.n; i++ {
+		for j := i + 1; j < c.n; j++ {
+			if math.IsNaN(c.at(i, j)) {
+				log.Fatalln(fmt.Sprintf("Distance between sample [%d] and [%d] is not defined (NaN)", i, j))
+			}
+		}
+	}
+}
+
+/*
+Label merges of samples with SciPy cluster ids - the merges are sorted by distance and the clusters the samples currently belong to are merged
+
+	:parameter
+		*	n: number of samples
+		*	pairs: merges where first and second are any sample of the merged clusters
+	:return
+		*	merges: merges with the SciPy cluster ids as returned by agglomerate
+*/
+func labelMerges(n int, pairs []clusterMerge) []clusterMerge {
+	if n < 2 {
+		return []clusterMerge{}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].dist < pairs[j].dist })
+	// union find over the 2n-1 clusters of the hierarchy
+	parent := make([]int, 2*n-1)
+	sizes := make([]int, 2*n-1)
+	for i := range parent {
+		parent[i] = i
+		sizes[i] = 1
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	merges := make([]clusterMerge, len(pairs))
+	for ci, i := range pairs {
+		first, second := find(i.first), find(i.second)
+		if second < first {
+			first, second = second, first
+		}
+		parent[first], parent[second] = n+ci, n+ci
+		sizes[n+ci] = sizes[first] + sizes[second]
+		merges[ci] = clusterMerge{first: first, second: second, dist: i.dist, size: sizes[n+ci]}
+	}
+	return merges
+}
+
+/*
+Convert a pointer representation (pi, lambda) of a hierarchy to a linkage matrix
+*/
+func pointerToLinkage(pi []int, lambda []float64) [][]float64 {
+	pairs := make([]clusterMerge, 0, len(pi))
+	for ci, i := range pi {
+		if !math.IsInf(lambda[ci], 1) {
+			pairs = append(pairs, clusterMerge{first: ci, second: i, dist: lambda[ci]})
+		}
+	}
+	return mergesToLinkage(labelMerges(len(pi), pairs))
+}
+
+/*
+Single linkage clustering with SLINK (Sibson 1973) - O(n²) time and O(n) memory besides the distances
+
+	:parameter
+		*	dist: condensed distance matrix
+	:return
+		*	linkageMat: SciPy compatible linkage matrix
+*/
+func slinkLinkage(dist *condensedDistances) [][]float64 {
+	dist.assertDefined()
+	n := dist.n
+	pi := make([]int, n)
+	lambda := make([]float64, n)
+	m := make([]float64, n)
+	for k := 0; k < n; k++ {
+		pi[k] = k
+		lambda[k] = math.Inf(1)
+		for i := 0; i < k; i++ {
+			m[i] = dist.at(i, k)
+		}
+		for i := 0; i < k; i++ {
+			if lambda[i] >= m[i] {
+				m[pi[i]] = math.Min(m[pi[i]], lambda[i])
+				lambda[i] = m[i]
+				pi[i] = k
+			} else {
+				m[pi[i]] = math.Min(m[pi[i]], m[i])
+			}
+		}
+		for i := 0; i < k; i++ {
+			if lambda[i] >= lambda[pi[i]] {
+				pi[i] = k
+			}
+		}
+	}
+	return pointerToLinkage(pi, lambda)
+}
+
+/*
+Complete linkage clustering with CLINK (Defays 1977) - O(n²) time and O(n) memory besides the distances
+CLINK is fast but its hierarchy depends on the order of the samples and can differ from exact complete linkage - use nnChainLinkage with complete linkage for the exact hierarchy
+
+	:parameter
+		*	dist: condensed distance matrix
+	:return
+		*	linkageMat: SciPy compatible linkage matrix
+*/
+func clinkLinkage(dist *condensedDistances) [][]float64 {
+	dist.assertDefined()
+	n := dist.n
+	pi := make([]int, n)
+	lambda := make([]float64, n)
+	m := make([]float64, n)
+	for k := 0; k < n; k++ {
+		pi[k] = k
+		lambda[k] = math.Inf(1)
+		if k == 0 {
+			continue
+		}
+		for i := 0; i < k; i++ {
+			m[i] = dist.at(i, k)
+		}
+		for i := 0; i < k; i++ {
+			if lambda[i] < m[i] {
+				m[pi[i]] = math.Max(m[pi[i]], m[i])
+				m[i] = math.Inf(1)
+			}
+		}
+		// the sample to attach the new one to
+		a := k - 1
+		for i := k - 1; i >= 0; i-- {
+			if lambda[i] >= m[pi[i]] {
+				if m[i] < m[a] {
+					a = i
+				}
+			} else {
+				m[i] = math.Inf(1)
+			}
+		}
+		b := pi[a]
+		c := lambda[a]
+		pi[a] = k
+		lambda[a] = m[a]
+		if a < k-1 {
+			for b < k-1 {
+				nextB, nextC := pi[b], lambda[b]
+				pi[b] = k
+				lambda[b] = c
+				b, c = nextB, nextC
+			}
+			if b == k-1 {
+				pi[b] = k
+				lambda[b] = c
+			}
+		}
+		for i := 0; i < k; i++ {
+			if pi[pi[i]] == k && lambda[i] >= lambda[pi[i]] {
+				pi[i] = k
+			}
+		}
+	}
+	return pointerToLinkage(pi, lambda)
+}
+
+// linkages that are reducible and can be clustered with the nearest neighbor chain
+var chainLinkages = []string{"single", "complete", "average", "weighted", "ward"}
+
+/*
+Agglomerative clustering with the nearest neighbor chain algorithm - O(n²) time for reducible linkages
+To save memory the distances are updated in place - pass dist.clone() to keep the original distances
+
+	:parameter
+		*	dist: condensed distance matrix (euclidean for ward) - overwritten
+		*	linkage: which linkage should be used (single, complete, average, weighted, ward)
+	:return
+		*	linkageMat: SciPy compatible linkage matrix
+*/
+func nnChainLinkage(dist *condensedDistances, linkage *string) [][]float64 {
+	chosenLinkage := *linkage
+	if !isinString(chainLinkages, chosenLinkage) {
+		fmt.Printf("Using default linkage ['average'] instead of the not implementd ['%s']\n", chosenLinkage)
+		chosenLinkage = "average"
+	}
+	dist.assertDefined()
+	update, squared := selectLinkage(&chosenLinkage)
+	n := dist.n
+	if squared {
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				d := dist.at(i, j)
+				dist.set(i, j, d*d)
+			}
+		}
+	}
+	active := make([]bool, n)
+	sizes := make([]int, n)
+	for i := 0; i < n; i++ {
+		active[i], sizes[i] = true, 1
+	}
+	pairs := make([]clusterMerge, 0, n)
+	chain := make([]int, 0, n)
+	for len(pairs) < n-1 {
+		if len(chain) == 0 {
+			for i := 0; i < n; i++ {
+				if active[i] {
+					chain = append(chain, i)
+					break
+				}
+			}
+		}
+		// extend the chain until two clusters are mutual nearest neighbors
+		var x, y int
+		var minDist float64
+		for {
+			x, y, minDist = chain[len(chain)-1], -1, math.Inf(1)
+			// prefer the previous element of the chain on ties so the chain can't cycle
+			if len(chain) > 1 {
+				y = chain[len(chain)-2]
+				minDist = dist.at(x, y)
+			}
+			for i := 0; i < n; i++ {
+				if active[i] && i != x {
+					if d := dist.at(x, i); d < minDist {
+						y, minDist = i, d
+					}
+				}
+			}
+			if y == -1 {
+				log.Fatalln(fmt.Sprintf("Cluster [%d] has no nearest neighbor", x))
+			}
+			if len(chain) > 1 && y == chain[len(chain)-2] {
+				break
+			}
+			chain = append(chain, y)
+		}
+		chain = chain[:len(chain)-2]
+		height := minDist
+		if squared {
+			height = math.Sqrt(math.Max(height, 0))
+		}
+		pairs = append(pairs, clusterMerge{first: x, second: y, dist: height})
+		// the merged cluster takes the place of y
+		for k := 0; k < n; k++ {
+			if active[k] && k != x && k != y {
+				dist.set(y, k, update(dist.at(x, k), dist.at(y, k), minDist, sizes[x], sizes[y], sizes[k]))
+			}
+		}
+		active[x] = false
+		sizes[y] += sizes[x]
+	}
+	return mergesToLinkage(labelMerges(n, pairs))
+}
+
+/*
+Memory efficient agglomerative clustering in O(n²) for large data sets - uses SLINK for single and the nearest neighbor chain for the other linkages
+
+	:parameter
+		*	inSlice: slice to be clustered
+		*	distType: which distance metric should be used (euclidean, manhattan, hamming, braycurtis) - ward needs euclidean
+		*	linkage: which linkage should be used
+			-	single, complete, average, weighted, ward: exact hierarchy equal to agglomerativeLinkage
+			-	clink: approximate complete linkage with CLINK that depends on the order of the samples
+		*	useFloat32: whether the distances should be stored as float32 to halve the memory
+	:return
+		*	linkageMat: SciPy compatible linkage matrix
+*/
+func fastAgglomerativeLinkage(inSlice [][]float64, distType *string, linkage *string, useFloat32 *bool) [][]float64 {
+	if isinString(squaredLinkages, *linkage) && *distType != "euclidean" {
+		fmt.Printf("Linkage ['%s'] is only defined for euclidean distances and not for ['%s']\n", *linkage, *distType)
+	}
+	if len(inSlice) < 2 {
+		return [][]float64{}
+	}
+	dist := condensedPairwiseDistances(inSlice, distType, useFloat32)
+	switch *linkage {
+	case "single":
+		return slinkLinkage(dist)
+	case "clink":
+		return clinkLinkage(dist)
+	default:
+		return nnChainLinkage(dist, linkage)
+	}
+}
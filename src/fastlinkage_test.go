@@ -0,0 +1,130 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestFastLinkageMatchesAgglomerate(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	x := make([][]float64, 60)
+	for i := range x {
+		x[i] = []float64{rng.Float64(), rng.Float64(), rng.Float64()}
+	}
+	distType := "euclidean"
+	useFloat32 := false
+	for _, linkage := range chainLinkages {
+		want := agglomerativeLinkage(x, &distType, &linkage)
+		got := fastAgglomerativeLinkage(x, &distType, &linkage, &useFloat32)
+		if len(got) != len(want) {
+			t.Fatalf("%s: %d merges, want %d", linkage, len(got), len(want))
+		}
+		for ci, i := range want {
+			for cj, j := range i {
+				if math.Abs(got[ci][cj]-j) > 1e-9 {
+					t.Errorf("%s: merge %d is %v, want %v", linkage, ci, got[ci], i)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestSlinkMatchesNNChain(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	x := make([][]float64, 80)
+	for i := range x {
+		x[i] = []float64{rng.NormFloat64(), rng.NormFloat64()}
+	}
+	distType := "manhattan"
+	linkage := "single"
+	useFloat32 := false
+	dist := condensedPairwiseDistances(x, &distType, &useFloat32)
+	want := nnChainLinkage(dist.clone(), &linkage)
+	got := slinkLinkage(dist)
+	for ci, i := range want {
+		for cj, j := range i {
+			if math.Abs(got[ci][cj]-j) > 1e-9 {
+				t.Errorf("merge %d is %v, want %v", ci, got[ci], i)
+				break
+			}
+		}
+	}
+}
+
+func TestFastLinkageCompleteIsExact(t *testing.T) {
+	x, _ := testBlobs([]int{40, 60, 80}, 2, 7)
+	distType := "euclidean"
+	linkage := "complete"
+	useFloat32 := false
+	numClusters := 3
+	got := testClusterSizes(cutTreeCount(fastAgglomerativeLinkage(x, &distType, &linkage, &useFloat32), &numClusters))
+	if got[0] != 40 || got[1] != 60 || got[2] != 80 {
+		t.Errorf("cluster sizes %v, want [40 60 80]", got)
+	}
+}
+
+func TestFastLinkageFewSamples(t *testing.T) {
+	distType := "euclidean"
+	linkage := "average"
+	useFloat32 := true
+	for _, x := range [][][]float64{{}, {{1, 2}}} {
+		if got := fastAgglomerativeLinkage(x, &distType, &linkage, &useFloat32); len(got) != 0 {
+			t.Errorf("%d samples give %d merges, want 0", len(x), len(got))
+		}
+	}
+}
+
+func TestClinkMergeHeights(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	x := make([][]float64, 50)
+	for i := range x {
+		x[i] = []float64{rng.NormFloat64(), rng.NormFloat64()}
+	}
+	distType := "euclidean"
+	linkage := "clink"
+	useFloat32 := false
+	linkageMat := fastAgglomerativeLinkage(x, &distType, &linkage, &useFloat32)
+	dist := condensedPairwiseDistances(x, &distType, &useFloat32)
+	n := len(x)
+	members := make(map[int][]int, n)
+	for i := 0; i < n; i++ {
+		members[i] = []int{i}
+	}
+	// every merge happens at the largest distance between the members of the two clusters
+	for ci, i := range linkageToMerges(linkageMat) {
+		maxDist := 0.0
+		for _, j := range members[i.first] {
+			for _, k := range members[i.second] {
+				maxDist = math.Max(maxDist, dist.at(j, k))
+			}
+		}
+		if math.Abs(i.dist-maxDist) > 1e-12 {
+			t.Errorf("merge %d at %g, want the largest member distance %g", ci, i.dist, maxDist)
+		}
+		members[n+ci] = append(members[i.first], members[i.second]...)
+	}
+}
+
+func TestFastLinkageFloat32(t *testing.T) {
+	rng := rand.New(rand.NewSource(13))
+	x := make([][]float64, 70)
+	for i := range x {
+		x[i] = []float64{rng.Float64() * 10, rng.Float64() * 10}
+	}
+	distType := "euclidean"
+	for _, linkage := range append([]string{"clink"}, chainLinkages...) {
+		useFloat32 := false
+		want := fastAgglomerativeLinkage(x, &distType, &linkage, &useFloat32)
+		useFloat32 = true
+		got := fastAgglomerativeLinkage(x, &distType, &linkage, &useFloat32)
+		// float32 keeps about 7 significant digits
+		for ci, i := range want {
+			if got[ci][0] != i[0] || got[ci][1] != i[1] || got[ci][3] != i[3] || math.Abs(got[ci][2]-i[2]) > 1e-5*math.Max(1, i[2]) {
+				t.Errorf("%s: float32 merge %d is %v, float64 %v", linkage, ci, got[ci], i)
+				break
+			}
+		}
+	}
+}
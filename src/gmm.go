@@ -0,0 +1,511 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+)
+
+/*
+Covariance types of the Gaussian mixture
+*/
+var covarianceTypes = []string{"full", "diag", "spherical", "tied"}
+
+/*
+Cholesky decomposition of a symmetric positive definite matrix
+
+	:parameter
+		*	mat: the matrix
+	:return
+		*	lower: lower triangular matrix with lower * lower^T = mat
+		*	ok: false if the matrix is not positive definite
+*/
+func cholesky(mat [][]float64) ([][]float64, bool) {
+	dim := len(mat)
+	lower := make([][]float64, dim)
+	for i := range lower {
+		lower[i] = make([]float64, dim)
+	}
+	for i := 0; i < dim; i++ {
+		for j := 0; j <= i; j++ {
+			sum := mat[i][j]
+			for k := 0; k < j; k++ {
+				sum -= lower[i][k] * lower[j][k]
+			}
+			if i == j {
+				if sum <= 0 {
+					return nil, false
+				}
+				lower[i][i] = math.Sqrt(sum)
+			} else {
+				lower[i][j] = sum / lower[j][j]
+			}
+		}
+	}
+	return lower, true
+}
+
+/*
+Gaussian mixture model fitted with expectation maximization
+*/
+type gaussianMixture struct {
+	k        int
+	covType  string
+	maxIter  int
+	nInit    int
+	tol      float64
+	regCovar float64
+	init     string
+	// set by Fit
+	weights       []float64
+	means         [][]float64
+	covariances   [][][]float64
+	choleskys     [][][]float64
+	logLikelihood float64
+	converged     bool
+	nIter         int
+}
+
+/*
+Create an unfitted Gaussian mixture model
+
+	:parameter
+		*	k: number of components
+		*	covType: the form of the covariance matrices
+			-	full: every component has its own covariance matrix
+			-	diag: every component has its own diagonal covariance matrix
+			-	spherical: every component has its own single variance
+			-	tied: all components share one covariance matrix
+		*	maxIter: maximum number of EM iterations per run
+		*	nInit: number of runs with different initializations - the run with the highest likelihood is kept
+		*	tol: a run stops when the mean log-likelihood per vector changes less than tol
+		*	regCovar: added to the diagonal of the covariances to keep them positive definite
+		*	init: how the responsibilities are initialized (kmeans, random)
+	:return
+		*	model: the unfitted model
+*/
+func newGaussianMixture(k *int, covType *string, maxIter *int, nInit *int, tol *float64, regCovar *float64, init *string) *gaussianMixture {
+	if *k < 1 || *maxIter < 1 || *nInit < 1 || *regCovar < 0 {
+		log.Fatalln(fmt.Sprintf("Number of components [%d], iterations [%d] and runs [%d] have to be at least 1 and regCovar [%f] not negative", *k, *maxIter, *nInit, *regCovar))
+	}
+	chosenCov := *covType
+	if !isinString(covarianceTypes, chosenCov) {
+		fmt.Printf("Using default covariance type ['full'] instead of the not implementd ['%s']\n", chosenCov)
+		chosenCov = "full"
+	}
+	chosenInit := *init
+	if chosenInit != "kmeans" && chosenInit != "random" {
+		fmt.Printf("Using default initialization ['kmeans'] instead of the not implementd ['%s']\n", chosenInit)
+		chosenInit = "kmeans"
+	}
+	return &gaussianMixture{k: *k, covType: chosenCov, maxIter: *maxIter, nInit: *nInit, tol: *tol, regCovar: *regCovar, init: chosenInit}
+}
+
+/*
+Initial responsibilities - one-hot k-means clusters or random weights
+
+	:parameter
+		*	x: vectors of the data
+	:return
+		*	resp: responsibility of every component for every vector
+*/
+func (m *gaussianMixture) initialResponsibilities(x [][]float64) [][]float64 {
+	resp := make([][]float64, len(x))
+	if m.init == "random" {
+		for ci := range resp {
+			resp[ci] = make([]float64, m.k)
+			total := 0.0
+			for cj := range resp[ci] {
+				resp[ci][cj] = rand.Float64()
+				total += resp[ci][cj]
+			}
+			for cj := range resp[ci] {
+				resp[ci][cj] /= total
+			}
+		}
+		return resp
+	}
+	nInit, maxIter, tol, distType := 1, 300, 1e-4, "euclidean"
+	for ci, i := range newKMeans(&m.k, &nInit, &maxIter, &tol, &distType).FitPredict(x) {
+		resp[ci] = make([]float64, m.k)
+		resp[ci][i] = 1
+	}
+	return resp
+}
+
+/*
+M-step - weights, means and covariances from the responsibilities
+
+	:parameter
+		*	x: vectors of the data
+		*	resp: responsibility of every component for every vector
+	:return
+		None
+*/
+func (m *gaussianMixture) maximize(x [][]float64, resp [][]float64) {
+	n, dim := len(x), len(x[0])
+	counts := make([]float64, m.k)
+	for _, i := range resp {
+		for cj, j := range i {
+			counts[cj] += j
+		}
+	}
+	m.weights = make([]float64, m.k)
+	m.means = make([][]float64, m.k)
+	m.covariances = make([][][]float64, m.k)
+	// empty components would divide by 0 - add 10 times the machine epsilon
+	for c := range counts {
+		counts[c] += 10 * 2.220446049250313e-16
+	}
+	parallelFor(m.k, func(c int) {
+		m.weights[c] = counts[c] / float64(n)
+		m.means[c] = make([]float64, dim)
+		for ci, i := range x {
+			for cj, j := range i {
+				m.means[c][cj] += resp[ci][c] * j / counts[c]
+			}
+		}
+		cov := make([][]float64, dim)
+		for i := range cov {
+			cov[i] = make([]float64, dim)
+		}
+		diff := make([]float64, dim)
+		for ci, i := range x {
+			for cj, j := range i {
+				diff[cj] = j - m.means[c][cj]
+			}
+			for a := 0; a < dim; a++ {
+				// diagonal and spherical covariances only need the variances
+				if m.covType == "diag" || m.covType == "spherical" {
+					cov[a][a] += resp[ci][c] * diff[a] * diff[a]
+					continue
+				}
+				for b := 0; b <= a; b++ {
+					cov[a][b] += resp[ci][c] * diff[a] * diff[b]
+				}
+			}
+		}
+		// the tied covariance is the scatter of all components divided by n
+		for a := 0; a < dim; a++ {
+			for b := 0; b <= a; b++ {
+				if m.covType != "tied" {
+					cov[a][b] /= counts[c]
+				}
+				cov[b][a] = cov[a][b]
+			}
+		}
+		if m.covType == "spherical" {
+			variance := 0.0
+			for a := 0; a < dim; a++ {
+				variance += cov[a][a] / float64(dim)
+			}
+			for a := 0; a < dim; a++ {
+				cov[a][a] = variance
+			}
+		}
+		m.covariances[c] = cov
+	})
+	if m.covType == "tied" {
+		tied := make([][]float64, dim)
+		for a := range tied {
+			tied[a] = make([]float64, dim)
+			for b := range tied[a] {
+				for c := 0; c < m.k; c++ {
+					tied[a][b] += m.covariances[c][a][b] / float64(n)
+				}
+			}
+		}
+		for c := range m.covariances {
+			m.covariances[c] = tied
+		}
+	}
+	m.choleskys = make([][][]float64, m.k)
+	for c := range m.covariances {
+		if c > 0 && m.covType == "tied" {
+			m.choleskys[c] = m.choleskys[0]
+			continue
+		}
+		for a := 0; a < dim; a++ {
+			m.covariances[c][a][a] += m.regCovar
+		}
+		lower, ok := cholesky(m.covariances[c])
+		if !ok {
+			log.Fatalln(fmt.Sprintf("Covariance of component [%d] is not positive definite - increase regCovar [%g]", c, m.regCovar))
+		}
+		m.choleskys[c] = lower
+	}
+}
+
+/*
+Log of the weighted density of every component for every vector
+
+	:parameter
+		*	x: vectors of the data
+	:return
+		*	weighted: log(weight) + log(density) of every component for every vector
+*/
+func (m *gaussianMixture) weightedLogProb(x [][]float64) [][]float64 {
+	dim := len(m.means[0])
+	logDets := make([]float64, m.k)
+	for c, i := range m.choleskys {
+		for a := 0; a < dim; a++ {
+			logDets[c] += 2 * math.Log(i[a][a])
+		}
+	}
+	weighted := make([][]float64, len(x))
+	parallelFor(len(x), func(i int) {
+		weighted[i] = make([]float64, m.k)
+		z := make([]float64, dim)
+		for c, lower := range m.choleskys {
+			// solve lower * z = x - mean by forward substitution
+			squared := 0.0
+			for a := 0; a < dim; a++ {
+				sum := x[i][a] - m.means[c][a]
+				for b := 0; b < a; b++ {
+					sum -= lower[a][b] * z[b]
+				}
+				z[a] = sum / lower[a][a]
+				squared += z[a] * z[a]
+			}
+			weighted[i][c] = math.Log(m.weights[c]) - 0.5*(float64(dim)*math.Log(2*math.Pi)+logDets[c]+squared)
+		}
+	})
+	return weighted
+}
+
+/*
+E-step - responsibilities and the log-likelihood of every vector
+
+	:parameter
+		*	x: vectors of the data
+	:return
+		*	resp: responsibility of every component for every vector
+		*	logLikelihoods: log-likelihood of every vector
+*/
+func (m *gaussianMixture) expect(x [][]float64) ([][]float64, []float64) {
+	resp := m.weightedLogProb(x)
+	logLikelihoods := make([]float64, len(x))
+	for ci, i := range resp {
+		// log-sum-exp
+		maxLog := math.Inf(-1)
+		for _, j := range i {
+			maxLog = math.Max(maxLog, j)
+		}
+		total := 0.0
+		for _, j := range i {
+			total += math.Exp(j - maxLog)
+		}
+		logLikelihoods[ci] = maxLog + math.Log(total)
+		for cj, j := range i {
+			i[cj] = math.Exp(j - logLikelihoods[ci])
+		}
+	}
+	return resp, logLikelihoods
+}
+
+/*
+Fit the mixture with EM - keeps the run with the highest log-likelihood of nInit runs
+
+	:parameter
+		*	x: vectors of the data
+	:return
+		None
+*/
+func (m *gaussianMixture) Fit(x [][]float64) {
+	if len(x) < m.k {
+		log.Fatalln(fmt.Sprintf("Number of samples [%d] has to be at least the number of components [%d]", len(x), m.k))
+	}
+	var best *gaussianMixture
+	for run := 0; run < m.nInit; run++ {
+		m.maximize(x, m.initialResponsibilities(x))
+		m.converged = false
+		previous := math.Inf(-1)
+		for m.nIter = 1; m.nIter <= m.maxIter; m.nIter++ {
+			resp, logLikelihoods := m.expect(x)
+			m.logLikelihood = 0
+			for _, i := range logLikelihoods {
+				m.logLikelihood += i
+			}
+			if math.Abs(m.logLikelihood-previous)/float64(len(x)) < m.tol {
+				m.converged = true
+				break
+			}
+			previous = m.logLikelihood
+			m.maximize(x, resp)
+		}
+		if !m.converged {
+			// likelihood of the parameters of the last M-step
+			_, logLikelihoods := m.expect(x)
+			m.logLikelihood = 0
+			for _, i := range logLikelihoods {
+				m.logLikelihood += i
+			}
+			fmt.Printf("Gaussian mixture run [%d] did not converge in [%d] iterations\n", run, m.maxIter)
+		}
+		// runs with a NaN or -Inf likelihood are never kept
+		if !math.IsNaN(m.logLikelihood) && !math.IsInf(m.logLikelihood, -1) && (best == nil || m.logLikelihood > best.logLikelihood) {
+			fitted := *m
+			best = &fitted
+		}
+	}
+	if best == nil {
+		log.Fatalln(fmt.Sprintf("None of the [%d] runs of the Gaussian mixture reached a finite log-likelihood - increase regCovar [%g]", m.nInit, m.regCovar))
+	}
+	*m = *best
+}
+
+/*
+Probability of every component for every vector
+
+	:parameter
+		*	x: vectors for which the components should be predicted
+	:return
+		*	proba: probability of every component per vector
+*/
+func (m *gaussianMixture) PredictProba(x [][]float64) []map[int]float64 {
+	if m.means == nil {
+		log.Fatalln("Gaussian mixture has to be fitted before predicting")
+	}
+	resp, _ := m.expect(x)
+	proba := make([]map[int]float64, len(x))
+	for ci, i := range resp {
+		proba[ci] = make(map[int]float64, m.k)
+		for cj, j := range i {
+			proba[ci][cj] = j
+		}
+	}
+	return proba
+}
+
+/*
+Most probable component of every vector
+
+	:parameter
+		*	x: vectors for which the components should be predicted
+	:return
+		*	pred: predicted components
+*/
+func (m *gaussianMixture) Predict(x [][]float64) []int {
+	pred := make([]int, len(x))
+	for ci, i := range m.PredictProba(x) {
+		pred[ci] = argmaxClass(i)
+	}
+	return pred
+}
+
+/*
+Log-likelihood of every vector under the fitted mixture
+
+	:parameter
+		*	x: vectors of the data
+	:return
+		*	logLikelihoods: log density of every vector
+*/
+func (m *gaussianMixture) scoreSamples(x [][]float64) []float64 {
+	if m.means == nil {
+		log.Fatalln("Gaussian mixture has to be fitted before scoring")
+	}
+	_, logLikelihoods := m.expect(x)
+	return logLikelihoods
+}
+
+/*
+Number of free parameters of the mixture
+*/
+func (m *gaussianMixture) numParameters() int {
+	dim := len(m.means[0])
+	covParams := 0
+	switch m.covType {
+	case "full":
+		covParams = m.k * dim * (dim + 1) / 2
+	case "diag":
+		covParams = m.k * dim
+	case "spherical":
+		covParams = m.k
+	case "tied":
+		covParams = dim * (dim + 1) / 2
+	}
+	return covParams + m.k*dim + m.k - 1
+}
+
+/*
+Bayesian information criterion of the mixture on x - lower is better
+
+	:parameter
+		*	x: vectors of the data
+	:return
+		*	bic: -2 log-likelihood + number of parameters * log(n)
+*/
+func (m *gaussianMixture) bic(x [][]float64) float64 {
+	logLikelihood := 0.0
+	for _, i := range m.scoreSamples(x) {
+		logLikelihood += i
+	}
+	return -2*logLikelihood + float64(m.numParameters())*math.Log(float64(len(x)))
+}
+
+/*
+Akaike information criterion of the mixture on x - lower is better
+
+	:parameter
+		*	x: vectors of the data
+	:return
+		*	aic: -2 log-likelihood + 2 * number of parameters
+*/
+func (m *gaussianMixture) aic(x [][]float64) float64 {
+	logLikelihood := 0.0
+	for _, i := range m.scoreSamples(x) {
+		logLikelihood += i
+	}
+	return -2*logLikelihood + 2*float64(m.numParameters())
+}
+
+/*
+Draw random vectors from the fitted mixture
+
+	:parameter
+		*	numSamples: number of vectors
+	:return
+		*	samples: the drawn vectors
+		*	components: component each vector was drawn from
+*/
+func (m *gaussianMixture) sample(numSamples *int) ([][]float64, []int) {
+	if m.means == nil {
+		log.Fatalln("Gaussian mixture has to be fitted before sampling")
+	}
+	dim := len(m.means[0])
+	samples := make([][]float64, *numSamples)
+	components := make([]int, *numSamples)
+	for i := range samples {
+		draw := rand.Float64()
+		c := m.k - 1
+		for cj, j := range m.weights {
+			draw -= j
+			if draw < 0 {
+				c = cj
+				break
+			}
+		}
+		z := make([]float64, dim)
+		for a := range z {
+			z[a] = rand.NormFloat64()
+		}
+		// mean + lower * z has the covariance lower * lower^T
+		samples[i] = append([]float64{}, m.means[c]...)
+		for a := 0; a < dim; a++ {
+			for b := 0; b <= a; b++ {
+				samples[i][a] += m.choleskys[c][a][b] * z[b]
+			}
+		}
+		components[i] = c
+	}
+	return samples, components
+}
+
+/*
+Create an unfitted copy of the model with the same parameters
+*/
+func (m *gaussianMixture) Clone() *gaussianMixture {
+	return &gaussianMixture{k: m.k, covType: m.covType, maxIter: m.maxIter, nInit: m.nInit, tol: m.tol, regCovar: m.regCovar, init: m.init}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGaussianMixtureRecoversBlobs(t *testing.T) {
+	x, blobs := testBlobs([]int{60, 80, 100}, 2, 17)
+	k := 3
+	maxIter := 200
+	nInit := 2
+	tol := 1e-4
+	regCovar := 1e-6
+	init := "kmeans"
+	for _, covType := range covarianceTypes {
+		m := newGaussianMixture(&k, &covType, &maxIter, &nInit, &tol, &regCovar, &init)
+		m.Fit(x)
+		if m.k != k || m.covType != covType || !m.converged {
+			t.Errorf("%s: fitted model has k [%d], covariance type [%s] and converged [%t]", covType, m.k, m.covType, m.converged)
+		}
+		if labels := m.Predict(x); !testMatchesBlobs(labels, blobs) {
+			t.Errorf("%s: labels %v don't match the blobs", covType, labels)
+		}
+		// every blob gets the weight of its share of the data
+		weights := append([]float64{}, m.weights...)
+		for _, i := range []float64{60. / 240, 80. / 240, 100. / 240} {
+			found := false
+			for _, j := range weights {
+				found = found || math.Abs(i-j) < 1e-3
+			}
+			if !found {
+				t.Errorf("%s: weights %v miss %g", covType, weights, i)
+			}
+		}
+	}
+}
+
+func TestGaussianMixtureBIC(t *testing.T) {
+	x, _ := testBlobs([]int{60, 80, 100}, 2, 19)
+	maxIter := 200
+	nInit := 2
+	tol := 1e-4
+	regCovar := 1e-6
+	covType := "full"
+	init := "kmeans"
+	bestK, bestBIC := 0, math.Inf(1)
+	for k := 1; k <= 5; k++ {
+		m := newGaussianMixture(&k, &covType, &maxIter, &nInit, &tol, &regCovar, &init)
+		m.Fit(x)
+		if bic := m.bic(x); bic < bestBIC {
+			bestK, bestBIC = k, bic
+		}
+	}
+	if bestK != 3 {
+		t.Errorf("BIC chose [%d] components, want 3", bestK)
+	}
+}
+
+func TestGaussianMixtureCovarianceTypes(t *testing.T) {
+	// the first component has the covariance [[2 1] [1 1]] and the second [[8/9 0] [0 2/3]]
+	x := [][]float64{{0, 0}, {2, 2}, {2, 0}, {4, 2}, {10, 0}, {10, 2}, {12, 1}}
+	resp := [][]float64{{1, 0}, {1, 0}, {1, 0}, {1, 0}, {0, 1}, {0, 1}, {0, 1}}
+	want := map[string][][][]float64{
+		"full":      {{{2, 1}, {1, 1}}, {{8. / 9, 0}, {0, 2. / 3}}},
+		"diag":      {{{2, 0}, {0, 1}}, {{8. / 9, 0}, {0, 2. / 3}}},
+		"spherical": {{{1.5, 0}, {0, 1.5}}, {{7. / 9, 0}, {0, 7. / 9}}},
+		// scatter of both components divided by the 7 vectors
+		"tied": {{{32. / 21, 4. / 7}, {4. / 7, 6. / 7}}, {{32. / 21, 4. / 7}, {4. / 7, 6. / 7}}},
+	}
+	wantParameters := map[string]int{"full": 11, "diag": 9, "spherical": 7, "tied": 8}
+	k := 2
+	maxIter := 1
+	nInit := 1
+	tol := 1e-4
+	regCovar := 1e-3
+	init := "kmeans"
+	for _, covType := range covarianceTypes {
+		m := newGaussianMixture(&k, &covType, &maxIter, &nInit, &tol, &regCovar, &init)
+		m.maximize(x, resp)
+		if math.Abs(m.weights[0]-4./7) > 1e-12 || math.Abs(m.means[0][0]-2) > 1e-12 || math.Abs(m.means[1][0]-32./3) > 1e-12 || math.Abs(m.means[1][1]-1) > 1e-12 {
+			t.Errorf("%s: weights %v and means %v, want [4/7 3/7] and [[2 1] [32/3 1]]", covType, m.weights, m.means)
+		}
+		for c, cov := range want[covType] {
+			for a, row := range cov {
+				for b, j := range row {
+					if a == b {
+						j += regCovar
+					}
+					if math.Abs(m.covariances[c][a][b]-j) > 1e-9 {
+						t.Errorf("%s: covariance of component %d is %v, want %v plus regCovar", covType, c, m.covariances[c], cov)
+					}
+				}
+			}
+		}
+		if m.numParameters() != wantParameters[covType] {
+			t.Errorf("%s: %d parameters, want %d", covType, m.numParameters(), wantParameters[covType])
+		}
+		// AIC and BIC only differ in the penalty of the parameters
+		if diff := m.bic(x) - m.aic(x); math.Abs(diff-float64(wantParameters[covType])*(math.Log(7)-2)) > 1e-9 {
+			t.Errorf("%s: BIC - AIC is %g, want %g", covType, diff, float64(wantParameters[covType])*(math.Log(7)-2))
+		}
+	}
+}
+
+func TestGaussianMixtureDensity(t *testing.T) {
+	// one component with the mean 2 and the variance 1 + regCovar
+	x := [][]float64{{1}, {3}}
+	k := 1
+	covType := "full"
+	maxIter := 1
+	nInit := 1
+	tol := 1e-4
+	regCovar := 1e-6
+	init := "kmeans"
+	m := newGaussianMixture(&k, &covType, &maxIter, &nInit, &tol, &regCovar, &init)
+	m.maximize(x, [][]float64{{1}, {1}})
+	variance := 1 + regCovar
+	for _, i := range []float64{2, 0.5, 4} {
+		want := -0.5*math.Log(2*math.Pi*variance) - (i-2)*(i-2)/(2*variance)
+		if got := m.scoreSamples([][]float64{{i}})[0]; math.Abs(got-want) > 1e-12 {
+			t.Errorf("log density at %g is %g, want %g", i, got, want)
+		}
+	}
+	aic := -2*(m.scoreSamples(x)[0]+m.scoreSamples(x)[1]) + 2*2
+	if math.Abs(m.aic(x)-aic) > 1e-12 {
+		t.Errorf("AIC %g, want %g with 2 parameters", m.aic(x), aic)
+	}
+}
+
+func TestGaussianMixturePredictProba(t *testing.T) {
+	x, _ := testBlobs([]int{50, 50}, 2, 29)
+	k := 2
+	covType := "full"
+	maxIter := 100
+	nInit := 1
+	tol := 1e-4
+	regCovar := 1e-6
+	init := "kmeans"
+	m := newGaussianMixture(&k, &covType, &maxIter, &nInit, &tol, &regCovar, &init)
+	m.Fit(x)
+	for ci, i := range m.PredictProba(append(x, []float64{5, 5}, []float64{-20, 40})) {
+		sum := 0.0
+		for _, j := range i {
+			if j < 0 || j > 1 {
+				t.Errorf("vector %d has the probability %g", ci, j)
+			}
+			sum += j
+		}
+		if math.Abs(sum-1) > 1e-12 {
+			t.Errorf("probabilities of vector %d sum to %g", ci, sum)
+		}
+	}
+}
+
+func TestGaussianMixtureSample(t *testing.T) {
+	x := [][]float64{{0, 0}, {2, 2}, {2, 0}, {4, 2}, {10, 0}, {10, 2}, {12, 1}}
+	resp := [][]float64{{1, 0}, {1, 0}, {1, 0}, {1, 0}, {0, 1}, {0, 1}, {0, 1}}
+	k := 2
+	covType := "full"
+	maxIter := 1
+	nInit := 1
+	tol := 1e-4
+	regCovar := 1e-6
+	init := "kmeans"
+	m := newGaussianMixture(&k, &covType, &maxIter, &nInit, &tol, &regCovar, &init)
+	m.maximize(x, resp)
+	numSamples := 20000
+	samples, components := m.sample(&numSamples)
+	// the drawn vectors of every component have about its weight, mean and covariance
+	for c := 0; c < k; c++ {
+		members := [][]float64{}
+		for ci, i := range components {
+			if i == c {
+				members = append(members, samples[ci])
+			}
+		}
+		if weight := float64(len(members)) / float64(numSamples); math.Abs(weight-m.weights[c]) > 0.02 {
+			t.Errorf("component %d has the share %g, want %g", c, weight, m.weights[c])
+		}
+		mean := centroid(members)
+		for a := range mean {
+			if math.Abs(mean[a]-m.means[c][a]) > 0.05 {
+				t.Errorf("component %d has the sample mean %v, want %v", c, mean, m.means[c])
+			}
+			for b := range mean {
+				cov := 0.0
+				for _, i := range members {
+					cov += (i[a] - mean[a]) * (i[b] - mean[b]) / float64(len(members))
+				}
+				if math.Abs(cov-m.covariances[c][a][b]) > 0.1 {
+					t.Errorf("component %d has the sample covariance %g at [%d][%d], want %g", c, cov, a, b, m.covariances[c][a][b])
+				}
+			}
+		}
+	}
+}
+
+func TestGaussianMixturePredictTies(t *testing.T) {
+	// two mirrored components are equally probable at 0 so the smaller component has to win
+	x := [][]float64{{-1}, {-3}, {1}, {3}}
+	resp := [][]float64{{1, 0}, {1, 0}, {0, 1}, {0, 1}}
+	k := 2
+	covType := "diag"
+	maxIter := 1
+	nInit := 1
+	tol := 1e-4
+	regCovar := 1e-6
+	init := "kmeans"
+	m := newGaussianMixture(&k, &covType, &maxIter, &nInit, &tol, &regCovar, &init)
+	m.maximize(x, resp)
+	for i := 0; i < 20; i++ {
+		if pred := m.Predict([][]float64{{0}}); pred[0] != 0 {
+			t.Fatalf("tie predicted as component %d, want 0", pred[0])
+		}
+	}
+}
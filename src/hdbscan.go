@@ -0,0 +1,344 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+)
+
+/*
+Edge of the condensed cluster tree - child is a vector (< n) or a cluster (>= n) that leaves parent at lambda = 1 / distance
+*/
+type condensedEdge struct {
+	parent int
+	child  int
+	lambda float64
+	size   int
+}
+
+/*
+HDBSCAN (Campello et al. 2013) - hierarchical density based clustering that selects the most stable clusters of all densities
+*/
+type hdbscan struct {
+	minClusterSize int
+	minSamples     int
+	distType       string
+	selection      string
+	// set by Fit
+	linkageMat    [][]float64
+	condensedTree []condensedEdge
+	// stability of every cluster of the condensed tree - for eom the summed stability of the selected clusters below it if that is larger
+	stability     map[int]float64
+	selected      []int
+	labels        []int
+	probabilities []float64
+	outlierScores []float64
+}
+
+/*
+Create an unfitted HDBSCAN clustering
+
+	:parameter
+		*	minClusterSize: minimum number of vectors in a cluster
+		*	minSamples: which neighbor (the vector itself counted as first) defines the core distance - 0 to use minClusterSize
+		*	distType: which distance metric should be used (euclidean, manhattan, hamming, braycurtis)
+		*	selection: how clusters are selected from the condensed tree
+			-	eom: excess of mass - the most stable clusters
+			-	leaf: the leaves of the condensed tree - smaller and more homogeneous clusters
+	:return
+		*	model: the unfitted model
+*/
+func newHDBSCAN(minClusterSize *int, minSamples *int, distType *string, selection *string) *hdbscan {
+	if *minClusterSize < 2 {
+		log.Fatalln(fmt.Sprintf("Minimum cluster size [%d] has to be at least 2", *minClusterSize))
+	}
+	chosenDist := *distType
+	if _, ok := distanceFunctions[chosenDist]; !ok {
+		selectDistanceFunction(distType)
+		chosenDist = "euclidean"
+	}
+	chosenSamples := *minSamples
+	if chosenSamples <= 0 {
+		chosenSamples = *minClusterSize
+	}
+	chosenSelection := *selection
+	if chosenSelection != "eom" && chosenSelection != "leaf" {
+		fmt.Printf("Using default selection ['eom'] instead of the not implementd ['%s']\n", chosenSelection)
+		chosenSelection = "eom"
+	}
+	return &hdbscan{minClusterSize: *minClusterSize, minSamples: chosenSamples, distType: chosenDist, selection: chosenSelection}
+}
+
+/*
+Minimum spanning tree of the mutual reachability distances max(core distance a, core distance b, distance(a, b)) with Prim's algorithm - O(n²) time and O(n) memory
+
+	:parameter
+		*	x: vectors of the data
+		*	coreDists: core distance of every vector
+		*	distType: which distance metric should be used
+	:return
+		*	edges: the n - 1 edges of the tree with first and second as the connected vectors
+*/
+func mutualReachabilityMST(x [][]float64, coreDists []float64, distType *string) []clusterMerge {
+	distanceFunction := selectDistanceFunction(distType)
+	n := len(x)
+	inTree := make([]bool, n)
+	bestDist := make([]float64, n)
+	bestFrom := make([]int, n)
+	for i := range bestDist {
+		bestDist[i] = math.Inf(1)
+	}
+	edges := make([]clusterMerge, 0, n-1)
+	current := 0
+	for len(edges) < n-1 {
+		inTree[current] = true
+		for ci, i := range distanceFunction(x, x[current]) {
+			if inTree[ci] {
+				continue
+			}
+			if reach := math.Max(i, math.Max(coreDists[current], coreDists[ci])); reach < bestDist[ci] {
+				bestDist[ci], bestFrom[ci] = reach, current
+			}
+		}
+		next := -1
+		for i := 0; i < n; i++ {
+			if !inTree[i] && (next == -1 || bestDist[i] < bestDist[next]) {
+				next = i
+			}
+		}
+		edges = append(edges, clusterMerge{first: bestFrom[next], second: next, dist: bestDist[next]})
+		current = next
+	}
+	return edges
+}
+
+/*
+Condense the single linkage tree - splits where a side has less than minClusterSize vectors are vectors falling out of the cluster instead of new clusters
+
+	:parameter
+		*	merges: single linkage merges of the n vectors
+		*	minClusterSize: minimum number of vectors in a cluster
+	:return
+		*	tree: edges of the condensed tree - the root cluster is n and new clusters are numbered from n + 1
+*/
+func condenseTree(merges []clusterMerge, minClusterSize int) []condensedEdge {
+	n := len(merges) + 1
+	sizes := func(node int) int {
+		if node < n {
+			return 1
+		}
+		return merges[node-n].size
+	}
+	// all vectors below a node of the single linkage tree
+	var leaves func(node int, out []int) []int
+	leaves = func(node int, out []int) []int {
+		if node < n {
+			return append(out, node)
+		}
+		return leaves(merges[node-n].second, leaves(merges[node-n].first, out))
+	}
+	tree := []condensedEdge{}
+	nextLabel := n + 1
+	relabel := map[int]int{2*n - 2: n}
+	queue := []int{2*n - 2}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if node < n {
+			continue
+		}
+		merge := merges[node-n]
+		lambda := math.Inf(1)
+		if merge.dist > 0 {
+			lambda = 1 / merge.dist
+		}
+		parent := relabel[node]
+		left, right := merge.first, merge.second
+		leftBig, rightBig := sizes(left) >= minClusterSize, sizes(right) >= minClusterSize
+		for _, child := range []int{left, right} {
+			big := sizes(child) >= minClusterSize
+			switch {
+			case leftBig && rightBig:
+				// true split into two new clusters
+				relabel[child] = nextLabel
+				tree = append(tree, condensedEdge{parent: parent, child: nextLabel, lambda: lambda, size: sizes(child)})
+				nextLabel++
+				queue = append(queue, child)
+			case big:
+				// the cluster continues in its big side
+				relabel[child] = parent
+				queue = append(queue, child)
+			default:
+				// the vectors of a small side fall out of the cluster
+				for _, i := range leaves(child, nil) {
+					tree = append(tree, condensedEdge{parent: parent, child: i, lambda: lambda, size: 1})
+				}
+			}
+		}
+	}
+	return tree
+}
+
+/*
+Build the cluster hierarchy and select the clusters
+
+	:parameter
+		*	x: vectors that should be clustered
+	:return
+		None
+*/
+func (m *hdbscan) Fit(x [][]float64) {
+	n := len(x)
+	if n < 2 {
+		log.Fatalln(fmt.Sprintf("Number of samples [%d] has to be at least 2", n))
+	}
+	minSamples := int(math.Min(float64(m.minSamples), float64(n)))
+	coreDists := coreDistances(newNeighborIndex(x, &m.distType), minSamples)
+	merges := labelMerges(n, mutualReachabilityMST(x, coreDists, &m.distType))
+	m.linkageMat = mergesToLinkage(merges)
+	m.condensedTree = condenseTree(merges, m.minClusterSize)
+
+	// lambda at which every cluster is born and the clusters below every cluster
+	birth := map[int]float64{n: 0}
+	children := map[int][]int{}
+	maxCluster := n
+	for _, i := range m.condensedTree {
+		if i.child >= n {
+			birth[i.child] = i.lambda
+			children[i.parent] = append(children[i.parent], i.child)
+			maxCluster = int(math.Max(float64(maxCluster), float64(i.child)))
+		}
+	}
+	// stability = sum over all vectors of the lambda they leave the cluster minus the lambda the cluster was born
+	m.stability = make(map[int]float64, maxCluster-n+1)
+	for c := n; c <= maxCluster; c++ {
+		m.stability[c] = 0
+	}
+	maxLambda := m.maxFiniteLambda()
+	for _, i := range m.condensedTree {
+		lambda := math.Min(i.lambda, maxLambda)
+		m.stability[i.parent] += (lambda - math.Min(birth[i.parent], maxLambda)) * float64(i.size)
+	}
+
+	// select clusters bottom up - the root is never a cluster
+	isSelected := map[int]bool{}
+	var unselect func(c int)
+	unselect = func(c int) {
+		for _, i := range children[c] {
+			isSelected[i] = false
+			unselect(i)
+		}
+	}
+	for c := maxCluster; c > n; c-- {
+		if m.selection == "leaf" {
+			isSelected[c] = len(children[c]) == 0
+			continue
+		}
+		childStability := 0.0
+		for _, i := range children[c] {
+			childStability += m.stability[i]
+		}
+		if len(children[c]) > 0 && childStability > m.stability[c] {
+			isSelected[c] = false
+			m.stability[c] = childStability
+		} else {
+			isSelected[c] = true
+			unselect(c)
+		}
+	}
+	m.selected = []int{}
+	for c := n + 1; c <= maxCluster; c++ {
+		if isSelected[c] {
+			m.selected = append(m.selected, c)
+		}
+	}
+	m.label(n)
+}
+
+/*
+Largest finite lambda of the condensed tree - duplicates never separate so their infinite lambda is clipped to it
+*/
+func (m *hdbscan) maxFiniteLambda() float64 {
+	maxLambda := 0.0
+	for _, i := range m.condensedTree {
+		if !math.IsInf(i.lambda, 1) {
+			maxLambda = math.Max(maxLambda, i.lambda)
+		}
+	}
+	return maxLambda
+}
+
+/*
+Labels, membership probabilities and GLOSH outlier scores of all vectors from the condensed tree and the selected clusters
+
+	:parameter
+		*	n: number of vectors
+	:return
+		None
+*/
+func (m *hdbscan) label(n int) {
+	parentOf := map[int]int{}
+	maxLambda := m.maxFiniteLambda()
+	// largest lambda of any vector below every cluster
+	deaths := map[int]float64{}
+	for _, i := range m.condensedTree {
+		if i.child >= n {
+			parentOf[i.child] = i.parent
+		}
+	}
+	for _, i := range m.condensedTree {
+		if i.child < n {
+			for c, ok := i.parent, true; ok; c, ok = parentOf[c] {
+				deaths[c] = math.Max(deaths[c], math.Min(i.lambda, maxLambda))
+			}
+		}
+	}
+	clusterLabel := make(map[int]int, len(m.selected))
+	for ci, i := range m.selected {
+		clusterLabel[i] = ci
+	}
+	m.labels = make([]int, n)
+	m.probabilities = make([]float64, n)
+	m.outlierScores = make([]float64, n)
+	for _, i := range m.condensedTree {
+		if i.child >= n {
+			continue
+		}
+		lambda := math.Min(i.lambda, maxLambda)
+		m.labels[i.child] = -1
+		for c, ok := i.parent, true; ok; c, ok = parentOf[c] {
+			if label, isCluster := clusterLabel[c]; isCluster {
+				m.labels[i.child] = label
+				m.probabilities[i.child] = 1
+				if death := deaths[c]; death > 0 {
+					m.probabilities[i.child] = math.Min(lambda, death) / death
+				}
+				break
+			}
+		}
+		// GLOSH compares the density at which a vector leaves to the highest density of its cluster
+		if death := deaths[i.parent]; death > 0 {
+			m.outlierScores[i.child] = (death - lambda) / death
+		}
+	}
+}
+
+/*
+Cluster the data and return the cluster of every vector
+
+	:parameter
+		*	x: vectors that should be clustered
+	:return
+		*	labels: cluster of every vector - -1 for noise
+*/
+func (m *hdbscan) FitPredict(x [][]float64) []int {
+	m.Fit(x)
+	return m.labels
+}
+
+/*
+Create an unfitted copy of the model with the same parameters
+*/
+func (m *hdbscan) Clone() *hdbscan {
+	return &hdbscan{minClusterSize: m.minClusterSize, minSamples: m.minSamples, distType: m.distType, selection: m.selection}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestHDBSCANRecoversBlobs(t *testing.T) {
+	x, blobs := testBlobs([]int{50, 70, 90}, 2, 11)
+	minClusterSize := 10
+	minSamples := 0
+	distType := "euclidean"
+	selection := "eom"
+	m := newHDBSCAN(&minClusterSize, &minSamples, &distType, &selection)
+	if labels := m.FitPredict(x); !testMatchesBlobs(labels, blobs) {
+		t.Errorf("labels %v don't match the blobs", labels)
+	}
+}
+
+func TestHDBSCANDuplicates(t *testing.T) {
+	// a cluster with more duplicates than minSamples has an infinite lambda that must not dominate its members
+	rng := rand.New(rand.NewSource(13))
+	x := [][]float64{}
+	for i := 0; i < 40; i++ {
+		x = append(x, []float64{rng.NormFloat64(), rng.NormFloat64()})
+	}
+	for i := 0; i < 10; i++ {
+		x = append(x, []float64{0, 0})
+	}
+	for i := 0; i < 40; i++ {
+		x = append(x, []float64{20 + rng.NormFloat64(), rng.NormFloat64()})
+	}
+	minClusterSize := 5
+	minSamples := 5
+	distType := "euclidean"
+	selection := "eom"
+	m := newHDBSCAN(&minClusterSize, &minSamples, &distType, &selection)
+	m.Fit(x)
+	belowOne, positiveScore := 0, 0
+	for i := 0; i < 50; i++ {
+		if m.probabilities[i] < 1 {
+			belowOne++
+		}
+		if m.outlierScores[i] > 0 {
+			positiveScore++
+		}
+	}
+	if belowOne == 0 || positiveScore == 0 {
+		t.Errorf("[%d] probabilities below 1 and [%d] positive outlier scores in the cluster with duplicates", belowOne, positiveScore)
+	}
+	for i := 40; i < 50; i++ {
+		if m.probabilities[i] != 1 || m.outlierScores[i] != 0 {
+			t.Errorf("duplicate [%d] has probability %g and outlier score %g, want 1 and 0", i, m.probabilities[i], m.outlierScores[i])
+		}
+	}
+}
+
+/*
+Two overlapping blobs at (0, 0) and (2, 0), a blob at (30, 30) and vectors 3, 5 and 8 above the last blob
+*/
+func testNestedBlobs() ([][]float64, []int) {
+	x := [][]float64{}
+	blobs := []int{}
+	for ci, i := range []struct {
+		size  int
+		seed  int64
+		shift []float64
+	}{{30, 17, []float64{0, 0}}, {30, 19, []float64{2, 0}}, {40, 23, []float64{30, 30}}} {
+		blob, _ := testBlobs([]int{i.size}, 2, i.seed)
+		for _, j := range blob {
+			x = append(x, []float64{j[0] + i.shift[0], j[1] + i.shift[1]})
+			blobs = append(blobs, ci)
+		}
+	}
+	x = append(x, []float64{30, 33}, []float64{30, 35}, []float64{30, 38})
+	return x, blobs
+}
+
+func TestHDBSCANLeafSelection(t *testing.T) {
+	x, blobs := testNestedBlobs()
+	minClusterSize := 10
+	minSamples := 0
+	distType := "euclidean"
+	// excess of mass keeps the overlapping blobs together and leaf selection splits them
+	for selection, want := range map[string][]int{"eom": {0, 0, 1}, "leaf": {0, 1, 2}} {
+		m := newHDBSCAN(&minClusterSize, &minSamples, &distType, &selection)
+		labels := m.FitPredict(x)
+		wantLabels := make([]int, len(blobs))
+		for ci, i := range blobs {
+			wantLabels[ci] = want[i]
+		}
+		// leaf clusters are born late so that vectors at the border of the blobs are noise
+		clustered := []int{}
+		for ci := range blobs {
+			if labels[ci] != -1 {
+				clustered = append(clustered, ci)
+			}
+		}
+		if len(clustered) < len(blobs)*3/4 || !testMatchesBlobs(subsetRows(labels, clustered), subsetRows(wantLabels, clustered)) {
+			t.Errorf("%s: labels %v, want the blobs grouped as %v", selection, labels, want)
+		}
+		parents := map[int]bool{}
+		for _, i := range m.condensedTree {
+			if i.child >= len(x) {
+				parents[i.parent] = true
+			}
+		}
+		for _, i := range m.selected {
+			if selection == "leaf" && parents[i] {
+				t.Errorf("leaf selection selected the cluster [%d] with child clusters", i)
+			}
+		}
+	}
+}
+
+func TestHDBSCANOutlierScores(t *testing.T) {
+	x, blobs := testNestedBlobs()
+	minClusterSize := 10
+	minSamples := 0
+	distType := "euclidean"
+	selection := "eom"
+	m := newHDBSCAN(&minClusterSize, &minSamples, &distType, &selection)
+	m.Fit(x)
+	// the farther a vector is from the densest part of its cluster the higher its GLOSH score
+	outliers := m.outlierScores[len(blobs):]
+	maxBlobScore := 0.0
+	for ci, i := range blobs {
+		if i == 2 {
+			maxBlobScore = math.Max(maxBlobScore, m.outlierScores[ci])
+		}
+	}
+	if !(maxBlobScore < outliers[0] && outliers[0] < outliers[1] && outliers[1] < outliers[2] && outliers[2] <= 1) {
+		t.Errorf("outlier scores %v of the vectors above the blob with the highest score %g, want increasing scores above it", outliers, maxBlobScore)
+	}
+	for ci, i := range m.probabilities {
+		if i < 0 || i > 1 || (m.labels[ci] == -1 && i != 0) {
+			t.Errorf("vector [%d] with label %d has the probability %g", ci, m.labels[ci], i)
+		}
+	}
+}
@@ -0,0 +1,512 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+)
+
+/*
+Result of a hypothesis test
+*/
+type testResult struct {
+	statistic float64
+	// two sided p-value
+	pValue float64
+	// degrees of freedom - two for the F-test (numerator, denominator) and nil if the test has none
+	df []float64
+}
+
+/*
+Format the result of a test
+*/
+func (t testResult) String() string {
+	if t.df == nil {
+		return fmt.Sprintf("statistic: %.4f p-value: %.4g", t.statistic, t.pValue)
+	}
+	return fmt.Sprintf("statistic: %.4f p-value: %.4g df: %v", t.statistic, t.pValue, t.df)
+}
+
+/*
+Sum of t^3 - t over all groups of t tied values used to correct rank tests for ties
+*/
+func tieCorrectionSum(values []float64) float64 {
+	sortedValues := append([]float64{}, values...)
+	sort.Float64s(sortedValues)
+	sum := 0.0
+	run := 1.0
+	for i := 1; i <= len(sortedValues); i++ {
+		if i < len(sortedValues) && sortedValues[i] == sortedValues[i-1] {
+			run++
+		} else {
+			sum += run*run*run - run
+			run = 1
+		}
+	}
+	return sum
+}
+
+/*
+Mean and sample variance of a vector
+*/
+func meanSampleVariance(values []float64) (float64, float64) {
+	mean, std := meanStd(values)
+	n := float64(len(values))
+	return mean, std * std * n / (n - 1)
+}
+
+/*
+Result of a test whose samples have no variance - no difference gives a statistic of 0 and a p-value of 1 and any difference an infinite statistic and a p-value of 0
+
+	:parameter
+		*	difference: difference of the means that the test compares
+		*	df: degrees of freedom of the test
+	:return
+		*	result: the degenerate test result
+*/
+func zeroVarianceResult(difference float64, df []float64) testResult {
+	if difference == 0 {
+		return testResult{statistic: 0, pValue: 1, df: df}
+	}
+	return testResult{statistic: math.Copysign(math.Inf(1), difference), pValue: 0, df: df}
+}
+
+/*
+Welch's t-test for the equality of the means of two independent samples with possibly unequal variances
+
+	:parameter
+		*	x, y: the two samples
+	:return
+		*	result: t statistic, two sided p-value and the Welch-Satterthwaite degrees of freedom - see zeroVarianceResult for constant samples
+*/
+func welchTTest(x, y []float64) testResult {
+	if len(x) < 2 || len(y) < 2 {
+		log.Fatalln(fmt.Sprintf("Welch's t-test needs at least 2 values per sample but got [%d] and [%d]", len(x), len(y)))
+	}
+	meanX, varX := meanSampleVariance(x)
+	meanY, varY := meanSampleVariance(y)
+	seX := varX / float64(len(x))
+	seY := varY / float64(len(y))
+	if seX+seY == 0 {
+		return zeroVarianceResult(meanX-meanY, []float64{float64(len(x) + len(y) - 2)})
+	}
+	t := (meanX - meanY) / math.Sqrt(seX+seY)
+	df := (seX + seY) * (seX + seY) / (seX*seX/float64(len(x)-1) + seY*seY/float64(len(y)-1))
+	return testResult{statistic: t, pValue: studentTTwoSidedP(t, df), df: []float64{df}}
+}
+
+/*
+Paired t-test for a mean difference of zero between two related samples
+
+	:parameter
+		*	x, y: the paired samples
+	:return
+		*	result: t statistic, two sided p-value and n - 1 degrees of freedom - see zeroVarianceResult for constant differences
+*/
+func pairedTTest(x, y []float64) testResult {
+	assertEqualLengthFloat(x, y)
+	if len(x) < 2 {
+		log.Fatalln(fmt.Sprintf("The paired t-test needs at least 2 pairs but got [%d]", len(x)))
+	}
+	diff := make([]float64, len(x))
+	for ci, i := range x {
+		diff[ci] = i - y[ci]
+	}
+	mean, variance := meanSampleVariance(diff)
+	df := float64(len(diff) - 1)
+	if variance == 0 {
+		return zeroVarianceResult(mean, []float64{df})
+	}
+	t := mean / math.Sqrt(variance/float64(len(diff)))
+	return testResult{statistic: t, pValue: studentTTwoSidedP(t, df), df: []float64{df}}
+}
+
+/*
+Mann-Whitney U test whether two independent samples come from the same distribution - normal approximation with tie and continuity correction
+
+	:parameter
+		*	x, y: the two samples
+	:return
+		*	result: U statistic of x and the two sided p-value
+*/
+func mannWhitneyU(x, y []float64) testResult {
+	n1, n2 := float64(len(x)), float64(len(y))
+	n := n1 + n2
+	ranks := rankAverage(append(append([]float64{}, x...), y...))
+	rankSumX := *sumFloat64(ranks[:len(x)])
+	u1 := rankSumX - n1*(n1+1)/2
+	u := math.Max(u1, n1*n2-u1)
+	mu := n1 * n2 / 2
+	sigma := math.Sqrt(n1 * n2 / 12 * ((n + 1) - tieCorrectionSum(ranks)/(n*(n-1))))
+	z := (u - mu - 0.5) / sigma
+	return testResult{statistic: u1, pValue: math.Min(1, 2*normalCDF(-z))}
+}
+
+/*
+Wilcoxon signed-rank test for a median difference of zero between two related samples - zero differences are dropped and the p-value uses the tie corrected normal approximation
+
+	:parameter
+		*	x, y: the paired samples
+	:return
+		*	result: smaller of the positive and negative rank sums and the two sided p-value
+*/
+func wilcoxonSignedRank(x, y []float64) testResult {
+	assertEqualLengthFloat(x, y)
+	diff := []float64{}
+	absDiff := []float64{}
+	for ci, i := range x {
+		if d := i - y[ci]; d != 0 {
+			diff = append(diff, d)
+			absDiff = append(absDiff, math.Abs(d))
+		}
+	}
+	if len(diff) == 0 {
+		log.Fatalln("All differences are zero - the Wilcoxon signed-rank test is not defined")
+	}
+	ranks := rankAverage(absDiff)
+	rankSumPos, rankSumNeg := 0.0, 0.0
+	for ci, i := range diff {
+		if i > 0 {
+			rankSumPos += ranks[ci]
+		} else {
+			rankSumNeg += ranks[ci]
+		}
+	}
+	n := float64(len(diff))
+	statistic := math.Min(rankSumPos, rankSumNeg)
+	mean := n * (n + 1) / 4
+	sd := math.Sqrt(n*(n+1)*(2*n+1)/24 - tieCorrectionSum(absDiff)/48)
+	z := (statistic - mean) / sd
+	return testResult{statistic: statistic, pValue: math.Min(1, 2*normalCDF(z))}
+}
+
+/*
+Survival function of the Kolmogorov distribution
+
+	:parameter
+		*	lambda: the scaled KS statistic
+	:return
+		*	p: probability of a value > lambda
+*/
+func kolmogorovSF(lambda float64) float64 {
+	a2 := -2 * lambda * lambda
+	fac := 2.0
+	sum := 0.0
+	prevTerm := 0.0
+	for j := 1; j <= 100; j++ {
+		term := fac * math.Exp(a2*float64(j*j))
+		sum += term
+		if math.Abs(term) <= 1e-3*prevTerm || math.Abs(term) <= 1e-8*sum {
+			return math.Max(0, math.Min(1, sum))
+		}
+		fac = -fac
+		prevTerm = math.Abs(term)
+	}
+	// the series did not converge because lambda is close to 0
+	return 1
+}
+
+/*
+One sample Kolmogorov-Smirnov test whether a sample follows a given distribution - asymptotic p-value with the Stephens correction
+
+	:parameter
+		*	x: the sample
+		*	cdf: cumulative distribution function of the hypothesized distribution e.g. normalCDF
+	:return
+		*	result: largest distance D between the empirical and the hypothesized CDF and the p-value
+*/
+func ksOneSample(x []float64, cdf func(float64) float64) testResult {
+	sortedX := append([]float64{}, x...)
+	sort.Float64s(sortedX)
+	n := float64(len(sortedX))
+	d := 0.0
+	for ci, i := range sortedX {
+		f := cdf(i)
+		d = math.Max(d, math.Max(float64(ci+1)/n-f, f-float64(ci)/n))
+	}
+	sqrtN := math.Sqrt(n)
+	return testResult{statistic: d, pValue: kolmogorovSF((sqrtN + 0.12 + 0.11/sqrtN) * d)}
+}
+
+/*
+Two sample Kolmogorov-Smirnov test whether two independent samples come from the same distribution - asymptotic p-value with the Stephens correction
+
+	:parameter
+		*	x, y: the two samples
+	:return
+		*	result: largest distance D between the two empirical CDFs and the p-value
+*/
+func ksTwoSample(x, y []float64) testResult {
+	sortedX := append([]float64{}, x...)
+	sortedY := append([]float64{}, y...)
+	sort.Float64s(sortedX)
+	sort.Float64s(sortedY)
+	n1, n2 := float64(len(x)), float64(len(y))
+	d := 0.0
+	i, j := 0, 0
+	for i < len(sortedX) && j < len(sortedY) {
+		// step over all values equal to the smaller current value in both samples
+		value := math.Min(sortedX[i], sortedY[j])
+		for i < len(sortedX) && sortedX[i] == value {
+			i++
+		}
+		for j < len(sortedY) && sortedY[j] == value {
+			j++
+		}
+		d = math.Max(d, math.Abs(float64(i)/n1-float64(j)/n2))
+	}
+	en := math.Sqrt(n1 * n2 / (n1 + n2))
+	return testResult{statistic: d, pValue: kolmogorovSF((en + 0.12 + 0.11/en) * d)}
+}
+
+/*
+Chi-square goodness-of-fit test of observed counts against expected counts
+
+	:parameter
+		*	observed: observed count per category
+		*	expected: expected count or frequency per category (rescaled to the total of observed) - nil for equally likely categories
+	:return
+		*	result: chi-square statistic, p-value and k - 1 degrees of freedom
+*/
+func chiSquareGoodnessOfFit(observed, expected []float64) testResult {
+	total := *sumFloat64(observed)
+	k := len(observed)
+	if expected == nil {
+		expected = make([]float64, k)
+		for i := range expected {
+			expected[i] = 1
+		}
+	} else {
+		assertEqualLengthFloat(observed, expected)
+	}
+	expectedTotal := *sumFloat64(expected)
+	statistic := 0.0
+	for ci, i := range observed {
+		e := expected[ci] * total / expectedTotal
+		statistic += (i - e) * (i - e) / e
+	}
+	df := float64(k - 1)
+	return testResult{statistic: statistic, pValue: chiSquareSF(statistic, df), df: []float64{df}}
+}
+
+/*
+Chi-square test of independence of the two variables of a contingency table
+
+	:parameter
+		*	table: contingency table with the counts of every combination of the row and column variable
+		*	correction: true to apply Yates' continuity correction to 2x2 tables
+	:return
+		*	result: chi-square statistic, p-value and (rows - 1) * (columns - 1) degrees of freedom
+*/
+func chiSquareIndependence(table [][]float64, correction *bool) testResult {
+	rowSums := make([]float64, len(table))
+	colSums := make([]float64, len(table[0]))
+	total := 0.0
+	for ci, i := range table {
+		if len(i) != len(colSums) {
+			log.Fatalln(fmt.Sprintf("Row [%d] of the contingency table has [%d] instead of [%d] columns", ci, len(i), len(colSums)))
+		}
+		for cj, j := range i {
+			rowSums[ci] += j
+			colSums[cj] += j
+			total += j
+		}
+	}
+	df := float64((len(rowSums) - 1) * (len(colSums) - 1))
+	statistic := 0.0
+	for ci, i := range table {
+		for cj, j := range i {
+			e := rowSums[ci] * colSums[cj] / total
+			diff := math.Abs(j - e)
+			if *correction && df == 1 {
+				diff -= math.Min(0.5, diff)
+			}
+			statistic += diff * diff / e
+		}
+	}
+	return testResult{statistic: statistic, pValue: chiSquareSF(statistic, df), df: []float64{df}}
+}
+
+/*
+Count the combinations of two categorical variables
+
+	:parameter
+		*	x: categories of the row variable
+		*	y: categories of the column variable
+	:return
+		*	table: count of every combination with the categories sorted
+		*	rowCategories: category of every row
+		*	colCategories: category of every column
+*/
+func crosstab(x, y []int) ([][]float64, []int, []int) {
+	assertEqualLengthInt(x, y)
+	rowCategories := uniqueInts(x)
+	colCategories := uniqueInts(y)
+	rowIdx := make(map[int]int)
+	for ci, i := range rowCategories {
+		rowIdx[i] = ci
+	}
+	colIdx := make(map[int]int)
+	for ci, i := range colCategories {
+		colIdx[i] = ci
+	}
+	table := make([][]float64, len(rowCategories))
+	for i := range table {
+		table[i] = make([]float64, len(colCategories))
+	}
+	for ci, i := range x {
+		table[rowIdx[i]][colIdx[y[ci]]]++
+	}
+	return table, rowCategories, colCategories
+}
+
+/*
+One-way ANOVA F-test for the equality of the means of several independent groups
+
+	:parameter
+		*	groups: the samples of every group
+	:return
+		*	result: F statistic, p-value and the degrees of freedom between (k - 1) and within (N - k) the groups
+*/
+func oneWayANOVA(groups ...[]float64) testResult {
+	if len(groups) < 2 {
+		log.Fatalln(fmt.Sprintf("ANOVA needs at least 2 groups but got [%d]", len(groups)))
+	}
+	total, n := 0.0, 0.0
+	for _, i := range groups {
+		total += *sumFloat64(i)
+		n += float64(len(i))
+	}
+	grandMean := total / n
+	ssBetween, ssWithin := 0.0, 0.0
+	for _, i := range groups {
+		mean, std := meanStd(i)
+		ni := float64(len(i))
+		ssBetween += ni * (mean - grandMean) * (mean - grandMean)
+		ssWithin += ni * std * std
+	}
+	dfBetween := float64(len(groups) - 1)
+	dfWithin := n - float64(len(groups))
+	if ssWithin == 0 {
+		return zeroVarianceResult(ssBetween, []float64{dfBetween, dfWithin})
+	}
+	f := (ssBetween / dfBetween) / (ssWithin / dfWithin)
+	return testResult{statistic: f, pValue: fisherFSF(f, dfBetween, dfWithin), df: []float64{dfBetween, dfWithin}}
+}
+
+/*
+Kruskal-Wallis H test whether several independent groups come from the same distribution - tie corrected with a chi-square p-value
+
+	:parameter
+		*	groups: the samples of every group
+	:return
+		*	result: H statistic, p-value and k - 1 degrees of freedom
+*/
+func kruskalWallis(groups ...[]float64) testResult {
+	if len(groups) < 2 {
+		log.Fatalln(fmt.Sprintf("The Kruskal-Wallis test needs at least 2 groups but got [%d]", len(groups)))
+	}
+	all := []float64{}
+	for _, i := range groups {
+		all = append(all, i...)
+	}
+	ranks := rankAverage(all)
+	n := float64(len(all))
+	df := float64(len(groups) - 1)
+	// all values are tied so that every group has the same ranks
+	if tieCorrectionSum(all) == n*n*n-n {
+		return testResult{statistic: 0, pValue: 1, df: []float64{df}}
+	}
+	h := 0.0
+	start := 0
+	for _, i := range groups {
+		rankSum := *sumFloat64(ranks[start : start+len(i)])
+		h += rankSum * rankSum / float64(len(i))
+		start += len(i)
+	}
+	h = 12/(n*(n+1))*h - 3*(n+1)
+	h /= 1 - tieCorrectionSum(all)/(n*n*n-n)
+	return testResult{statistic: h, pValue: chiSquareSF(h, df), df: []float64{df}}
+}
+
+/*
+Split one feature (column) of the feature matrix by class - missing (NaN) values are skipped
+
+	:parameter
+		*	features: feature matrix as returned by genTrainTestData
+		*	labels: class of every sample
+		*	colInd: index (zero indexed) of the feature
+	:return
+		*	groups: values of the feature for every class
+		*	classes: the class of every group in ascending order
+*/
+func groupByLabel(features [][]float64, labels []int, colInd *int) ([][]float64, []int) {
+	if len(features) != len(labels) {
+		log.Fatalln(fmt.Sprintf("Number of samples [%d] and labels [%d] differ", len(features), len(labels)))
+	}
+	classes := uniqueInts(labels)
+	classIdx := make(map[int]int)
+	for ci, i := range classes {
+		classIdx[i] = ci
+	}
+	groups := make([][]float64, len(classes))
+	for ci, i := range features {
+		if !math.IsNaN(i[*colInd]) {
+			groups[classIdx[labels[ci]]] = append(groups[classIdx[labels[ci]]], i[*colInd])
+		}
+	}
+	return groups, classes
+}
+
+/*
+Tests comparing the distributions of groups that can be selected by their name
+*/
+var groupTests = map[string]func(groups ...[]float64) testResult{
+	"welch":       twoGroups(welchTTest),
+	"mannwhitney": twoGroups(mannWhitneyU),
+	"ks":          twoGroups(ksTwoSample),
+	"anova":       oneWayANOVA,
+	"kruskal":     kruskalWallis,
+}
+
+/*
+Turn a two sample test into a test of groups that only accepts two groups
+*/
+func twoGroups(test func(x, y []float64) testResult) func(groups ...[]float64) testResult {
+	return func(groups ...[]float64) testResult {
+		if len(groups) != 2 {
+			log.Fatalln(fmt.Sprintf("Test needs exactly 2 groups but got [%d]", len(groups)))
+		}
+		return test(groups[0], groups[1])
+	}
+}
+
+/*
+Test for every feature whether its distribution differs between the classes
+
+	:parameter
+		*	features: feature matrix as returned by genTrainTestData
+		*	labels: class of every sample
+		*	testType: which test should be used
+			-	welch: Welch's t-test (two classes)
+			-	mannwhitney: Mann-Whitney U test (two classes)
+			-	ks: two sample Kolmogorov-Smirnov test (two classes)
+			-	anova: one-way ANOVA
+			-	kruskal: Kruskal-Wallis H test
+	:return
+		*	results: result of the test for every feature
+*/
+func featureClassTests(features [][]float64, labels []int, testType *string) []testResult {
+	test, ok := groupTests[*testType]
+	if !ok {
+		fmt.Printf("Using default test ['kruskal'] instead of the not implementd ['%s']\n", *testType)
+		test = kruskalWallis
+	}
+	results := make([]testResult, len(features[0]))
+	parallelFor(len(results), func(i int) {
+		groups, _ := groupByLabel(features, labels, &i)
+		results[i] = test(groups...)
+	})
+	return results
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHypothesisTestsReferenceValues(t *testing.T) {
+	correction := true
+	noCorrection := false
+	table := [][]float64{{10, 20}, {20, 10}}
+	// the p-values follow from the closed forms of the distributions with few degrees of freedom
+	for _, i := range []struct {
+		name                  string
+		result                testResult
+		statistic, pValue, df float64
+	}{
+		// t distribution with 2 df: p = 1 - |t| / sqrt(t^2 + 2)
+		{"welch", welchTTest([]float64{0, 2}, []float64{3, 5}), -3 / math.Sqrt(2), 1 - 3/math.Sqrt(2)/math.Sqrt(4.5+2), 2},
+		{"paired", pairedTTest([]float64{2, 4, 6}, []float64{1, 2, 3}), math.Sqrt(12), 1 - math.Sqrt(12)/math.Sqrt(14), 2},
+		// chi-square with 2 df: p = exp(-x / 2)
+		{"goodness of fit", chiSquareGoodnessOfFit([]float64{10, 20, 30}, []float64{20, 20, 20}), 10, math.Exp(-5), 2},
+		{"independence", chiSquareIndependence(table, &noCorrection), 20. / 3, math.Erfc(math.Sqrt(20. / 3 / 2)), 1},
+		{"independence yates", chiSquareIndependence(table, &correction), 5.4, math.Erfc(math.Sqrt(5.4 / 2)), 1},
+		// F distribution with 2 and 6 df: p = (1 + 2 f / 6)^-3
+		{"anova", oneWayANOVA([]float64{1, 2, 3}, []float64{4, 5, 6}, []float64{7, 8, 9}), 27, 0.001, 2},
+		{"kruskal", kruskalWallis([]float64{1, 2, 3}, []float64{4, 5, 6}, []float64{7, 8, 9}), 7.2, math.Exp(-3.6), 2},
+	} {
+		if math.Abs(i.result.statistic-i.statistic) > 1e-9 || math.Abs(i.result.pValue-i.pValue) > 1e-6 || i.result.df[0] != i.df {
+			t.Errorf("%s: %v, want statistic %g, p-value %g and df %g", i.name, i.result, i.statistic, i.pValue, i.df)
+		}
+	}
+	// normal approximation with continuity correction: z = (20 - 10 - 0.5) / sqrt(4 * 5 * 10 / 12)
+	mw := mannWhitneyU([]float64{1, 2, 3, 4}, []float64{5, 6, 7, 8, 9})
+	if mw.statistic != 0 || math.Abs(mw.pValue-math.Erfc(9.5/math.Sqrt(200./12)/math.Sqrt2)) > 1e-9 {
+		t.Errorf("mann-whitney %v, want statistic 0 and p-value 0.01996", mw)
+	}
+	// differences 1 -2 3 4 5 with the negative rank sum 2 and z = (2 - 7.5) / sqrt(5 * 6 * 11 / 24)
+	wx := wilcoxonSignedRank([]float64{2, 0, 6, 8, 10}, []float64{1, 2, 3, 4, 5})
+	if wx.statistic != 2 || math.Abs(wx.pValue-math.Erfc(5.5/math.Sqrt(13.75)/math.Sqrt2)) > 1e-9 {
+		t.Errorf("wilcoxon %v, want statistic 2 and p-value 0.138", wx)
+	}
+	// critical value of the Kolmogorov distribution at the 5 % level
+	if p := kolmogorovSF(1.358); math.Abs(p-0.05) > 1e-3 {
+		t.Errorf("kolmogorov survival function at 1.358 is %g, want 0.05", p)
+	}
+}
+
+func TestHypothesisTestsZeroVariance(t *testing.T) {
+	constant := []float64{3, 3, 3}
+	for _, i := range []struct {
+		name   string
+		result testResult
+	}{
+		{"welch", welchTTest(constant, constant)},
+		{"paired", pairedTTest([]float64{4, 5, 6}, []float64{4, 5, 6})},
+		{"anova", oneWayANOVA(constant, constant)},
+		{"kruskal", kruskalWallis(constant, constant, constant)},
+	} {
+		if i.result.statistic != 0 || i.result.pValue != 1 {
+			t.Errorf("%s of samples without difference: %v, want statistic 0 and p-value 1", i.name, i.result)
+		}
+	}
+	for _, i := range []struct {
+		name   string
+		result testResult
+	}{
+		{"welch", welchTTest([]float64{1, 1}, constant)},
+		{"paired", pairedTTest([]float64{4, 5, 6}, []float64{3, 4, 5})},
+		{"anova", oneWayANOVA([]float64{1, 1}, constant)},
+	} {
+		if !math.IsInf(i.result.statistic, 0) || i.result.pValue != 0 {
+			t.Errorf("%s of constant samples that differ: %v, want an infinite statistic and p-value 0", i.name, i.result)
+		}
+	}
+	// a constant feature must not give NaN
+	features := [][]float64{{1, 0}, {1, 1}, {1, 2}, {1, 3}, {1, 4}, {1, 5}}
+	labels := []int{0, 0, 0, 1, 1, 1}
+	for _, testType := range []string{"welch", "anova", "kruskal", "mannwhitney"} {
+		results := featureClassTests(features, labels, &testType)
+		if math.IsNaN(results[0].pValue) || results[0].pValue != 1 || math.IsNaN(results[1].pValue) {
+			t.Errorf("%s: p-values %g and %g, want 1 for the constant feature", testType, results[0].pValue, results[1].pValue)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+/*
+Coordinate wise median of vectors - the center that minimizes the manhattan distances to all vectors
+
+	:parameter
+		*	inSlice: vectors for which the median should be calculated
+	:return
+		*	medianSlice: the median of every coordinate
+*/
+func coordinateMedian(inSlice [][]float64) []float64 {
+	medianSlice := make([]float64, len(inSlice[0]))
+	column := make([]float64, len(inSlice))
+	for cj := range medianSlice {
+		for ci, i := range inSlice {
+			column[ci] = i[cj]
+		}
+		sort.Float64s(column)
+		medianSlice[cj] = quantileSorted(column, 0.5)
+	}
+	return medianSlice
+}
+
+/*
+Center updates of the distance metrics for which k-means is defined - the center minimizes the summed distances of the members
+(hamming and braycurtis have no such center - use k-medoids for them)
+*/
+var kMeansCenters = map[string]func([][]float64) []float64{
+	"euclidean": centroid,
+	"manhattan": coordinateMedian,
+}
+
+/*
+k-means clustering with k-means++ seeding and multiple restarts
+*/
+type kMeans struct {
+	k        int
+	nInit    int
+	maxIter  int
+	tol      float64
+	distType string
+	// set by Fit
+	centers [][]float64
+	labels  []int
+	inertia float64
+	nIter   int
+}
+
+/*
+Create an unfitted k-means clustering
+
+	:parameter
+		*	k: number of clusters
+		*	nInit: number of restarts with different seeds - the run with the lowest inertia is kept
+		*	maxIter: maximum number of iterations per run
+		*	tol: a run stops when the squared shift of the centers is below tol times the mean variance of the features
+		*	distType: which distance metric should be used
+			-	euclidean: centers are the means (k-means)
+			-	manhattan: centers are the coordinate wise medians (k-medians)
+	:return
+		*	model: the unfitted model
+*/
+func newKMeans(k *int, nInit *int, maxIter *int, tol *float64, distType *string) *kMeans {
+	if *k < 1 || *nInit < 1 || *maxIter < 1 {
+		log.Fatalln(fmt.Sprintf("Number of clusters [%d], restarts [%d] and iterations [%d] have to be at least 1", *k, *nInit, *maxIter))
+	}
+	chosenDist := *distType
+	if _, ok := kMeansCenters[chosenDist]; !ok {
+		fmt.Printf("Using default distance metric ['euclidean'] instead of the not implementd ['%s']\n", chosenDist)
+		chosenDist = "euclidean"
+	}
+	return &kMeans{k: *k, nInit: *nInit, maxIter: *maxIter, tol: *tol, distType: chosenDist}
+}
+
+/*
+Cost of a distance in the inertia - squared for euclidean like in the k-means objective
+*/
+func (m *kMeans) cost(dist float64) float64 {
+	if m.distType == "euclidean" {
+		return dist * dist
+	}
+	return dist
+}
+
+/*
+Choose the initial centers with k-means++ - every next center is drawn with a probability proportional to its cost to the closest chosen center
+
+	:parameter
+		*	x: vectors that should be clustered
+	:return
+		*	centers: copies of the chosen vectors
+*/
+func (m *kMeans) seed(x [][]float64) [][]float64 {
+	return m.greedySeed(x, 1)
+}
+
+/*
+Choose the initial centers with greedy k-means++ - for every next center numTrials candidates are drawn with a probability proportional to their cost to the closest chosen center and the one that reduces the total cost the most is kept
+
+	:parameter
+		*	x: vectors that should be clustered
+		*	numTrials: number of candidates per center - 1 for k-means++
+	:return
+		*	centers: copies of the chosen vectors
+*/
+func (m *kMeans) greedySeed(x [][]float64, numTrials int) [][]float64 {
+	distanceFunction := selectDistanceFunction(&m.distType)
+	centers := make([][]float64, 0, m.k)
+	centers = append(centers, append([]float64{}, x[rand.Intn(len(x))]...))
+	costs := distanceFunction(x, centers[0])
+	for ci, i := range costs {
+		costs[ci] = m.cost(i)
+	}
+	for len(centers) < m.k {
+		total := 0.0
+		for _, i := range costs {
+			total += i
+		}
+		var bestCosts []float64
+		bestTotal, bestCandidate := math.Inf(1), 0
+		for trial := 0; trial < numTrials; trial++ {
+			// all vectors are already centers (duplicates) - take any
+			candidate := rand.Intn(len(x))
+			if total > 0 {
+				draw := rand.Float64() * total
+				for ci, i := range costs {
+					draw -= i
+					if draw < 0 && i > 0 {
+						candidate = ci
+						break
+					}
+				}
+			}
+			candidateCosts := distanceFunction(x, x[candidate])
+			candidateTotal := 0.0
+			for ci, i := range candidateCosts {
+				candidateCosts[ci] = math.Min(costs[ci], m.cost(i))
+				candidateTotal += candidateCosts[ci]
+			}
+			if candidateTotal < bestTotal {
+				bestCosts, bestTotal, bestCandidate = candidateCosts, candidateTotal, candidate
+			}
+		}
+		centers = append(centers, append([]float64{}, x[bestCandidate]...))
+		costs = bestCosts
+	}
+	return centers
+}
+
+/*
+Assign every vector to its closest center in parallel
+
+	:parameter
+		*	x: vectors that should be assigned
+		*	centers: the cluster centers
+	:return
+		*	labels: index of the closest center of every vector
+		*	costs: cost of every vector to its center (squared distance for euclidean)
+*/
+func (m *kMeans) assign(x [][]float64, centers [][]float64) ([]int, []float64) {
+	distanceFunction := selectDistanceFunction(&m.distType)
+	labels := make([]int, len(x))
+	costs := make([]float64, len(x))
+	parallelFor(len(x), func(i int) {
+		dists := distanceFunction(centers, x[i])
+		labels[i] = argminExcluding(dists, -1)
+		costs[i] = m.cost(dists[labels[i]])
+	})
+	return labels, costs
+}
+
+/*
+One k-means run from k-means++ seeds
+
+	:parameter
+		*	x: vectors that should be clustered
+		*	tol: absolute tolerance of the squared center shift
+	:return
+		*	centers: the cluster centers
+		*	labels: cluster of every vector
+		*	inertia: summed cost of all vectors to their centers
+		*	nIter: number of iterations until convergence
+*/
+func (m *kMeans) run(x [][]float64, tol float64) ([][]float64, []int, float64, int) {
+	centerFunction := kMeansCenters[m.distType]
+	centers := m.seed(x)
+	nIter := 0
+	for nIter < m.maxIter {
+		nIter++
+		labels, costs := m.assign(x, centers)
+		members := make([][][]float64, m.k)
+		for ci, i := range labels {
+			members[i] = append(members[i], x[ci])
+		}
+		shift := 0.0
+		for ci, i := range members {
+			var newCenter []float64
+			if len(i) == 0 {
+				// empty clusters take over the vector that is worst represented by its center
+				far := 0
+				for cj, j := range costs {
+					if j > costs[far] {
+						far = cj
+					}
+				}
+				newCenter = append([]float64{}, x[far]...)
+				costs[far] = 0
+			} else {
+				newCenter = centerFunction(i)
+			}
+			for cj, j := range newCenter {
+				shift += (j - centers[ci][cj]) * (j - centers[ci][cj])
+			}
+			centers[ci] = newCenter
+		}
+		if shift <= tol {
+			break
+		}
+	}
+	labels, costs := m.assign(x, centers)
+	inertia := 0.0
+	for _, i := range costs {
+		inertia += i
+	}
+	return centers, labels, inertia, nIter
+}
+
+/*
+Cluster the training data - keeps the run with the lowest inertia of nInit restarts
+
+	:parameter
+		*	x: vectors that should be clustered
+	:return
+		None
+*/
+func (m *kMeans) Fit(x [][]float64) {
+	if len(x) < m.k {
+		log.Fatalln(fmt.Sprintf("Number of samples [%d] has to be at least the number of clusters [%d]", len(x), m.k))
+	}
+	meanVariance := 0.0
+	moments := columnMoments(x)
+	for _, i := range moments {
+		meanVariance += i.variance(0) / float64(len(moments))
+	}
+	m.inertia = math.Inf(1)
+	for run := 0; run < m.nInit; run++ {
+		centers, labels, inertia, nIter := m.run(x, m.tol*meanVariance)
+		if inertia < m.inertia {
+			m.centers, m.labels, m.inertia, m.nIter = centers, labels, inertia, nIter
+		}
+	}
+}
+
+/*
+Assign new vectors to the closest cluster center
+
+	:parameter
+		*	x: vectors that should be assigned
+	:return
+		*	labels: cluster of every vector
+*/
+func (m *kMeans) Predict(x [][]float64) []int {
+	if m.centers == nil {
+		log.Fatalln("k-means has to be fitted before predicting")
+	}
+	labels, _ := m.assign(x, m.centers)
+	return labels
+}
+
+/*
+Cluster the training data and return the cluster of every vector
+
+	:parameter
+		*	x: vectors that should be clustered
+	:return
+		*	labels: cluster of every vector
+*/
+func (m *kMeans) FitPredict(x [][]float64) []int {
+	m.Fit(x)
+	return m.labels
+}
+
+/*
+Create an unfitted copy of the model with the same parameters
+*/
+func (m *kMeans) Clone() *kMeans {
+	return &kMeans{k: m.k, nInit: m.nInit, maxIter: m.maxIter, tol: m.tol, distType: m.distType}
+}
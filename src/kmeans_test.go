@@ -0,0 +1,111 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestKMeansRecoversBlobs(t *testing.T) {
+	x, blobs := testBlobs([]int{40, 60, 80}, 3, 41)
+	k := 3
+	nInit := 5
+	maxIter := 100
+	tol := 1e-4
+	for _, distType := range []string{"euclidean", "manhattan"} {
+		m := newKMeans(&k, &nInit, &maxIter, &tol, &distType)
+		if labels := m.FitPredict(x); !testMatchesBlobs(labels, blobs) {
+			t.Errorf("%s: labels %v don't match the blobs", distType, labels)
+		}
+		// new vectors at the blob centers go to the clusters of the blobs
+		pred := m.Predict([][]float64{{0, 0, 0}, {10, 10, 10}, {20, 20, 20}})
+		if !testMatchesBlobs(pred, []int{0, 1, 2}) {
+			t.Errorf("%s: blob centers are predicted as %v", distType, pred)
+		}
+	}
+}
+
+func TestKMeansInertia(t *testing.T) {
+	x := [][]float64{{0, 0}, {0, 2}, {10, 0}, {10, 2}}
+	k := 2
+	nInit := 5
+	maxIter := 100
+	tol := 0.
+	distType := "euclidean"
+	m := newKMeans(&k, &nInit, &maxIter, &tol, &distType)
+	m.Fit(x)
+	if math.Abs(m.inertia-4) > 1e-12 {
+		t.Errorf("inertia %g, want 4", m.inertia)
+	}
+	for _, i := range m.centers {
+		if i[1] != 1 || (i[0] != 0 && i[0] != 10) {
+			t.Errorf("centers %v, want (0, 1) and (10, 1)", m.centers)
+		}
+	}
+}
+
+func TestKMeansEscapesBadSeeds(t *testing.T) {
+	x, blobs := testBlobs([]int{30, 30, 30, 30}, 2, 43)
+	k := 4
+	nInit := 10
+	maxIter := 100
+	tol := 0.
+	distType := "euclidean"
+	m := newKMeans(&k, &nInit, &maxIter, &tol, &distType)
+	members := make([][][]float64, k)
+	for ci, i := range blobs {
+		members[i] = append(members[i], x[ci])
+	}
+	// Lloyd iterations from two centers in the first blob and one between the last two blobs get stuck
+	centers := [][]float64{x[0], x[1], centroid(members[1]), centroid(append(append([][]float64{}, members[2]...), members[3]...))}
+	var costs []float64
+	for iter := 0; iter < maxIter; iter++ {
+		var labels []int
+		labels, costs = m.assign(x, centers)
+		clusters := make([][][]float64, k)
+		for ci, i := range labels {
+			clusters[i] = append(clusters[i], x[ci])
+		}
+		for ci, i := range clusters {
+			centers[ci] = centroid(i)
+		}
+	}
+	badInertia := *sumFloat64(costs)
+	blobInertia := 0.0
+	for _, i := range members {
+		center := centroid(i)
+		for _, j := range i {
+			for cl, l := range j {
+				blobInertia += (l - center[cl]) * (l - center[cl])
+			}
+		}
+	}
+	m.Fit(x)
+	if math.Abs(m.inertia-blobInertia) > 1e-9*blobInertia || badInertia < 10*blobInertia {
+		t.Errorf("inertia %g, want the inertia of the blobs %g far below the one of the bad seed %g", m.inertia, blobInertia, badInertia)
+	}
+}
+
+func TestKMeansPlusPlusSeedsSpread(t *testing.T) {
+	x, blobs := testBlobs([]int{25, 25, 25, 25}, 2, 47)
+	// tight blobs so that a second seed in the same blob is practically impossible for k-means++ - uniform seeds hit 4 different blobs in only 9 % of the cases
+	for ci, i := range x {
+		for cj := range i {
+			x[ci][cj] = 10*float64(blobs[ci]) + (x[ci][cj]-10*float64(blobs[ci]))*0.01
+		}
+	}
+	k := 4
+	nInit := 1
+	maxIter := 1
+	tol := 0.
+	distType := "euclidean"
+	m := newKMeans(&k, &nInit, &maxIter, &tol, &distType)
+	for trial := 0; trial < 20; trial++ {
+		hit := make(map[int]bool)
+		for _, i := range m.seed(x) {
+			hit[int(math.Round(i[0]/10))] = true
+		}
+		if len(hit) != k {
+			t.Fatalf("seeds hit the blobs %v, want all 4", hit)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+/*
+k-medoids clustering - cluster representatives are actual vectors of the data so any distance metric can be used
+*/
+type kMedoids struct {
+	k          int
+	maxIter    int
+	distType   string
+	method     string
+	numSamples int
+	sampleSize int
+	// set by Fit
+	medoidIdx []int
+	medoids   [][]float64
+	labels    []int
+	cost      float64
+}
+
+/*
+Create an unfitted k-medoids clustering
+
+	:parameter
+		*	k: number of clusters
+		*	maxIter: maximum number of passes of the swap phase
+		*	distType: which distance metric should be used (euclidean, manhattan, hamming, braycurtis)
+		*	method: how the medoids are found
+			-	pam: PAM on the full distance matrix with the FastPAM improvements - O(n²) memory
+			-	clara: PAM on numSamples random samples and the medoids with the lowest cost on all data are kept - for large data sets
+		*	numSamples: number of samples for clara
+		*	sampleSize: number of vectors per sample for clara - 0 to use 40 + 2k
+	:return
+		*	model: the unfitted model
+*/
+func newKMedoids(k *int, maxIter *int, distType *string, method *string, numSamples *int, sampleSize *int) *kMedoids {
+	if *k < 1 || *maxIter < 1 || *numSamples < 1 {
+		log.Fatalln(fmt.Sprintf("Number of clusters [%d], iterations [%d] and samples [%d] have to be at least 1", *k, *maxIter, *numSamples))
+	}
+	chosenDist := *distType
+	if _, ok := distanceFunctions[chosenDist]; !ok {
+		selectDistanceFunction(distType)
+		chosenDist = "euclidean"
+	}
+	chosenMethod := *method
+	if chosenMethod != "pam" && chosenMethod != "clara" {
+		fmt.Printf("Using default method ['pam'] instead of the not implementd ['%s']\n", chosenMethod)
+		chosenMethod = "pam"
+	}
+	chosenSize := *sampleSize
+	if chosenSize <= 0 {
+		chosenSize = 40 + 2**k
+	}
+	return &kMedoids{k: *k, maxIter: *maxIter, distType: chosenDist, method: chosenMethod, numSamples: *numSamples, sampleSize: chosenSize}
+}
+
+/*
+Nearest and second nearest medoid of every vector
+
+	:parameter
+		*	distMat: distance matrix of all vectors
+		*	medoids: indices of the medoids
+	:return
+		*	nearest: position in medoids of the nearest medoid of every vector
+		*	nearestDist: distance to the nearest medoid
+		*	secondDist: distance to the second nearest medoid - math.Inf(1) for k = 1
+*/
+func nearestMedoids(distMat [][]float64, medoids []int) ([]int, []float64, []float64) {
+	n := len(distMat)
+	nearest := make([]int, n)
+	nearestDist := make([]float64, n)
+	secondDist := make([]float64, n)
+	parallelFor(n, func(i int) {
+		nearest[i], nearestDist[i], secondDist[i] = -1, math.Inf(1), math.Inf(1)
+		for cj, j := range medoids {
+			d := distMat[i][j]
+			if d < nearestDist[i] {
+				secondDist[i] = nearestDist[i]
+				nearest[i], nearestDist[i] = cj, d
+			} else if d < secondDist[i] {
+				secondDist[i] = d
+			}
+		}
+	})
+	return nearest, nearestDist, secondDist
+}
+
+/*
+PAM (Kaufman and Rousseeuw) with the FastPAM1 swap (Schubert and Rousseeuw 2019) that evaluates the swap of a candidate with all k medoids in one pass over the data
+Improving swaps are done eagerly (FasterPAM) instead of searching the best swap of all candidates first
+
+	:parameter
+		*	distMat: distance matrix of all vectors
+		*	k: number of medoids
+		*	maxIter: maximum number of passes over all candidates
+	:return
+		*	medoids: indices of the medoids
+		*	cost: summed distance of all vectors to their medoid
+*/
+func pam(distMat [][]float64, k int, maxIter int) ([]int, float64) {
+	n := len(distMat)
+	if n < k {
+		log.Fatalln(fmt.Sprintf("Number of samples [%d] has to be at least the number of clusters [%d]", n, k))
+	}
+	// BUILD: greedily add the medoid that reduces the cost the most
+	medoids := make([]int, 0, k)
+	isMedoid := make([]bool, n)
+	nearestDist := make([]float64, n)
+	for i := range nearestDist {
+		nearestDist[i] = math.Inf(1)
+	}
+	for len(medoids) < k {
+		gains := make([]float64, n)
+		parallelFor(n, func(c int) {
+			if isMedoid[c] {
+				gains[c] = math.Inf(1)
+				return
+			}
+			for ci, i := range distMat[c] {
+				gains[c] += math.Min(i, nearestDist[ci])
+			}
+		})
+		best := argminExcluding(gains, -1)
+		medoids = append(medoids, best)
+		isMedoid[best] = true
+		for ci, i := range distMat[best] {
+			nearestDist[ci] = math.Min(nearestDist[ci], i)
+		}
+	}
+
+	// SWAP
+	nearest, nearestDist, secondDist := nearestMedoids(distMat, medoids)
+	// loss of removing each medoid
+	removalLoss := func() []float64 {
+		loss := make([]float64, k)
+		for ci, i := range nearest {
+			loss[i] += secondDist[ci] - nearestDist[ci]
+		}
+		return loss
+	}
+	loss := removalLoss()
+	for iter := 0; iter < maxIter; iter++ {
+		swapped := false
+		for c := 0; c < n; c++ {
+			if isMedoid[c] {
+				continue
+			}
+			delta := append([]float64{}, loss...)
+			// gain shared by all medoids from the vectors that move to the candidate
+			shared := 0.0
+			for ci, i := range distMat[c] {
+				if i < nearestDist[ci] {
+					shared += i - nearestDist[ci]
+					delta[nearest[ci]] += nearestDist[ci] - secondDist[ci]
+				} else if i < secondDist[ci] {
+					delta[nearest[ci]] += i - secondDist[ci]
+				}
+			}
+			best := argminExcluding(delta, -1)
+			if delta[best]+shared < -1e-12 {
+				isMedoid[medoids[best]] = false
+				medoids[best] = c
+				isMedoid[c] = true
+				nearest, nearestDist, secondDist = nearestMedoids(distMat, medoids)
+				loss = removalLoss()
+				swapped = true
+			}
+		}
+		if !swapped {
+			break
+		}
+	}
+	cost := 0.0
+	for _, i := range nearestDist {
+		cost += i
+	}
+	return medoids, cost
+}
+
+/*
+Find the medoids of the data
+
+	:parameter
+		*	x: vectors that should be clustered
+	:return
+		None
+*/
+func (m *kMedoids) Fit(x [][]float64) {
+	if len(x) < m.k {
+		log.Fatalln(fmt.Sprintf("Number of samples [%d] has to be at least the number of clusters [%d]", len(x), m.k))
+	}
+	m.medoidIdx = nil
+	if m.method == "clara" && m.sampleSize < len(x) {
+		m.cost = math.Inf(1)
+		for s := 0; s < m.numSamples; s++ {
+			// the best medoids so far are part of every sample
+			sample := append([]int{}, m.medoidIdx...)
+			for _, i := range rand.Perm(len(x)) {
+				if len(sample) == m.sampleSize {
+					break
+				}
+				if !isinInt(m.medoidIdx, i) {
+					sample = append(sample, i)
+				}
+			}
+			sampleMedoids, _ := pam(pairwiseDistances(subsetRows(x, sample), &m.distType), m.k, m.maxIter)
+			medoidIdx := subsetRows(sample, sampleMedoids)
+			labels, cost := m.assign(x, subsetRows(x, medoidIdx))
+			if cost < m.cost {
+				m.medoidIdx, m.labels, m.cost = medoidIdx, labels, cost
+			}
+		}
+	} else {
+		m.medoidIdx, _ = pam(pairwiseDistances(x, &m.distType), m.k, m.maxIter)
+		m.labels, m.cost = m.assign(x, subsetRows(x, m.medoidIdx))
+	}
+	// medoids in the order of the data
+	order := make([]int, m.k)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return m.medoidIdx[order[i]] < m.medoidIdx[order[j]] })
+	rank := make([]int, m.k)
+	for ci, i := range order {
+		rank[i] = ci
+	}
+	m.medoidIdx = subsetRows(m.medoidIdx, order)
+	for ci, i := range m.labels {
+		m.labels[ci] = rank[i]
+	}
+	m.medoids = copyFeatures(subsetRows(x, m.medoidIdx))
+}
+
+/*
+Assign vectors to their closest medoid in parallel
+
+	:parameter
+		*	x: vectors that should be assigned
+		*	medoids: the medoid vectors
+	:return
+		*	labels: index of the closest medoid of every vector
+		*	cost: summed distance of all vectors to their medoid
+*/
+func (m *kMedoids) assign(x [][]float64, medoids [][]float64) ([]int, float64) {
+	distanceFunction := selectDistanceFunction(&m.distType)
+	labels := make([]int, len(x))
+	costs := make([]float64, len(x))
+	parallelFor(len(x), func(i int) {
+		dists := distanceFunction(medoids, x[i])
+		labels[i] = argminExcluding(dists, -1)
+		costs[i] = dists[labels[i]]
+	})
+	cost := 0.0
+	for _, i := range costs {
+		cost += i
+	}
+	return labels, cost
+}
+
+/*
+Assign new vectors to the closest medoid
+
+	:parameter
+		*	x: vectors that should be assigned
+	:return
+		*	labels: cluster of every vector
+*/
+func (m *kMedoids) Predict(x [][]float64) []int {
+	if m.medoids == nil {
+		log.Fatalln("k-medoids has to be fitted before predicting")
+	}
+	labels, _ := m.assign(x, m.medoids)
+	return labels
+}
+
+/*
+Find the medoids of the data and return the cluster of every vector
+
+	:parameter
+		*	x: vectors that should be clustered
+	:return
+		*	labels: cluster of every vector
+*/
+func (m *kMedoids) FitPredict(x [][]float64) []int {
+	m.Fit(x)
+	return m.labels
+}
+
+/*
+Create an unfitted copy of the model with the same parameters
+*/
+func (m *kMedoids) Clone() *kMedoids {
+	return &kMedoids{k: m.k, maxIter: m.maxIter, distType: m.distType, method: m.method, numSamples: m.numSamples, sampleSize: m.sampleSize}
+}
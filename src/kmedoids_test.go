@@ -0,0 +1,97 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestKMedoidsRecoversBlobs(t *testing.T) {
+	x, blobs := testBlobs([]int{30, 50, 70}, 2, 53)
+	// shift the blobs away from 0 so that the Bray-Curtis dissimilarity is defined
+	for _, i := range x {
+		for cj := range i {
+			i[cj] += 5
+		}
+	}
+	k := 3
+	maxIter := 100
+	numSamples := 5
+	sampleSize := 0
+	for _, distType := range []string{"euclidean", "manhattan", "braycurtis"} {
+		for _, method := range []string{"pam", "clara"} {
+			m := newKMedoids(&k, &maxIter, &distType, &method, &numSamples, &sampleSize)
+			if labels := m.FitPredict(x); !testMatchesBlobs(labels, blobs) {
+				t.Errorf("%s %s: labels %v don't match the blobs", distType, method, labels)
+			}
+		}
+	}
+}
+
+func TestKMedoidsLocalOptimum(t *testing.T) {
+	// PAM ends when no swap of a medoid with a non medoid lowers the cost
+	x, _ := testBlobs([]int{20, 20, 20}, 2, 59)
+	k := 4
+	maxIter := 100
+	distType := "manhattan"
+	method := "pam"
+	numSamples := 1
+	sampleSize := 0
+	m := newKMedoids(&k, &maxIter, &distType, &method, &numSamples, &sampleSize)
+	m.Fit(x)
+	distMat := pairwiseDistances(x, &distType)
+	cost := func(medoids []int) float64 {
+		total := 0.0
+		for _, i := range distMat {
+			closest := math.Inf(1)
+			for _, j := range medoids {
+				closest = math.Min(closest, i[j])
+			}
+			total += closest
+		}
+		return total
+	}
+	if math.Abs(cost(m.medoidIdx)-m.cost) > 1e-9 {
+		t.Errorf("cost %g of the medoids, want %g", m.cost, cost(m.medoidIdx))
+	}
+	for ci := range m.medoidIdx {
+		for j := range x {
+			swapped := append([]int{}, m.medoidIdx...)
+			swapped[ci] = j
+			if cost(swapped) < m.cost-1e-9 {
+				t.Errorf("swapping medoid [%d] with [%d] lowers the cost from %g to %g", m.medoidIdx[ci], j, m.cost, cost(swapped))
+			}
+		}
+	}
+}
+
+func TestCLARACostBound(t *testing.T) {
+	// uniform data without clusters so that the medoids depend on the sample
+	rng := rand.New(rand.NewSource(61))
+	x := make([][]float64, 400)
+	for i := range x {
+		x[i] = []float64{rng.Float64(), rng.Float64()}
+	}
+	k := 5
+	maxIter := 100
+	distType := "euclidean"
+	method := "pam"
+	numSamples := 5
+	sampleSize := 0
+	pamModel := newKMedoids(&k, &maxIter, &distType, &method, &numSamples, &sampleSize)
+	pamModel.Fit(x)
+	method = "clara"
+	clara := newKMedoids(&k, &maxIter, &distType, &method, &numSamples, &sampleSize)
+	clara.Fit(x)
+	// samples of 40 + 2k vectors find medoids close to the ones of PAM on all vectors - PAM is only a local optimum so CLARA can be lower
+	if clara.cost > pamModel.cost*1.1 {
+		t.Errorf("CLARA cost %g, want at most 10 %% above the PAM cost %g", clara.cost, pamModel.cost)
+	}
+	// a sample with all vectors is PAM
+	sampleSize = len(x)
+	clara = newKMedoids(&k, &maxIter, &distType, &method, &numSamples, &sampleSize)
+	clara.Fit(x)
+	if math.Abs(clara.cost-pamModel.cost) > 1e-9 {
+		t.Errorf("CLARA with all vectors has the cost %g, want the PAM cost %g", clara.cost, pamModel.cost)
+	}
+}
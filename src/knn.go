@@ -123,6 +123,37 @@ func braycurtisDiss(x [][]float64, target []float64) []float64 {
 	return diss
 }
 
+/*
+Distance functions that can be selected by their name
+*/
+var distanceFunctions = map[string]func([][]float64, []float64) []float64{
+	"euclidean":  euclideanDist,
+	"manhattan":  manhattanDist,
+	"hamming":    hammingDist,
+	"braycurtis": braycurtisDiss,
+}
+
+/*
+Select the distance function for a given distance metric
+
+	:parameter
+		*	distType: which distance metric should be used
+			-	euclidean
+			-	manhattan
+			-	hamming
+			-	braycurtis
+	:return
+		*	distanceFunction: function calculating the distances between a set of vectors and a target vector
+*/
+func selectDistanceFunction(distType *string) func([][]float64, []float64) []float64 {
+	distanceFunction, ok := distanceFunctions[*distType]
+	if !ok {
+		fmt.Printf("Using default distance metric ['euclidean'] instead of the not implementd ['%s']\n", *distType)
+		distanceFunction = euclideanDist
+	}
+	return distanceFunction
+}
+
 /*
 Random forest regressor
 
@@ -147,29 +178,40 @@ func kNNRegressor(x [][]float64, y []float64, target []float64, k *int, distType
 		log.Fatal(fmt.Printf("Size of x [%d] not equal to size of y [%d]", xSize, ySize))
 	}
 	// calc distance
-	dists := []float64{}
-	switch *distType {
-	case "manhattan":
-		dists = append(dists, manhattanDist(x, target)...)
-	case "hamming":
-		dists = append(dists, hammingDist(x, target)...)
-	case "braycurtis":
-		dists = append(dists, braycurtisDiss(x, target)...)
-	case "euclidean":
-		dists = append(dists, euclideanDist(x, target)...)
-	default:
-		dists = append(dists, euclideanDist(x, target)...)
-		fmt.Printf("Using default distance metric ['euclidean'] instead of the not implementd ['%s']\n", *distType)
-	}
+	dists := selectDistanceFunction(distType)(x, target)
 	// sort distances small to big
 	sortedDistIdx := argsort(dists)
+	result := kNNRegressionVote(y, dists, sortedDistIdx, k, scaleDist, -1)
+	return result, sortedDistIdx, dists
+}
+
+/*
+Calculate the kNN regression result from already sorted distances
+
+	:parameter
+		*	y: values of the training data
+		*	dists: distances of the target to all samples in x
+		*	sortedDistIdx: indices sorting dists from small to big
+		*	k: number of samples used for the prediction
+		*	scaleDist: whether to scale the prediction based on the distance of samples to the target
+		*	exclude: index of a sample that should not be used as neighbour (-1 to use all samples)
+	:return
+		*	result: regression result
+*/
+func kNNRegressionVote(y []float64, dists []float64, sortedDistIdx []int, k *int, scaleDist *bool, exclude int) float64 {
 	// nearest neighbours y values
-	nnYs := make([]float64, *k)
+	nnYs := []float64{}
 	// nearest neighbours distances
-	nnDists := make([]float64, *k)
-	for i := 0; i < *k; i++ {
-		nnYs[i] = y[sortedDistIdx[i]]
-		nnDists[i] = dists[sortedDistIdx[i]]
+	nnDists := []float64{}
+	for _, i := range sortedDistIdx {
+		if len(nnYs) == *k {
+			break
+		}
+		if i == exclude {
+			continue
+		}
+		nnYs = append(nnYs, y[i])
+		nnDists = append(nnDists, dists[i])
 	}
 
 	// calculate the result
@@ -193,9 +235,9 @@ func kNNRegressor(x [][]float64, y []float64, target []float64, k *int, distType
 		for _, i := range nnYs {
 			result += i
 		}
-		result = result / float64(*k)
+		result = result / float64(len(nnYs))
 	}
-	return result, sortedDistIdx, dists
+	return result
 }
 
 /*
@@ -223,29 +265,41 @@ func kNNClassifier(x [][]float64, y []int, target []float64, k *int, distType *s
 		log.Fatal(fmt.Printf("Size of x [%d] not equal to size of y [%d]\n", xSize, ySize))
 	}
 	// calc distance
-	dists := []float64{}
-	switch *distType {
-	case "manhattan":
-		dists = append(dists, manhattanDist(x, target)...)
-	case "hamming":
-		dists = append(dists, hammingDist(x, target)...)
-	case "braycurtis":
-		dists = append(dists, braycurtisDiss(x, target)...)
-	case "euclidean":
-		dists = append(dists, euclideanDist(x, target)...)
-	default:
-		dists = append(dists, euclideanDist(x, target)...)
-		fmt.Printf("Using default distance metric ['euclidean'] instead of the not implementd ['%s']\n", *distType)
-	}
+	dists := selectDistanceFunction(distType)(x, target)
 	// sort distances small to big
 	sortedDistIdx := argsort(dists)
+	resultClasses := kNNClassVote(y, dists, sortedDistIdx, k, scaleDist, -1)
+	result := argmaxClass(resultClasses)
+	return &result, sortedDistIdx, dists, resultClasses
+}
+
+/*
+Calculate the class percentages of the k nearest neighbours from already sorted distances
+
+	:parameter
+		*	y: classes of the training data
+		*	dists: distances of the target to all samples in x
+		*	sortedDistIdx: indices sorting dists from small to big
+		*	k: number of samples used for the prediction
+		*	scaleDist: whether to scale the prediction based on the distance of samples to the target
+		*	exclude: index of a sample that should not be used as neighbour (-1 to use all samples)
+	:return
+		*	resultClasses: percentages for all classes
+*/
+func kNNClassVote(y []int, dists []float64, sortedDistIdx []int, k *int, scaleDist *bool, exclude int) map[int]float64 {
 	// nearest neighbours y values
-	nnYs := make([]int, *k)
+	nnYs := []int{}
 	// nearest neighbours distances
-	nnDists := make([]float64, *k)
-	for i := 0; i < *k; i++ {
-		nnYs[i] = y[sortedDistIdx[i]]
-		nnDists[i] = dists[sortedDistIdx[i]]
+	nnDists := []float64{}
+	for _, i := range sortedDistIdx {
+		if len(nnYs) == *k {
+			break
+		}
+		if i == exclude {
+			continue
+		}
+		nnYs = append(nnYs, y[i])
+		nnDists = append(nnDists, dists[i])
 	}
 
 	// calculate the result
@@ -269,19 +323,554 @@ func kNNClassifier(x [][]float64, y []int, target []float64, k *int, distType *s
 		}
 	} else {
 		// add a fraction per sample to its class
-		fractSample := 1 / float64(*k)
+		fractSample := 1 / float64(len(nnYs))
 		for _, i := range nnYs {
 			resultClasses[i] += fractSample
 		}
 	}
-	// find the class with the highest percentage
+	return resultClasses
+}
+
+/*
+Find the class with the highest percentage - ties go to the smallest class label so that the result doesn't depend on the map order
+
+	:parameter
+		*	resultClasses: percentages for all classes
+	:return
+		*	result: class with the highest percentage
+*/
+func argmaxClass(resultClasses map[int]float64) int {
 	result := -1
 	resultPercent := math.Inf(-1)
 	for key, value := range resultClasses {
-		if value > resultPercent {
+		if value > resultPercent || (value == resultPercent && key < result) {
 			result = key
 			resultPercent = value
 		}
 	}
-	return &result, sortedDistIdx, dists, resultClasses
+	return result
+}
+
+/*
+kNN classifier that keeps its training data so that it can be used as an estimator
+*/
+type kNNClassifierModel struct {
+	k          int
+	distType   string
+	scaleDist  bool
+	scalerType string
+	scaler     func([][]float64)
+	x          [][]float64
+	y          []int
+}
+
+/*
+Create a new kNN classifier model
+
+	:parameter
+		*	k: number of samples used for the prediction
+		*	distType: which distance metric should be used
+		*	scaleDist: whether to scale the prediction based on the distance of samples to the target
+		*	scalerType: which scaler is fitted on the training data and applied to all data (none, minmax, standard)
+	:return
+		*	model: the unfitted model
+*/
+func newKNNClassifierModel(k *int, distType *string, scaleDist *bool, scalerType *string) *kNNClassifierModel {
+	if _, ok := distanceFunctions[*distType]; !ok {
+		selectDistanceFunction(distType)
+		euclidean := "euclidean"
+		distType = &euclidean
+	}
+	if selectScaler(scalerType) == nil {
+		none := "none"
+		scalerType = &none
+	}
+	return &kNNClassifierModel{k: *k, distType: *distType, scaleDist: *scaleDist, scalerType: *scalerType}
+}
+
+/*
+Store the training data
+
+	:parameter
+		*	x: vectors representing the training data
+		*	y: classes of the training data
+	:return
+		None
+*/
+func (m *kNNClassifierModel) Fit(x [][]float64, y []int) {
+	if xSize, ySize := len(x), len(y); xSize != ySize {
+		log.Fatalln(fmt.Sprintf("Size of x [%d] not equal to size of y [%d]", xSize, ySize))
+	}
+	m.x = x
+	m.y = y
+	m.scaler = nil
+	if newScaler := selectScaler(&m.scalerType); newScaler != nil {
+		m.x = copyFeatures(x)
+		m.scaler = newScaler(m.x)
+		m.scaler(m.x)
+	}
+}
+
+/*
+Predict the class percentages for every vector in x in parallel
+
+	:parameter
+		*	x: vectors for which the classes should be predicted
+	:return
+		*	proba: percentages for all classes per vector
+*/
+func (m *kNNClassifierModel) PredictProba(x [][]float64) []map[int]float64 {
+	x = m.scale(x)
+	proba := make([]map[int]float64, len(x))
+	parallelFor(len(x), func(i int) {
+		_, _, _, proba[i] = kNNClassifier(m.x, m.y, x[i], &m.k, &m.distType, &m.scaleDist)
+	})
+	return proba
+}
+
+/*
+Predict the class for every vector in x in parallel
+
+	:parameter
+		*	x: vectors for which the classes should be predicted
+	:return
+		*	pred: predicted classes
+*/
+func (m *kNNClassifierModel) Predict(x [][]float64) []int {
+	pred := make([]int, len(x))
+	for ci, i := range m.PredictProba(x) {
+		pred[ci] = argmaxClass(i)
+	}
+	return pred
+}
+
+/*
+Create an unfitted copy of the model with the same parameters
+*/
+func (m *kNNClassifierModel) Clone() estimator[int] {
+	return &kNNClassifierModel{k: m.k, distType: m.distType, scaleDist: m.scaleDist, scalerType: m.scalerType}
+}
+
+/*
+Scale x with the scaler fitted on the training data
+
+	:parameter
+		*	x: vectors that should be scaled
+	:return
+		*	scaled: scaled copy of x or x itself if no scaler is used
+*/
+func (m *kNNClassifierModel) scale(x [][]float64) [][]float64 {
+	if m.scaler == nil {
+		return x
+	}
+	scaled := copyFeatures(x)
+	m.scaler(scaled)
+	return scaled
+}
+
+/*
+Predict every sample in x with all other samples as training data by excluding it from its own neighbours - the scaler is fitted on all samples except the left out one
+
+	:parameter
+		*	x: vectors representing the data
+		*	y: classes of the data
+	:return
+		*	pred: predicted class of every sample
+*/
+func (m *kNNClassifierModel) leaveOneOutPredict(x [][]float64, y []int) []int {
+	if xSize, ySize := len(x), len(y); xSize != ySize {
+		log.Fatalln(fmt.Sprintf("Size of x [%d] not equal to size of y [%d]", xSize, ySize))
+	}
+	distanceFunction := distanceFunctions[m.distType]
+	pred := make([]int, len(x))
+	parallelFor(len(x), func(i int) {
+		dists := leaveOneOutDistances(x, i, &m.scalerType, distanceFunction)
+		pred[i] = argmaxClass(kNNClassVote(y, dists, argsort(dists), &m.k, &m.scaleDist, i))
+	})
+	return pred
+}
+
+/*
+kNN regressor that keeps its training data so that it can be used as an estimator
+*/
+type kNNRegressorModel struct {
+	k          int
+	distType   string
+	scaleDist  bool
+	scalerType string
+	scaler     func([][]float64)
+	x          [][]float64
+	y          []float64
+}
+
+/*
+Create a new kNN regressor model
+
+	:parameter
+		*	k: number of samples used for the prediction
+		*	distType: which distance metric should be used
+		*	scaleDist: whether to scale the prediction based on the distance of samples to the target
+		*	scalerType: which scaler is fitted on the training data and applied to all data (none, minmax, standard)
+	:return
+		*	model: the unfitted model
+*/
+func newKNNRegressorModel(k *int, distType *string, scaleDist *bool, scalerType *string) *kNNRegressorModel {
+	if _, ok := distanceFunctions[*distType]; !ok {
+		selectDistanceFunction(distType)
+		euclidean := "euclidean"
+		distType = &euclidean
+	}
+	if selectScaler(scalerType) == nil {
+		none := "none"
+		scalerType = &none
+	}
+	return &kNNRegressorModel{k: *k, distType: *distType, scaleDist: *scaleDist, scalerType: *scalerType}
+}
+
+/*
+Store the training data
+
+	:parameter
+		*	x: vectors representing the training data
+		*	y: values of the training data
+	:return
+		None
+*/
+func (m *kNNRegressorModel) Fit(x [][]float64, y []float64) {
+	if xSize, ySize := len(x), len(y); xSize != ySize {
+		log.Fatalln(fmt.Sprintf("Size of x [%d] not equal to size of y [%d]", xSize, ySize))
+	}
+	m.x = x
+	m.y = y
+	m.scaler = nil
+	if newScaler := selectScaler(&m.scalerType); newScaler != nil {
+		m.x = copyFeatures(x)
+		m.scaler = newScaler(m.x)
+		m.scaler(m.x)
+	}
+}
+
+/*
+Predict the value for every vector in x in parallel
+
+	:parameter
+		*	x: vectors for which the values should be predicted
+	:return
+		*	pred: predicted values
+*/
+func (m *kNNRegressorModel) Predict(x [][]float64) []float64 {
+	x = m.scale(x)
+	pred := make([]float64, len(x))
+	parallelFor(len(x), func(i int) {
+		pred[i], _, _ = kNNRegressor(m.x, m.y, x[i], &m.k, &m.distType, &m.scaleDist)
+	})
+	return pred
+}
+
+/*
+Create an unfitted copy of the model with the same parameters
+*/
+func (m *kNNRegressorModel) Clone() estimator[float64] {
+	return &kNNRegressorModel{k: m.k, distType: m.distType, scaleDist: m.scaleDist, scalerType: m.scalerType}
+}
+
+/*
+Scale x with the scaler fitted on the training data
+
+	:parameter
+		*	x: vectors that should be scaled
+	:return
+		*	scaled: scaled copy of x or x itself if no scaler is used
+*/
+func (m *kNNRegressorModel) scale(x [][]float64) [][]float64 {
+	if m.scaler == nil {
+		return x
+	}
+	scaled := copyFeatures(x)
+	m.scaler(scaled)
+	return scaled
+}
+
+/*
+Predict every sample in x with all other samples as training data by excluding it from its own neighbours - the scaler is fitted on all samples except the left out one
+
+	:parameter
+		*	x: vectors representing the data
+		*	y: values of the data
+	:return
+		*	pred: predicted value of every sample
+*/
+func (m *kNNRegressorModel) leaveOneOutPredict(x [][]float64, y []float64) []float64 {
+	if xSize, ySize := len(x), len(y); xSize != ySize {
+		log.Fatalln(fmt.Sprintf("Size of x [%d] not equal to size of y [%d]", xSize, ySize))
+	}
+	distanceFunction := distanceFunctions[m.distType]
+	pred := make([]float64, len(x))
+	parallelFor(len(x), func(i int) {
+		dists := leaveOneOutDistances(x, i, &m.scalerType, distanceFunction)
+		pred[i] = kNNRegressionVote(y, dists, argsort(dists), &m.k, &m.scaleDist, i)
+	})
+	return pred
+}
+
+/*
+Distances of one sample to all samples of x where the scaler is fitted without that sample as in a leave-one-out fold
+
+	:parameter
+		*	x: vectors representing the data
+		*	leftOut: index of the left out sample
+		*	scalerType: which scaler should be used (none, minmax, standard)
+		*	distanceFunction: function calculating the distances between a set of vectors and a target vector
+	:return
+		*	dists: distances of the left out sample to all samples in x
+*/
+func leaveOneOutDistances(x [][]float64, leftOut int, scalerType *string, distanceFunction func([][]float64, []float64) []float64) []float64 {
+	newScaler := selectScaler(scalerType)
+	if newScaler == nil {
+		return distanceFunction(x, x[leftOut])
+	}
+	trainX := make([][]float64, 0, len(x)-1)
+	trainX = append(append(trainX, x[:leftOut]...), x[leftOut+1:]...)
+	scaled := copyFeatures(x)
+	newScaler(trainX)(scaled)
+	return distanceFunction(scaled, scaled[leftOut])
+}
+
+// maximum number of vectors in a leaf of the kd-tree
+const neighborLeafSize = 32
+
+/*
+Node of a kd-tree - leaves hold the indices of their vectors and every node the bounding box of its vectors
+*/
+type kdNode struct {
+	indices  []int
+	lower    []float64
+	upper    []float64
+	splitDim int
+	splitVal float64
+	left     *kdNode
+	right    *kdNode
+}
+
+/*
+Index for neighbor searches shared by the density based clusterings - a kd-tree for euclidean and manhattan distances and brute force search for the other distance metrics
+*/
+type neighborIndex struct {
+	x                [][]float64
+	distType         string
+	distanceFunction func([][]float64, []float64) []float64
+	root             *kdNode
+}
+
+/*
+Build a neighbor index
+
+	:parameter
+		*	x: vectors that should be searched
+		*	distType: which distance metric should be used (euclidean, manhattan, hamming, braycurtis)
+	:return
+		*	index: the neighbor index
+*/
+func newNeighborIndex(x [][]float64, distType *string) *neighborIndex {
+	index := &neighborIndex{x: x, distType: *distType, distanceFunction: selectDistanceFunction(distType)}
+	if _, ok := distanceFunctions[*distType]; !ok {
+		index.distType = "euclidean"
+	}
+	if len(x) > 0 && (index.distType == "euclidean" || index.distType == "manhattan") {
+		indices := make([]int, len(x))
+		for i := range indices {
+			indices[i] = i
+		}
+		index.root = index.build(indices)
+	}
+	return index
+}
+
+/*
+Build a kd-tree node by splitting the vectors at the median of the dimension with the largest spread
+*/
+func (idx *neighborIndex) build(indices []int) *kdNode {
+	node := &kdNode{indices: indices, lower: append([]float64{}, idx.x[indices[0]]...), upper: append([]float64{}, idx.x[indices[0]]...)}
+	for _, i := range indices {
+		for cj, j := range idx.x[i] {
+			node.lower[cj] = math.Min(node.lower[cj], j)
+			node.upper[cj] = math.Max(node.upper[cj], j)
+		}
+	}
+	if len(indices) <= neighborLeafSize {
+		return node
+	}
+	for cj := range node.lower {
+		if node.upper[cj]-node.lower[cj] > node.upper[node.splitDim]-node.lower[node.splitDim] {
+			node.splitDim = cj
+		}
+	}
+	// all vectors are identical
+	if node.upper[node.splitDim] == node.lower[node.splitDim] {
+		return node
+	}
+	sort.Slice(indices, func(i, j int) bool { return idx.x[indices[i]][node.splitDim] < idx.x[indices[j]][node.splitDim] })
+	mid := len(indices) / 2
+	node.splitVal = idx.x[indices[mid]][node.splitDim]
+	node.left = idx.build(indices[:mid])
+	node.right = idx.build(indices[mid:])
+	node.indices = nil
+	return node
+}
+
+/*
+Smallest possible distance between a target and any vector in the bounding box of a node
+*/
+func (idx *neighborIndex) boxDist(node *kdNode, target []float64) float64 {
+	dist := 0.0
+	for cj, j := range target {
+		gap := math.Max(0, math.Max(node.lower[cj]-j, j-node.upper[cj]))
+		if idx.distType == "euclidean" {
+			dist += gap * gap
+		} else {
+			dist += gap
+		}
+	}
+	if idx.distType == "euclidean" {
+		return math.Sqrt(dist)
+	}
+	return dist
+}
+
+/*
+Find all vectors within a radius of a target
+
+	:parameter
+		*	target: vector whose neighbors should be found
+		*	radius: maximum distance of a neighbor (inclusive)
+	:return
+		*	neighbors: indices of the neighbors in increasing order - includes the target itself if it is part of the index
+		*	dists: distances of the neighbors
+*/
+func (idx *neighborIndex) radiusQuery(target []float64, radius float64) ([]int, []float64) {
+	neighbors := []int{}
+	dists := []float64{}
+	collect := func(indices []int) {
+		for ci, i := range idx.distanceFunction(subsetRows(idx.x, indices), target) {
+			if i <= radius {
+				neighbors = append(neighbors, indices[ci])
+				dists = append(dists, i)
+			}
+		}
+	}
+	if idx.root == nil {
+		if len(idx.x) > 0 {
+			all := make([]int, len(idx.x))
+			for i := range all {
+				all[i] = i
+			}
+			collect(all)
+		}
+		return neighbors, dists
+	}
+	var search func(node *kdNode)
+	search = func(node *kdNode) {
+		if idx.boxDist(node, target) > radius {
+			return
+		}
+		if node.left == nil {
+			collect(node.indices)
+			return
+		}
+		search(node.left)
+		search(node.right)
+	}
+	search(idx.root)
+	order := make([]int, len(neighbors))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return neighbors[order[i]] < neighbors[order[j]] })
+	return subsetRows(neighbors, order), subsetRows(dists, order)
+}
+
+/*
+Find the k nearest vectors of a target
+
+	:parameter
+		*	target: vector whose neighbors should be found
+		*	k: number of neighbors
+	:return
+		*	neighbors: indices of the neighbors sorted by distance - includes the target itself if it is part of the index
+		*	dists: distances of the neighbors
+*/
+func (idx *neighborIndex) kNearest(target []float64, k int) ([]int, []float64) {
+	neighbors := make([]int, 0, k+1)
+	dists := make([]float64, 0, k+1)
+	// insert into the sorted neighbors and drop the farthest if there are more than k
+	collect := func(indices []int) {
+		for ci, i := range idx.distanceFunction(subsetRows(idx.x, indices), target) {
+			if len(dists) == k && i >= dists[k-1] {
+				continue
+			}
+			pos := sort.SearchFloat64s(dists, i)
+			for pos < len(dists) && dists[pos] == i {
+				pos++
+			}
+			dists = append(dists, 0)
+			neighbors = append(neighbors, 0)
+			copy(dists[pos+1:], dists[pos:])
+			copy(neighbors[pos+1:], neighbors[pos:])
+			dists[pos], neighbors[pos] = i, indices[ci]
+			if len(dists) > k {
+				dists, neighbors = dists[:k], neighbors[:k]
+			}
+		}
+	}
+	if k <= 0 {
+		return neighbors, dists
+	}
+	if idx.root == nil {
+		all := make([]int, len(idx.x))
+		for i := range all {
+			all[i] = i
+		}
+		collect(all)
+		return neighbors, dists
+	}
+	var search func(node *kdNode)
+	search = func(node *kdNode) {
+		if len(dists) == k && idx.boxDist(node, target) >= dists[k-1] {
+			return
+		}
+		if node.left == nil {
+			collect(node.indices)
+			return
+		}
+		// the side of the target first to shrink the search radius early
+		first, second := node.left, node.right
+		if target[node.splitDim] >= node.splitVal {
+			first, second = second, first
+		}
+		search(first)
+		search(second)
+	}
+	search(idx.root)
+	return neighbors, dists
+}
+
+/*
+Core distance of every indexed vector - the distance to its minPts-th nearest neighbor with the vector itself counted as first neighbor
+
+	:parameter
+		*	index: neighbor index of the vectors
+		*	minPts: which neighbor defines the core distance
+	:return
+		*	coreDists: core distance of every vector
+*/
+func coreDistances(index *neighborIndex, minPts int) []float64 {
+	if minPts < 1 || minPts > len(index.x) {
+		log.Fatalln(fmt.Sprintf("minPts [%d] has to be between 1 and the number of samples [%d]", minPts, len(index.x)))
+	}
+	coreDists := make([]float64, len(index.x))
+	parallelFor(len(index.x), func(i int) {
+		_, dists := index.kNearest(index.x[i], minPts)
+		coreDists[i] = dists[minPts-1]
+	})
+	return coreDists
 }
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+)
+
+/*
+Lance-Williams update of the distance between a merged cluster (i + j) and another cluster k
+
+	:parameter
+		*	dik, djk: distances of the merged clusters i and j to k
+		*	dij: distance between i and j
+		*	ni, nj, nk: sizes of the clusters
+	:return
+		*	dist: distance between the merged cluster and k
+*/
+type linkageUpdate func(dik, djk, dij float64, ni, nj, nk int) float64
+
+/*
+Linkages that can be selected by their name
+*/
+var linkageFunctions = map[string]linkageUpdate{
+	"single": func(dik, djk, dij float64, ni, nj, nk int) float64 {
+		return math.Min(dik, djk)
+	},
+	"complete": func(dik, djk, dij float64, ni, nj, nk int) float64 {
+		return math.Max(dik, djk)
+	},
+	"average": func(dik, djk, dij float64, ni, nj, nk int) float64 {
+		return (float64(ni)*dik + float64(nj)*djk) / float64(ni+nj)
+	},
+	"weighted": func(dik, djk, dij float64, ni, nj, nk int) float64 {
+		return (dik + djk) / 2
+	},
+	// centroid, median and ward work on squared euclidean distances
+	"centroid": func(dik, djk, dij float64, ni, nj, nk int) float64 {
+		nij := float64(ni + nj)
+		return (float64(ni)*dik+float64(nj)*djk)/nij - float64(ni)*float64(nj)*dij/(nij*nij)
+	},
+	"median": func(dik, djk, dij float64, ni, nj, nk int) float64 {
+		return dik/2 + djk/2 - dij/4
+	},
+	"ward": func(dik, djk, dij float64, ni, nj, nk int) float64 {
+		return (float64(ni+nk)*dik + float64(nj+nk)*djk - float64(nk)*dij) / float64(ni+nj+nk)
+	},
+}
+
+// linkages whose Lance-Williams update is only valid for squared euclidean distances
+var squaredLinkages = []string{"centroid", "median", "ward"}
+
+/*
+Select the Lance-Williams update for a given linkage
+
+	:parameter
+		*	linkage: which linkage should be used
+			-	single
+			-	complete
+			-	average
+			-	weighted
+			-	centroid
+			-	median
+			-	ward
+	:return
+		*	update: the Lance-Williams update of the linkage
+		*	squared: true if the update works on squared distances
+*/
+func selectLinkage(linkage *string) (linkageUpdate, bool) {
+	update, ok := linkageFunctions[*linkage]
+	if !ok {
+		fmt.Printf("Using default linkage ['average'] instead of the not implementd ['%s']\n", *linkage)
+		return linkageFunctions["average"], false
+	}
+	return update, isinString(squaredLinkages, *linkage)
+}
+
+/*
+One merge of two clusters - clusters are numbered like in SciPy: the samples are 0 to n-1 and the cluster created by the i-th merge is n+i
+*/
+type clusterMerge struct {
+	first  int
+	second int
+	dist   float64
+	size   int
+}
+
+/*
+Calculate the distances between all vectors in parallel
+
+	:parameter
+		*	inSlice: vectors for which the distances should be computed
+		*	distType: which distance metric should be used (euclidean, manhattan, hamming, braycurtis)
+	:return
+		*	distMat: symmetric matrix with the distance between vector i and j at [i][j]
+*/
+func pairwiseDistances(inSlice [][]float64, distType *string) [][]float64 {
+	distanceFunction := selectDistanceFunction(distType)
+	distMat := make([][]float64, len(inSlice))
+	parallelFor(len(inSlice), func(i int) {
+		distMat[i] = distanceFunction(inSlice, inSlice[i])
+	})
+	return distMat
+}
+
+/*
+Find the index of the smallest value in a slice ignoring one index
+
+	:parameter
+		*	inSlice: the slice to search in
+		*	exclude: index that is skipped (e.g. the distance of a point to itself)
+	:return
+		*	minIdx: index of the smallest value - -1 if there is none
+*/
+func argminExcluding(inSlice []float64, exclude int) int {
+	minIdx := -1
+	for ci, i := range inSlice {
+		if ci != exclude && (minIdx == -1 || i < inSlice[minIdx]) {
+			minIdx = ci
+		}
+	}
+	return minIdx
+}
+
+/*
+Agglomerative clustering on a distance matrix with Lance-Williams updates - merges the closest clusters until the next merge would be above maxDist or only numClusters are left
+
+	:parameter
+		*	distMat: symmetric distance matrix as returned by pairwiseDistances (euclidean for centroid, median and ward)
+		*	linkage: which linkage should be used (single, complete, average, weighted, centroid, median, ward)
+		*	maxDist: maximum distance of two clusters to be merged - math.Inf(1) to not stop at a distance
+		*	numClusters: number of clusters at which merging stops - 1 to not stop at a number of clusters
+	:return
+		*	merges: all merges in the order they were made
+*/
+func agglomerate(distMat [][]float64, linkage *string, maxDist *float64, numClusters *int) []clusterMerge {
+	n := len(distMat)
+	if *numClusters < 1 {
+		log.Fatalln(fmt.Sprintf("Number of clusters [%d] has to be at least 1", *numClusters))
+	}
+	update, squared := selectLinkage(linkage)
+	// working copy of the distances since they are updated in place
+	dist := make([][]float64, n)
+	for ci, i := range distMat {
+		if len(i) != n {
+			log.Fatalln(fmt.Sprintf("Distance matrix has to be square but row [%d] has [%d] instead of [%d] entries", ci, len(i), n))
+		}
+		dist[ci] = make([]float64, n)
+		for cj, j := range i {
+			if squared {
+				dist[ci][cj] = j * j
+			} else {
+				dist[ci][cj] = j
+			}
+		}
+	}
+	active := make([]bool, n)
+	sizes := make([]int, n)
+	ids := make([]int, n)
+	// nearest active neighbor of every active cluster
+	nearest := make([]int, n)
+	nearestDist := make([]float64, n)
+	findNearest := func(i int) {
+		nearest[i] = -1
+		nearestDist[i] = math.Inf(1)
+		for j := 0; j < n; j++ {
+			if active[j] && j != i && dist[i][j] < nearestDist[i] {
+				nearest[i] = j
+				nearestDist[i] = dist[i][j]
+			}
+		}
+	}
+	for i := 0; i < n; i++ {
+		active[i], sizes[i], ids[i] = true, 1, i
+	}
+	parallelFor(n, findNearest)
+
+	merges := []clusterMerge{}
+	for remaining := n; remaining > *numClusters; remaining-- {
+		// the closest pair of clusters
+		p1 := -1
+		for i := 0; i < n; i++ {
+			if active[i] && nearest[i] >= 0 && (p1 == -1 || nearestDist[i] < nearestDist[p1]) {
+				p1 = i
+			}
+		}
+		if p1 == -1 {
+			break
+		}
+		p2 := nearest[p1]
+		height := nearestDist[p1]
+		if squared {
+			height = math.Sqrt(math.Max(height, 0))
+		}
+		if height > *maxDist {
+			break
+		}
+		if p2 < p1 {
+			p1, p2 = p2, p1
+		}
+		first, second := ids[p1], ids[p2]
+		if second < first {
+			first, second = second, first
+		}
+		merges = append(merges, clusterMerge{first: first, second: second, dist: height, size: sizes[p1] + sizes[p2]})
+		// the merged cluster takes the place of p1
+		for k := 0; k < n; k++ {
+			if active[k] && k != p1 && k != p2 {
+				dist[p1][k] = update(dist[p1][k], dist[p2][k], dist[p1][p2], sizes[p1], sizes[p2], sizes[k])
+				dist[k][p1] = dist[p1][k]
+			}
+		}
+		active[p2] = false
+		sizes[p1] += sizes[p2]
+		ids[p1] = n + len(merges) - 1
+		for k := 0; k < n; k++ {
+			if !active[k] {
+				continue
+			}
+			if k == p1 || nearest[k] == p1 || nearest[k] == p2 {
+				findNearest(k)
+			} else if dist[k][p1] < nearestDist[k] {
+				nearest[k] = p1
+				nearestDist[k] = dist[k][p1]
+			}
+		}
+	}
+	return merges
+}
+
+/*
+Build the clusters that result from a sequence of merges
+
+	:parameter
+		*	n: number of samples
+		*	merges: merges as returned by agglomerate
+	:return
+		*	cluster: indices of members of clusters in their own slice - sorted by their smallest member
+*/
+func clustersFromMerges(n int, merges []clusterMerge) [][]int {
+	members := make(map[int][]int, n)
+	for i := 0; i < n; i++ {
+		members[i] = []int{i}
+	}
+	for ci, i := range merges {
+		members[n+ci] = append(members[i.first], members[i.second]...)
+		delete(members, i.first)
+		delete(members, i.second)
+	}
+	cluster := make([][]int, 0, len(members))
+	for _, i := range members {
+		sort.Ints(i)
+		cluster = append(cluster, i)
+	}
+	sort.Slice(cluster, func(i, j int) bool { return cluster[i][0] < cluster[j][0] })
+	return cluster
+}
+
+/*
+Agglomerative (hierarchical) clustering with a selectable linkage
+
+	:parameter
+		*	inSlice: slice to be clustered
+		*	distType: which distance metric should be used (euclidean, manhattan, hamming, braycurtis) - centroid, median and ward need euclidean
+		*	linkage: which linkage should be used
+			-	single: smallest distance between the members
+			-	complete: largest distance between the members
+			-	average: mean distance between the members (UPGMA)
+			-	weighted: mean of the distances of the two merged clusters (WPGMA)
+			-	centroid: distance between the centroids (UPGMC)
+			-	median: distance between the midpoints of the merged clusters (WPGMC)
+			-	ward: increase of the within cluster variance
+		*	maxDist: maximum distance of two clusters to be merged - math.Inf(1) to not stop at a distance
+		*	numClusters: number of clusters at which merging stops - 1 to not stop at a number of clusters
+	:return
+		*	cluster: indices of members of clusters in their own slice
+*/
+func agglomerativeClustering(inSlice [][]float64, distType *string, linkage *string, maxDist *float64, numClusters *int) [][]int {
+	if isinString(squaredLinkages, *linkage) && *distType != "euclidean" {
+		fmt.Printf("Linkage ['%s'] is only defined for euclidean distances and not for ['%s']\n", *linkage, *distType)
+	}
+	merges := agglomerate(pairwiseDistances(inSlice, distType), linkage, maxDist, numClusters)
+	return clustersFromMerges(len(inSlice), merges)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestAgglomerativeLinkageHeights(t *testing.T) {
+	// points 0, 1, 3 and 7 on a line merge in the same order for every linkage - only the heights differ
+	x := [][]float64{{0}, {1}, {3}, {7}}
+	distType := "euclidean"
+	for _, i := range []struct {
+		linkage string
+		heights [3]float64
+	}{
+		{"single", [3]float64{1, 2, 4}},
+		{"complete", [3]float64{1, 3, 7}},
+		{"average", [3]float64{1, 2.5, 17. / 3}},
+		{"weighted", [3]float64{1, 2.5, 5.25}},
+		{"centroid", [3]float64{1, 2.5, 17. / 3}},
+		{"median", [3]float64{1, 2.5, 5.25}},
+		// sqrt(2 * |a| * |b| / (|a| + |b|)) * distance of the centroids
+		{"ward", [3]float64{1, math.Sqrt(4./3) * 2.5, math.Sqrt(6./4) * 17. / 3}},
+	} {
+		linkageMat := agglomerativeLinkage(x, &distType, &i.linkage)
+		want := [][]float64{{0, 1, i.heights[0], 2}, {2, 4, i.heights[1], 3}, {3, 5, i.heights[2], 4}}
+		for ci, row := range want {
+			for cj, j := range row {
+				if math.Abs(linkageMat[ci][cj]-j) > 1e-12 {
+					t.Errorf("%s: linkage matrix %v, want %v", i.linkage, linkageMat, want)
+				}
+			}
+		}
+	}
+}
+
+func TestAgglomerativeClusteringStops(t *testing.T) {
+	x, blobs := testBlobs([]int{15, 25, 35}, 3, 37)
+	distType := "euclidean"
+	linkage := "average"
+	maxDist := math.Inf(1)
+	numClusters := 3
+	clusters := agglomerativeClustering(x, &distType, &linkage, &maxDist, &numClusters)
+	labels := make([]int, len(x))
+	for ci, i := range clusters {
+		for _, j := range i {
+			labels[j] = ci
+		}
+	}
+	if !testMatchesBlobs(labels, blobs) {
+		t.Errorf("clusters %v don't match the blobs", clusters)
+	}
+	// the blobs are 10 * sqrt(3) apart so no merge happens above a distance of 5
+	maxDist = 5
+	numClusters = 1
+	if got := len(agglomerativeClustering(x, &distType, &linkage, &maxDist, &numClusters)); got != 3 {
+		t.Errorf("[%d] clusters below a distance of 5, want 3", got)
+	}
+}
+
+/*
+Single linkage of the points 0, 1, 3 and 7 on a line
+*/
+var testLinkageMat = [][]float64{{0, 1, 1, 2}, {2, 4, 2, 3}, {3, 5, 4, 4}}
+
+/*
+Check that the clusters have exactly the wanted members in the same order
+*/
+func testEqualClusters(t *testing.T, name string, got, want [][]int) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s: clusters %v, want %v", name, got, want)
+		return
+	}
+	for ci, i := range want {
+		if len(got[ci]) != len(i) {
+			t.Errorf("%s: clusters %v, want %v", name, got, want)
+			return
+		}
+		for cj, j := range i {
+			if got[ci][cj] != j {
+				t.Errorf("%s: clusters %v, want %v", name, got, want)
+				return
+			}
+		}
+	}
+}
+
+func TestCutTree(t *testing.T) {
+	for _, i := range []struct {
+		height float64
+		want   [][]int
+	}{
+		{0.5, [][]int{{0}, {1}, {2}, {3}}},
+		// a merge exactly at the height is kept
+		{1, [][]int{{0, 1}, {2}, {3}}},
+		{3, [][]int{{0, 1, 2}, {3}}},
+		{10, [][]int{{0, 1, 2, 3}}},
+	} {
+		testEqualClusters(t, fmt.Sprintf("height %g", i.height), cutTreeHeight(testLinkageMat, &i.height), i.want)
+	}
+	// the merge at 1 is above the cut in the subtree of a merge at 2 with an inversion
+	inverted := [][]float64{{0, 1, 2, 2}, {2, 3, 1, 3}}
+	height := 1.5
+	testEqualClusters(t, "inversion", cutTreeHeight(inverted, &height), [][]int{{0}, {1}, {2}})
+	numClusters := 2
+	clusters := cutTreeCount(testLinkageMat, &numClusters)
+	for _, i := range clusters {
+		sort.Ints(i)
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i][0] < clusters[j][0] })
+	testEqualClusters(t, "count", clusters, [][]int{{0, 1, 2}, {3}})
+}
+
+func TestCopheneticDistances(t *testing.T) {
+	want := [][]float64{{0, 1, 2, 4}, {1, 0, 2, 4}, {2, 2, 0, 4}, {4, 4, 4, 0}}
+	got := copheneticDistances(testLinkageMat)
+	for ci, i := range want {
+		for cj, j := range i {
+			if got[ci][cj] != j {
+				t.Fatalf("cophenetic distances %v, want %v", got, want)
+			}
+		}
+	}
+	x := [][]float64{{0}, {1}, {3}, {7}}
+	distMat := make([][]float64, len(x))
+	for ci, i := range x {
+		distMat[ci] = make([]float64, len(x))
+		for cj, j := range x {
+			distMat[ci][cj] = math.Abs(i[0] - j[0])
+		}
+	}
+	// cophenetic 1 2 4 2 4 4 against the original 1 3 7 2 6 4
+	if corr := copheneticCorrelation(testLinkageMat, distMat); math.Abs(corr-83/math.Sqrt(53*161)) > 1e-12 {
+		t.Errorf("cophenetic correlation %g, want %g", corr, 83/math.Sqrt(53*161))
+	}
+}
+
+func TestDendrogramExport(t *testing.T) {
+	labels := []string{"a", "b", "c d", "e"}
+	if got, want := newickTree(testLinkageMat, labels), "(e:4,('c d':2,(a:1,b:1):1):2);"; got != want {
+		t.Errorf("newick tree %s, want %s", got, want)
+	}
+	var root dendrogramNode
+	if err := json.Unmarshal([]byte(dendrogramJSON(testLinkageMat, nil)), &root); err != nil {
+		t.Fatal(err)
+	}
+	if root.Height != 4 || root.Size != 4 || root.Children[0].Name != "3" || root.Children[1].Children[1].Height != 1 {
+		t.Errorf("JSON dendrogram %+v, want the root at 4 with the leaf 3 and the merge at 2", root)
+	}
+}
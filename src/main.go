@@ -2,12 +2,18 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"sync"
 )
 
 const float64EqualityThreshold = 1e-9
 
 func main() {
+	// profile a data set with: describe <csv file> [text|csv|json]
+	if len(os.Args) > 2 && os.Args[1] == "describe" {
+		runDescribe(os.Args[2:])
+		return
+	}
 	// rand.Seed(42)
 	// read data csv train KNN and testt accuracy
 
@@ -25,19 +31,23 @@ func main() {
 	convertCat := true
 	firstLineLabels := true
 	scaleFeatures := false
+	// whether the data should be shuffled before splitting (false for time ordered data)
+	shuffleData := true
 	// whether the neighbor importance should be scaled by distance
 	scale := false
 
-	trainFeatures, trainLabels, testFeatures, testLabels, _, _ := genTrainTestData(&fPath, &trainFract, &convertCat, &firstLineLabels, &scaleFeatures)
+	trainFeatures, trainLabels, testFeatures, testLabels, labelMap, _ := genTrainTestData(&fPath, &trainFract, &convertCat, &firstLineLabels, &scaleFeatures, &shuffleData)
 	testSize := len(testLabels)
 	pred := make([]int, testSize)
+	proba := make([]map[int]float64, testSize)
 	var wg sync.WaitGroup
 	wg.Add(testSize)
 	for ci, i := range testFeatures {
 		go func(ci int, i []float64) {
 			var err error
-			res, _, _, _ := kNNClassifier(trainFeatures, trainLabels, i, &k, &distanceMetric, &scale)
+			res, _, _, resultClasses := kNNClassifier(trainFeatures, trainLabels, i, &k, &distanceMetric, &scale)
 			pred[ci] = *res
+			proba[ci] = resultClasses
 			if err != nil {
 				panic(err)
 			}
@@ -45,7 +55,13 @@ func main() {
 		}(ci, i)
 	}
 	wg.Wait()
-	fmt.Println(multiclassAccuracy(testLabels, pred))
+	fmt.Println(newClassificationReport(pred, testLabels, invertLabelMap(labelMap)))
+	// the ROC AUC is only defined if the test split contains at least two classes
+	if len(uniqueInts(testLabels)) > 1 {
+		_, macroAUC, _ := oneVsRestAUC(proba, testLabels)
+		fmt.Printf("ROC AUC (one-vs-rest macro): %.4f\n", macroAUC)
+	}
+	fmt.Printf("log loss: %.4f\n", logLoss(proba, testLabels))
 
 	/*
 		for i := 0; i < testSize; i++ {
@@ -53,35 +69,55 @@ func main() {
 			pred[i] = *res
 		}
 	*/
+	/*
+		// stratified 5-fold cross-validation of the kNN classifier
+		numFolds := 5
+		shuffle := true
+		scalerType := "none"
+		model := newKNNClassifierModel(&k, &distanceMetric, &scale, &scalerType)
+		fmt.Println(crossValidate[int](model, trainFeatures, trainLabels, stratifiedKFold(trainLabels, &numFolds, &shuffle), multiclassAccuracy))
+		// leave-one-out cross-validation
+		fmt.Println(leaveOneOutCV[int](model, trainFeatures, trainLabels, multiclassAccuracy))
+		// grid search for the best kNN parameters
+		space := kNNParamSpace{
+			k:          []int{1, 3, 5, 7, 11},
+			distType:   []string{"euclidean", "manhattan", "hamming", "braycurtis"},
+			scaleDist:  []bool{false, true},
+			scalerType: []string{"none", "minmax", "standard"},
+		}
+		greaterIsBetter := true
+		results, bestModel := kNNClassifierGridSearch(trainFeatures, trainLabels, space, stratifiedKFold(trainLabels, &numFolds, &shuffle), multiclassAccuracy, &greaterIsBetter)
+		printSearchResults(results)
+		fmt.Println(multiclassAccuracy(bestModel.Predict(testFeatures), testLabels))
+		// compare the best model against the current one
+		exact := false
+		fmt.Println(mcNemarTest(bestModel.Predict(testFeatures), pred, testLabels, &exact))
+		fmt.Println(fiveByTwoCVTest[int](bestModel, model, trainFeatures, trainLabels, multiclassAccuracy))
+		numResamples := 1000
+		confidence := 0.95
+		fmt.Println(bootstrapCI(pred, testLabels, multiclassAccuracy, &numResamples, &confidence))
+	*/
 	/*
 		// cluster correlating attributes
 		maximumIteration := 20
 		minimumCorrelation := .6
-		clusters := hierarchicalCorrelationClustering(trainFeatures, &maximumIteration, &minimumCorrelation)
-		trainSize := len(trainFeatures)
-		newTrainFeatures := make([][]float64, trainSize)
-		newTestFeatures := make([][]float64, testSize)
-		for _, i := range clusters {
-			feature := -1
-			if len(i) > 1 {
-					feature = *findRepresentativeForCluster(trainFeatures, i)
-			} else {
-				feature =  i[0]
-			}
-			for cj, j := range trainFeatures {
-				newTrainFeatures[cj] = append(newTrainFeatures[cj], j[feature])
-			}
-			for cj, j := range testFeatures {
-				newTestFeatures[cj] = append(newTestFeatures[cj], j[feature])
-			}
-		}
+		correlationType := "spearman"
+		correlationMatrix := CorrelationMatrix(trainFeatures, &correlationType)
+		corrPath := "../datasets/correlationMatrix.csv"
+		writeCorrelationMatrixCSV(correlationMatrix, nil, &corrPath)
+		// keep one representative feature of every cluster of correlating features
+		representativeStrategy := "correlation"
+		reducer := newCorrelationReducer(&minimumCorrelation, &maximumIteration, &correlationType, &representativeStrategy)
+		newTrainFeatures, keptNames := reducer.FitTransform(trainFeatures, nil)
+		newTestFeatures := reducer.Transform(testFeatures, nil)
+		fmt.Println(keptNames, len(newTrainFeatures), len(newTestFeatures))
 	*/
 	/*
 		// read data csv train KNN and testt accuracy
 		fPath := "../datasets/spambase/spambaseNew.data"
 		trainFract := 0.8
 		convertCat := false
-		trainFeatures, trainLabels, testFeatures, testLabels, _, _ := genTrainTestData(&fPath, &trainFract, &convertCat)
+		trainFeatures, trainLabels, testFeatures, testLabels, labelMap, _ := genTrainTestData(&fPath, &trainFract, &convertCat)
 		testSize := len(testLabels)
 		pred := make([]int, testSize)
 		k := 10
@@ -98,17 +134,87 @@ func main() {
 		maximumIterations := 100
 		maximumDistance := 7.
 		fmt.Println(hierachicalClustering(data, &distanceType, &maximumIterations, &maximumDistance))
+		linkage := "ward"
+		numberOfClusters := 1
+		fmt.Println(agglomerativeClustering(data, &distanceType, &linkage, &maximumDistance, &numberOfClusters))
+		// complete hierarchy as SciPy compatible linkage matrix
+		linkageMatrix := agglomerativeLinkage(data, &distanceType, &linkage)
+		cutHeight := 3.
+		fmt.Println(cutTreeHeight(linkageMatrix, &cutHeight), cutTreeCount(linkageMatrix, &numberOfClusters))
+		fmt.Println(copheneticCorrelation(linkageMatrix, pairwiseDistances(data, &distanceType)))
+		fmt.Println(newickTree(linkageMatrix, nil))
+		// O(n²) hierarchy for large data sets with float32 distances
+		useFloat32 := true
+		fmt.Println(fastAgglomerativeLinkage(data, &distanceType, &linkage, &useFloat32))
+		// k-means with 10 k-means++ restarts
+		numberOfRestarts := 10
+		tolerance := 1e-4
+		kMeansModel := newKMeans(&numberOfClusters, &numberOfRestarts, &maximumIterations, &tolerance, &distanceType)
+		fmt.Println(kMeansModel.FitPredict(data), kMeansModel.inertia, kMeansModel.Predict([][]float64{{2, 3}}))
+		// mini-batch k-means on a csv file that is too large for memory
+		batchSize := 1024
+		numberOfEpochs := 20
+		miniBatchModel := newMiniBatchKMeans(&numberOfClusters, &batchSize, &numberOfRestarts, &numberOfEpochs, &tolerance)
+		miniBatchModel.FitCSV(&fPath, &firstLineLabels)
+		fmt.Println(miniBatchModel.inertia, miniBatchModel.Predict(data))
+		// k-medoids for distances without a meaningful centroid
+		medoidDistance := "braycurtis"
+		medoidMethod := "clara"
+		numberOfSamples := 5
+		sampleSize := 0
+		kMedoidsModel := newKMedoids(&numberOfClusters, &maximumIterations, &medoidDistance, &medoidMethod, &numberOfSamples, &sampleSize)
+		fmt.Println(kMedoidsModel.FitPredict(data), kMedoidsModel.medoids, kMedoidsModel.cost)
+		// density based clustering with noise (-1) - eps from the knee of the k-distance plot
+		minimumPoints := 3
+		neighborRank := minimumPoints - 1
+		_, epsilon := kDistances(data, &neighborRank, &distanceType)
+		dbscanModel := newDBSCAN(&epsilon, &minimumPoints, &distanceType)
+		fmt.Println(dbscanModel.FitPredict(data), dbscanModel.pointTypes())
+		// density based clustering for clusters of different density
+		maximumEpsilon := math.Inf(1)
+		xi := 0.05
+		minimumClusterSize := 0
+		opticsModel := newOPTICS(&minimumPoints, &maximumEpsilon, &xi, &minimumClusterSize, &distanceType)
+		fmt.Println(opticsModel.FitPredict(data), opticsModel.reachability, opticsModel.extractDBSCAN(&epsilon))
+		hdbscanClusterSize := 3
+		clusterSelection := "eom"
+		hdbscanModel := newHDBSCAN(&hdbscanClusterSize, &minimumClusterSize, &distanceType, &clusterSelection)
+		fmt.Println(hdbscanModel.FitPredict(data), hdbscanModel.probabilities, hdbscanModel.outlierScores)
+		// soft clustering with a Gaussian mixture - choose the number of components by BIC
+		covarianceType := "full"
+		regularization := 1e-6
+		mixtureInit := "kmeans"
+		mixtureModel := newGaussianMixture(&numberOfClusters, &covarianceType, &maximumIterations, &numberOfRestarts, &tolerance, &regularization, &mixtureInit)
+		mixtureModel.Fit(data)
+		fmt.Println(mixtureModel.bic(data), mixtureModel.aic(data), mixtureModel.PredictProba(data))
+		numberOfDraws := 5
+		fmt.Println(mixtureModel.sample(&numberOfDraws))
 
 		// cluster based on correlation
 		data := [][]float64{{1,2,0}, {2,3,1}, {3,4,1}, {4,5,0}}
 		maximumIteration := 10
 		minimumCorrelation := .2
-		fmt.Println(hierarchicalCorrelationClustering(data, &maximumIteration, &minimumCorrelation))
+		correlationType := "pearson"
+		fmt.Println(hierarchicalCorrelationClustering(data, &maximumIteration, &minimumCorrelation, &correlationType))
 
 		// correlation coefficient
 		x := [][]float64{{15,25}, {18,25}, {21,27}, {24,31}, {27,32}}
 		x = [][]float64{{43, 99}, {21, 65}, {25, 79}, {42, 75}, {57, 87}, {59, 81}}
 		fmt.Println(corrCoef(x, 0, 1))
+		colX, colY := 0, 1
+		fmt.Println(spearmanCorr(x, &colX, &colY), kendallTau(x, &colX, &colY))
+
+		// test which features differ between the classes
+		testType := "mannwhitney"
+		for ci, i := range featureClassTests(trainFeatures, trainLabels, &testType) {
+			fmt.Println(ci, i)
+		}
+		// keep the 20 features with the highest mutual information with the classes
+		numKept := 20
+		selector := newSelectKBest(classificationScores["mutualinfo"], &numKept)
+		newTrainFeatures, keptNames := selector.FitTransform(trainFeatures, trainLabels, nil)
+		newTestFeatures := selector.Transform(testFeatures)
+		fmt.Println(keptNames, len(newTrainFeatures), len(newTestFeatures))
 
 		// get scaler to scale the data
 		x := [][]float64{{1, 2}, {3, 4}, {5, 6}}
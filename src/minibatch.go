@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"runtime"
+)
+
+/*
+Mini-batch k-means (Sculley 2010) - updates the centers with small batches so that data sets that don't fit in memory can be clustered
+*/
+type miniBatchKMeans struct {
+	k         int
+	batchSize int
+	nInit     int
+	maxIter   int
+	tol       float64
+	// seeding and parallel assignment are shared with k-means
+	base *kMeans
+	// set by Fit and PartialFit
+	centers [][]float64
+	counts  []float64
+	pending [][]float64
+	labels  []int
+	inertia float64
+}
+
+/*
+Create an unfitted mini-batch k-means clustering
+
+	:parameter
+		*	k: number of clusters
+		*	batchSize: number of vectors per update
+		*	nInit: number of greedy k-means++ seedings of which the one with the lowest inertia on the seeding vectors is kept
+		*	maxIter: maximum number of passes (epochs) over the data
+		*	tol: fitting stops when the squared shift of the centers during an epoch is below tol times the mean variance of the features
+	:return
+		*	model: the unfitted model
+*/
+func newMiniBatchKMeans(k *int, batchSize *int, nInit *int, maxIter *int, tol *float64) *miniBatchKMeans {
+	if *k < 1 || *batchSize < 1 || *nInit < 1 || *maxIter < 1 {
+		log.Fatalln(fmt.Sprintf("Number of clusters [%d], batch size [%d], seedings [%d] and iterations [%d] have to be at least 1", *k, *batchSize, *nInit, *maxIter))
+	}
+	base := &kMeans{k: *k, nInit: *nInit, maxIter: 1, tol: *tol, distType: "euclidean"}
+	return &miniBatchKMeans{k: *k, batchSize: *batchSize, nInit: *nInit, maxIter: *maxIter, tol: *tol, base: base}
+}
+
+/*
+Seed the centers with the best of nInit greedy k-means++ seedings with 2 + log(k) candidates per center
+
+	:parameter
+		*	x: vectors the centers are seeded from
+	:return
+		None
+*/
+func (m *miniBatchKMeans) seed(x [][]float64) {
+	bestInertia := math.Inf(1)
+	numTrials := 2 + int(math.Log(float64(m.k)))
+	for run := 0; run < m.nInit; run++ {
+		centers := m.base.greedySeed(x, numTrials)
+		_, costs := m.base.assign(x, centers)
+		inertia := 0.0
+		for _, i := range costs {
+			inertia += i
+		}
+		if inertia < bestInertia {
+			m.centers, bestInertia = centers, inertia
+		}
+	}
+	m.counts = make([]float64, m.k)
+}
+
+/*
+Update the centers with one batch - every center moves towards its new members with a learning rate of 1 / number of vectors it has seen
+Before the first update the vectors are buffered until there are three batches (and at least k vectors) to seed the centers with greedy k-means++
+
+	:parameter
+		*	x: the batch
+	:return
+		None
+*/
+func (m *miniBatchKMeans) PartialFit(x [][]float64) {
+	if m.centers == nil {
+		m.pending = append(m.pending, x...)
+		if len(m.pending) < m.k || len(m.pending) < 3*m.batchSize {
+			return
+		}
+		x, m.pending = m.pending, nil
+		m.seed(x)
+	}
+	labels, _ := m.base.assign(x, m.centers)
+	for ci, i := range labels {
+		m.counts[i]++
+		learningRate := 1 / m.counts[i]
+		for cj, j := range x[ci] {
+			m.centers[i][cj] += learningRate * (j - m.centers[i][cj])
+		}
+	}
+}
+
+/*
+Cluster data that fits in memory by shuffled mini-batches
+
+	:parameter
+		*	x: vectors that should be clustered
+	:return
+		None
+*/
+func (m *miniBatchKMeans) Fit(x [][]float64) {
+	if len(x) < m.k {
+		log.Fatalln(fmt.Sprintf("Number of samples [%d] has to be at least the number of clusters [%d]", len(x), m.k))
+	}
+	meanVariance := 0.0
+	moments := columnMoments(x)
+	for _, i := range moments {
+		meanVariance += i.variance(0) / float64(len(moments))
+	}
+	order := rand.Perm(len(x))
+	// seed on a random subset of three batches
+	initSize := int(math.Min(float64(len(x)), math.Max(float64(3*m.batchSize), float64(m.k))))
+	m.seed(subsetRows(x, order[:initSize]))
+	m.pending = nil
+	for epoch := 0; epoch < m.maxIter; epoch++ {
+		previous := copyFeatures(m.centers)
+		rand.Shuffle(len(order), func(i, j int) {
+			order[i], order[j] = order[j], order[i]
+		})
+		for start := 0; start < len(order); start += m.batchSize {
+			end := int(math.Min(float64(start+m.batchSize), float64(len(order))))
+			m.PartialFit(subsetRows(x, order[start:end]))
+		}
+		if centerShift(previous, m.centers) <= m.tol*meanVariance {
+			break
+		}
+	}
+	labels, costs := m.base.assign(x, m.centers)
+	m.labels = labels
+	m.inertia = 0
+	for _, i := range costs {
+		m.inertia += i
+	}
+}
+
+/*
+Summed squared shift of all centers between two iterations
+*/
+func centerShift(previous [][]float64, centers [][]float64) float64 {
+	shift := 0.0
+	for ci, i := range centers {
+		for cj, j := range i {
+			shift += (j - previous[ci][cj]) * (j - previous[ci][cj])
+		}
+	}
+	return shift
+}
+
+/*
+Convert a chunk of csv lines to feature vectors - lines with missing values are skipped
+*/
+func parseFeatureChunk(chunk [][]string) [][]float64 {
+	features := make([][]float64, 0, len(chunk))
+	for _, i := range chunk {
+		row := parseFeatureRecord(i)
+		complete := true
+		for _, j := range row {
+			if math.IsNaN(j) {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			features = append(features, row)
+		}
+	}
+	return features
+}
+
+/*
+Cluster a csv file (label in the first column) without loading it into memory - the file is streamed maxIter times in batches of batchSize lines and once more to calculate the inertia
+Lines are used in file order so the file should be shuffled
+
+	:parameter
+		*	filePath: path to the csv file
+		*	header: true if there is a header
+	:return
+		None
+*/
+func (m *miniBatchKMeans) FitCSV(filePath *string, header *bool) {
+	m.centers, m.counts, m.pending, m.labels = nil, nil, nil, nil
+	// the variance of the features for the tolerance is accumulated during the first epoch
+	var moments []welford
+	meanVariance := 0.0
+	for epoch := 0; epoch < m.maxIter; epoch++ {
+		previous := copyFeatures(m.centers)
+		// a single worker keeps the batches in order
+		streamCsvChunks(filePath, header, &m.batchSize, 1, func(worker int, chunk [][]string) {
+			batch := parseFeatureChunk(chunk)
+			if epoch == 0 {
+				for _, i := range batch {
+					if moments == nil {
+						moments = make([]welford, len(i))
+					}
+					for cj, j := range i {
+						moments[cj].add(j)
+					}
+				}
+			}
+			m.PartialFit(batch)
+		})
+		// files shorter than the seeding size are seeded at the end of the first epoch
+		if m.centers == nil && len(m.pending) >= m.k {
+			batch := m.pending
+			m.pending = nil
+			m.seed(batch)
+			m.PartialFit(batch)
+		}
+		if m.centers == nil {
+			log.Fatalln(fmt.Sprintf("File [%s] has less complete lines than clusters [%d]", *filePath, m.k))
+		}
+		if epoch == 0 {
+			for _, i := range moments {
+				meanVariance += i.variance(0) / float64(len(moments))
+			}
+			continue
+		}
+		if centerShift(previous, m.centers) <= m.tol*meanVariance {
+			break
+		}
+	}
+	numWorkers := runtime.NumCPU()
+	inertias := make([]float64, numWorkers)
+	streamCsvChunks(filePath, header, &m.batchSize, numWorkers, func(worker int, chunk [][]string) {
+		_, costs := m.base.assign(parseFeatureChunk(chunk), m.centers)
+		for _, i := range costs {
+			inertias[worker] += i
+		}
+	})
+	m.inertia = 0
+	for _, i := range inertias {
+		m.inertia += i
+	}
+}
+
+/*
+Assign new vectors to the closest cluster center
+
+	:parameter
+		*	x: vectors that should be assigned
+	:return
+		*	labels: cluster of every vector
+*/
+func (m *miniBatchKMeans) Predict(x [][]float64) []int {
+	if m.centers == nil {
+		log.Fatalln("Mini-batch k-means has to be fitted before predicting")
+	}
+	labels, _ := m.base.assign(x, m.centers)
+	return labels
+}
+
+/*
+Create an unfitted copy of the model with the same parameters
+*/
+func (m *miniBatchKMeans) Clone() *miniBatchKMeans {
+	return newMiniBatchKMeans(&m.k, &m.batchSize, &m.nInit, &m.maxIter, &m.tol)
+}
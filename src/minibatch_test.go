@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMiniBatchKMeansRecoversBlobs(t *testing.T) {
+	x, blobs := testBlobs([]int{400, 600, 800}, 2, 43)
+	k := 3
+	batchSize := 100
+	nInit := 3
+	maxIter := 20
+	tol := 1e-4
+	m := newMiniBatchKMeans(&k, &batchSize, &nInit, &maxIter, &tol)
+	m.Fit(x)
+	if labels := m.Predict(x); !testMatchesBlobs(labels, blobs) {
+		t.Errorf("labels don't match the blobs")
+	}
+}
+
+func TestMiniBatchKMeansPartialFit(t *testing.T) {
+	x, blobs := testBlobs([]int{300, 300, 300}, 2, 47)
+	order := permutation(len(x), true)
+	x, blobs = subsetRows(x, order), subsetRows(blobs, order)
+	k := 3
+	batchSize := 50
+	nInit := 3
+	maxIter := 1
+	tol := 0.
+	m := newMiniBatchKMeans(&k, &batchSize, &nInit, &maxIter, &tol)
+	for start := 0; start < len(x); start += batchSize {
+		m.PartialFit(x[start : start+batchSize])
+	}
+	if labels := m.Predict(x); !testMatchesBlobs(labels, blobs) {
+		t.Errorf("labels after streaming all batches don't match the blobs")
+	}
+}
+
+func TestMiniBatchKMeansFitCSV(t *testing.T) {
+	x, blobs := testBlobs([]int{200, 300, 250}, 2, 53)
+	// the file has to be shuffled since its lines are used in order
+	order := permutation(len(x), true)
+	x, blobs = subsetRows(x, order), subsetRows(blobs, order)
+	lines := []string{"label,a,b"}
+	for ci, i := range x {
+		lines = append(lines, fmt.Sprintf("%d,%v,%v", blobs[ci], i[0], i[1]))
+	}
+	// lines with missing values are skipped
+	lines = append(lines[:100], append([]string{"1,,3"}, lines[100:]...)...)
+	filePath := testWriteCSV(t, lines)
+	header := true
+	k := 3
+	batchSize := 64
+	nInit := 3
+	maxIter := 10
+	tol := 1e-4
+	m := newMiniBatchKMeans(&k, &batchSize, &nInit, &maxIter, &tol)
+	m.FitCSV(&filePath, &header)
+	if labels := m.Predict(x); !testMatchesBlobs(labels, blobs) {
+		t.Errorf("labels of the streamed fit don't match the blobs")
+	}
+	maxIter = 100
+	distType := "euclidean"
+	full := newKMeans(&k, &nInit, &maxIter, &tol, &distType)
+	full.Fit(x)
+	// the mini-batch centers are noisy so the inertia is only close to the one of k-means
+	if m.inertia < full.inertia*(1-1e-9) || m.inertia > full.inertia*1.02 {
+		t.Errorf("streamed inertia %g, want at most 2 %% above the k-means inertia %g", m.inertia, full.inertia)
+	}
+}
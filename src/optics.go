@@ -0,0 +1,380 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"log"
+	"math"
+)
+
+/*
+OPTICS (Ankerst et al. 1999) - orders the vectors by density reachability so that clusters of different densities can be extracted
+*/
+type optics struct {
+	minPts         int
+	maxEps         float64
+	xi             float64
+	minClusterSize int
+	distType       string
+	// set by Fit
+	ordering     []int
+	reachability []float64
+	coreDists    []float64
+	predecessor  []int
+	clusters     [][2]int
+	labels       []int
+}
+
+/*
+Reachability of a vector in the seed list of OPTICS
+*/
+type reachabilityEntry struct {
+	reach float64
+	point int
+}
+
+/*
+Min-heap of the seed list ordered by reachability and then by index - outdated entries stay in the heap and are skipped when popped
+*/
+type reachabilityHeap []reachabilityEntry
+
+func (h reachabilityHeap) Len() int { return len(h) }
+func (h reachabilityHeap) Less(i, j int) bool {
+	if h[i].reach != h[j].reach {
+		return h[i].reach < h[j].reach
+	}
+	return h[i].point < h[j].point
+}
+func (h reachabilityHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *reachabilityHeap) Push(x interface{}) { *h = append(*h, x.(reachabilityEntry)) }
+func (h *reachabilityHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
+
+/*
+Create an unfitted OPTICS clustering
+
+	:parameter
+		*	minPts: number of vectors in the neighborhood (including the vector itself) for a vector to be a core point
+		*	maxEps: maximum radius of the neighborhood - math.Inf(1) to search all vectors
+		*	xi: minimum relative steepness of the reachability plot at the borders of a cluster (between 0 and 1)
+		*	minClusterSize: minimum number of vectors in a cluster - 0 to use minPts
+		*	distType: which distance metric should be used (euclidean, manhattan, hamming, braycurtis)
+	:return
+		*	model: the unfitted model
+*/
+func newOPTICS(minPts *int, maxEps *float64, xi *float64, minClusterSize *int, distType *string) *optics {
+	if *minPts < 1 || *maxEps <= 0 || *xi <= 0 || *xi >= 1 {
+		log.Fatalln(fmt.Sprintf("minPts [%d] has to be at least 1, maxEps [%f] positive and xi [%f] between 0 and 1", *minPts, *maxEps, *xi))
+	}
+	chosenDist := *distType
+	if _, ok := distanceFunctions[chosenDist]; !ok {
+		selectDistanceFunction(distType)
+		chosenDist = "euclidean"
+	}
+	chosenSize := *minClusterSize
+	if chosenSize <= 0 {
+		chosenSize = *minPts
+	}
+	return &optics{minPts: *minPts, maxEps: *maxEps, xi: *xi, minClusterSize: chosenSize, distType: chosenDist}
+}
+
+/*
+Compute the reachability ordering and extract the clusters with the xi method
+
+	:parameter
+		*	x: vectors that should be clustered
+	:return
+		None
+*/
+func (m *optics) Fit(x [][]float64) {
+	n := len(x)
+	index := newNeighborIndex(x, &m.distType)
+	m.coreDists = coreDistances(index, m.minPts)
+	for ci, i := range m.coreDists {
+		if i > m.maxEps {
+			m.coreDists[ci] = math.Inf(1)
+		}
+	}
+	m.reachability = make([]float64, n)
+	m.predecessor = make([]int, n)
+	for i := range m.reachability {
+		m.reachability[i] = math.Inf(1)
+		m.predecessor[i] = -1
+	}
+	processed := make([]bool, n)
+	m.ordering = make([]int, 0, n)
+	seeds := &reachabilityHeap{}
+	// first unprocessed vector for when no vector is reachable
+	unreached := 0
+	for len(m.ordering) < n {
+		// the unprocessed vector with the smallest reachability is next
+		point := -1
+		for seeds.Len() > 0 && point == -1 {
+			entry := heap.Pop(seeds).(reachabilityEntry)
+			if !processed[entry.point] && entry.reach == m.reachability[entry.point] {
+				point = entry.point
+			}
+		}
+		if point == -1 {
+			for processed[unreached] {
+				unreached++
+			}
+			point = unreached
+		}
+		processed[point] = true
+		m.ordering = append(m.ordering, point)
+		if math.IsInf(m.coreDists[point], 1) {
+			continue
+		}
+		neighbors, dists := index.radiusQuery(x[point], m.maxEps)
+		for ci, i := range neighbors {
+			if processed[i] {
+				continue
+			}
+			if reach := math.Max(dists[ci], m.coreDists[point]); reach < m.reachability[i] {
+				m.reachability[i] = reach
+				m.predecessor[i] = point
+				heap.Push(seeds, reachabilityEntry{reach: reach, point: i})
+			}
+		}
+	}
+	m.clusters = m.xiClusters()
+	m.labels = m.xiLabels()
+}
+
+/*
+Cluster the data and return the cluster of every vector
+
+	:parameter
+		*	x: vectors that should be clustered
+	:return
+		*	labels: cluster of every vector - -1 for noise
+*/
+func (m *optics) FitPredict(x [][]float64) []int {
+	m.Fit(x)
+	return m.labels
+}
+
+/*
+Extend a steep region - it ends at the last steep point before a point going in the other direction or more than minPts points that are neither steep nor going in the other direction
+*/
+func extendSteepRegion(steep []bool, xward []bool, start int, minPts int) int {
+	nonXward := 0
+	end := start
+	for i := start; i < len(steep); i++ {
+		if steep[i] {
+			nonXward = 0
+			end = i
+		} else if !xward[i] {
+			nonXward++
+			if nonXward > minPts {
+				break
+			}
+		} else {
+			return end
+		}
+	}
+	return end
+}
+
+/*
+Steep down area of the reachability plot with the maximum reachability between it and the current position
+*/
+type steepDownArea struct {
+	start int
+	end   int
+	mib   float64
+}
+
+/*
+Find the clusters in the reachability plot with the xi method as in scikit-learn - clusters start at a steep down area and end at a steep up area
+
+	:parameter
+		None
+	:return
+		*	clusters: start and end (inclusive) of every cluster in the ordering - smaller (nested) clusters come before the clusters that contain them
+*/
+func (m *optics) xiClusters() [][2]int {
+	n := len(m.ordering)
+	// reachability plot with an infinite reachability after the last point
+	plot := append(subsetRows(m.reachability, m.ordering), math.Inf(1))
+	predecessorPlot := subsetRows(m.predecessor, m.ordering)
+	xiComplement := 1 - m.xi
+	steepUp := make([]bool, n)
+	steepDown := make([]bool, n)
+	up := make([]bool, n)
+	down := make([]bool, n)
+	for i := 0; i < n; i++ {
+		// inf / inf is NaN and neither steep, up nor down
+		ratio := plot[i] / plot[i+1]
+		steepUp[i] = ratio <= xiComplement
+		steepDown[i] = ratio >= 1/xiComplement
+		down[i] = ratio > 1
+		up[i] = ratio < 1
+	}
+	// keep the steep down areas that can still start a cluster and update their maximum in between
+	filterAreas := func(areas []*steepDownArea, mib float64) []*steepDownArea {
+		if math.IsInf(mib, 1) {
+			return nil
+		}
+		kept := []*steepDownArea{}
+		for _, i := range areas {
+			if mib <= plot[i.start]*xiComplement {
+				i.mib = math.Max(i.mib, mib)
+				kept = append(kept, i)
+			}
+		}
+		return kept
+	}
+	// shrink the cluster until the predecessor of its end is part of it
+	correctPredecessor := func(start int, end int) (int, int, bool) {
+		for start < end {
+			if plot[start] > plot[end] {
+				return start, end, true
+			}
+			for i := start; i < end; i++ {
+				if predecessorPlot[end] == m.ordering[i] {
+					return start, end, true
+				}
+			}
+			end--
+		}
+		return 0, 0, false
+	}
+
+	clusters := [][2]int{}
+	areas := []*steepDownArea{}
+	index := 0
+	mib := 0.0
+	for steepIndex := 0; steepIndex < n; steepIndex++ {
+		if !(steepUp[steepIndex] || steepDown[steepIndex]) || steepIndex < index {
+			continue
+		}
+		for i := index; i <= steepIndex; i++ {
+			mib = math.Max(mib, plot[i])
+		}
+		areas = filterAreas(areas, mib)
+		if steepDown[steepIndex] {
+			end := extendSteepRegion(steepDown, up, steepIndex, m.minPts)
+			areas = append(areas, &steepDownArea{start: steepIndex, end: end})
+			index = end + 1
+			mib = plot[index]
+			continue
+		}
+		upStart := steepIndex
+		upEnd := extendSteepRegion(steepUp, down, steepIndex, m.minPts)
+		index = upEnd + 1
+		mib = plot[index]
+		upClusters := [][2]int{}
+		for _, i := range areas {
+			start, end := i.start, upEnd
+			if plot[end+1]*xiComplement < i.mib {
+				continue
+			}
+			downMax := plot[i.start]
+			if downMax*xiComplement >= plot[end+1] {
+				// start at the point of the down area at the level of the end of the cluster
+				for plot[start+1] > plot[end+1] && start < i.end {
+					start++
+				}
+			} else if plot[end+1]*xiComplement >= downMax {
+				// end at the point of the up area at the level of the start of the cluster
+				for plot[end-1] > downMax && end > upStart {
+					end--
+				}
+			}
+			var ok bool
+			if start, end, ok = correctPredecessor(start, end); !ok {
+				continue
+			}
+			if end-start+1 < m.minClusterSize || start > i.end || end < upStart {
+				continue
+			}
+			upClusters = append(upClusters, [2]int{start, end})
+		}
+		// the clusters of the latest down areas are the smallest
+		for i := len(upClusters) - 1; i >= 0; i-- {
+			clusters = append(clusters, upClusters[i])
+		}
+	}
+	return clusters
+}
+
+/*
+Labels of the xi clusters - every vector gets the label of the smallest cluster it belongs to and clusters that overlap an already labeled one are skipped
+
+	:parameter
+		None
+	:return
+		*	labels: cluster of every vector - -1 for noise
+*/
+func (m *optics) xiLabels() []int {
+	orderedLabels := make([]int, len(m.ordering))
+	for i := range orderedLabels {
+		orderedLabels[i] = -1
+	}
+	label := 0
+	for _, i := range m.clusters {
+		free := true
+		for j := i[0]; j <= i[1]; j++ {
+			if orderedLabels[j] != -1 {
+				free = false
+				break
+			}
+		}
+		if free {
+			for j := i[0]; j <= i[1]; j++ {
+				orderedLabels[j] = label
+			}
+			label++
+		}
+	}
+	labels := make([]int, len(m.ordering))
+	for ci, i := range m.ordering {
+		labels[i] = orderedLabels[ci]
+	}
+	return labels
+}
+
+/*
+Extract the DBSCAN clustering for a radius from the reachability ordering - equals DBSCAN with minPts up to the assignment of border points
+
+	:parameter
+		*	eps: radius of the neighborhood - has to be smaller than maxEps
+	:return
+		*	labels: cluster of every vector - -1 for noise
+*/
+func (m *optics) extractDBSCAN(eps *float64) []int {
+	if m.ordering == nil {
+		log.Fatalln("OPTICS has to be fitted before extracting clusters")
+	}
+	if *eps > m.maxEps {
+		fmt.Printf("eps [%f] is larger than maxEps [%f] the extracted clusters are not exact\n", *eps, m.maxEps)
+	}
+	labels := make([]int, len(m.ordering))
+	label := -1
+	for _, i := range m.ordering {
+		if m.reachability[i] > *eps {
+			if m.coreDists[i] <= *eps {
+				label++
+				labels[i] = label
+			} else {
+				labels[i] = -1
+			}
+		} else {
+			labels[i] = label
+		}
+	}
+	return labels
+}
+
+/*
+Create an unfitted copy of the model with the same parameters
+*/
+func (m *optics) Clone() *optics {
+	return &optics{minPts: m.minPts, maxEps: m.maxEps, xi: m.xi, minClusterSize: m.minClusterSize, distType: m.distType}
+}
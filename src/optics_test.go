@@ -0,0 +1,93 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOPTICSExtractDBSCAN(t *testing.T) {
+	x, blobs := testBlobs([]int{40, 60, 80}, 2, 67)
+	x = append(x, []float64{5, -5}, []float64{-5, 5})
+	minPts := 5
+	maxEps := 5.0
+	xi := 0.05
+	minClusterSize := 5
+	distType := "euclidean"
+	m := newOPTICS(&minPts, &maxEps, &xi, &minClusterSize, &distType)
+	m.Fit(x)
+	eps := 1.5
+	got := m.extractDBSCAN(&eps)
+	want := newDBSCAN(&eps, &minPts, &distType).FitPredict(x)
+	// the blobs have no border points so both clusterings have to be the same
+	if !testMatchesBlobs(got[:len(blobs)], want[:len(blobs)]) || !testMatchesBlobs(got[:len(blobs)], blobs) {
+		t.Errorf("extracted labels %v, want %v", got, want)
+	}
+	for ci := len(blobs); ci < len(x); ci++ {
+		if got[ci] != -1 {
+			t.Errorf("outlier %v has label %d, want -1", x[ci], got[ci])
+		}
+	}
+}
+
+func TestOPTICSXiClusters(t *testing.T) {
+	// a dense grid with a spacing of 0.1 and a sparse grid with a spacing of 1 - no single eps finds both
+	x := [][]float64{}
+	for i := 0; i < 64; i++ {
+		x = append(x, []float64{float64(i%8) * 0.1, float64(i/8) * 0.1})
+	}
+	for i := 0; i < 64; i++ {
+		x = append(x, []float64{20 + float64(i%8), 20 + float64(i/8)})
+	}
+	minPts := 5
+	maxEps := math.Inf(1)
+	xi := 0.05
+	minClusterSize := 10
+	distType := "euclidean"
+	m := newOPTICS(&minPts, &maxEps, &xi, &minClusterSize, &distType)
+	labels := m.FitPredict(x)
+	// the ordering visits the dense grid first so the grids are the first and the last 64 positions
+	found := map[[2]int]bool{}
+	for ci, i := range m.clusters {
+		found[i] = true
+		// nested clusters come before the clusters that contain them
+		for _, j := range m.clusters[ci+1:] {
+			if i != j && i[0] <= j[0] && j[1] <= i[1] {
+				t.Errorf("cluster %v comes before the cluster %v it contains", i, j)
+			}
+		}
+	}
+	if !found[[2]int{0, 63}] || !found[[2]int{64, 127}] {
+		t.Errorf("clusters %v, want the grids [0 63] and [64 127]", m.clusters)
+	}
+	for ci, i := range m.ordering {
+		if (ci < 64) != (i < 64) {
+			t.Fatalf("ordering %v doesn't visit the grids one after the other", m.ordering)
+		}
+	}
+	// every grid gets its own label and only vectors at the start of a grid can be noise
+	gridLabel := [2]int{-1, -1}
+	for ci, i := range labels {
+		if i == -1 {
+			continue
+		}
+		if gridLabel[ci/64] == -1 {
+			gridLabel[ci/64] = i
+		}
+		if i != gridLabel[ci/64] {
+			t.Errorf("vector %v has the label %d, want %d of its grid", x[ci], i, gridLabel[ci/64])
+		}
+	}
+	noise := 0
+	for _, i := range labels {
+		if i == -1 {
+			noise++
+		}
+	}
+	if gridLabel[0] == -1 || gridLabel[0] == gridLabel[1] || noise > 2*minClusterSize {
+		t.Errorf("grid labels %v with %d noise vectors, want two different labels", gridLabel, noise)
+	}
+	eps := 0.15
+	if dense := m.extractDBSCAN(&eps); dense[64] != -1 {
+		t.Errorf("eps of the dense grid gives the sparse grid the label %d, want noise", dense[64])
+	}
+}
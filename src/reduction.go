@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+)
+
+/*
+Strategies to choose the representative feature of a cluster of correlated features
+*/
+var representativeStrategies = []string{"correlation", "variance", "pca"}
+
+/*
+Transformer that clusters correlated features and keeps one representative feature per cluster
+*/
+type correlationReducer struct {
+	minCorr  float64
+	maxIter  int
+	corrType string
+	strategy string
+	// set by Fit
+	clusters  [][]int
+	kept      []int
+	keptNames []string
+}
+
+/*
+Create an unfitted correlation cluster based feature reduction
+
+	:parameter
+		*	minCorr: minimum mean absolute correlation of two clusters to be merged
+		*	maxIter: maximum number of merges
+		*	corrType: which correlation should be used (pearson, spearman, kendall)
+		*	strategy: how the representative of a cluster is chosen
+			-	correlation: member with the highest summed absolute correlation to the other members
+			-	variance: member with the highest variance
+			-	pca: member with the highest absolute loading on the first principal component of the cluster
+	:return
+		*	reducer: the unfitted reducer
+*/
+func newCorrelationReducer(minCorr *float64, maxIter *int, corrType *string, strategy *string) *correlationReducer {
+	chosenStrategy := *strategy
+	if !isinString(representativeStrategies, chosenStrategy) {
+		fmt.Printf("Using default strategy ['correlation'] instead of the not implementd ['%s']\n", chosenStrategy)
+		chosenStrategy = "correlation"
+	}
+	return &correlationReducer{minCorr: *minCorr, maxIter: *maxIter, corrType: *corrType, strategy: chosenStrategy}
+}
+
+/*
+Absolute loadings of the first principal component of standardized features from their correlation matrix by power iteration
+
+	:parameter
+		*	corrMat: correlation matrix of the features - NaN entries are treated as 0
+	:return
+		*	loadings: absolute loading of every feature
+*/
+func firstComponentLoadings(corrMat [][]float64) []float64 {
+	dim := len(corrMat)
+	loadings := make([]float64, dim)
+	for i := range loadings {
+		loadings[i] = 1 / math.Sqrt(float64(dim))
+	}
+	for iter := 0; iter < 1000; iter++ {
+		next := make([]float64, dim)
+		norm := 0.0
+		for ci, i := range corrMat {
+			for cj, j := range i {
+				if !math.IsNaN(j) {
+					next[ci] += j * loadings[cj]
+				}
+			}
+			norm += next[ci] * next[ci]
+		}
+		norm = math.Sqrt(norm)
+		if norm == 0 {
+			break
+		}
+		change := 0.0
+		for ci := range next {
+			next[ci] /= norm
+			change = math.Max(change, math.Abs(next[ci]-loadings[ci]))
+		}
+		loadings = next
+		if change < 1e-10 {
+			break
+		}
+	}
+	for ci, i := range loadings {
+		loadings[ci] = math.Abs(i)
+	}
+	return loadings
+}
+
+/*
+Choose the representative of a cluster
+
+	:parameter
+		*	x: vectors representing the training data
+		*	corrMat: correlation matrix of all features
+		*	members: indices of the features in the cluster
+	:return
+		*	representative: index of the chosen feature
+*/
+func (r *correlationReducer) representative(x [][]float64, corrMat [][]float64, members []int) int {
+	if len(members) == 1 {
+		return members[0]
+	}
+	scores := make([]float64, len(members))
+	switch r.strategy {
+	case "variance":
+		for ci, i := range members {
+			moments := welford{}
+			for _, j := range x {
+				moments.add(j[i])
+			}
+			scores[ci] = moments.variance(0)
+		}
+	case "pca":
+		subMat := make([][]float64, len(members))
+		for ci, i := range members {
+			subMat[ci] = subsetRows(corrMat[i], members)
+		}
+		scores = firstComponentLoadings(subMat)
+	default:
+		for ci, i := range members {
+			for _, j := range members {
+				if corr := corrMat[i][j]; !math.IsNaN(corr) {
+					scores[ci] += math.Abs(corr)
+				}
+			}
+		}
+	}
+	best := 0
+	for ci, i := range scores {
+		if i > scores[best] {
+			best = ci
+		}
+	}
+	return members[best]
+}
+
+/*
+Cluster the features of the training data and choose the representative of every cluster
+
+	:parameter
+		*	x: vectors representing the training data
+		*	featureNames: names of the features - nil to use their indices
+	:return
+		None
+*/
+func (r *correlationReducer) Fit(x [][]float64, featureNames []string) {
+	if featureNames == nil {
+		featureNames = make([]string, len(x[0]))
+		for i := range featureNames {
+			featureNames[i] = strconv.Itoa(i)
+		}
+	}
+	if len(featureNames) != len(x[0]) {
+		log.Fatalln(fmt.Sprintf("Number of feature names [%d] not equal to the number of features [%d]", len(featureNames), len(x[0])))
+	}
+	corrMat := CorrelationMatrix(x, &r.corrType)
+	r.clusters = hierarchicalCorrelationClusteringMatrix(corrMat, &r.maxIter, &r.minCorr)
+	r.kept = make([]int, len(r.clusters))
+	for ci, i := range r.clusters {
+		r.kept[ci] = r.representative(x, corrMat, i)
+	}
+	sort.Ints(r.kept)
+	r.keptNames = subsetRows(featureNames, r.kept)
+}
+
+/*
+Keep only the representative features
+
+	:parameter
+		*	x: vectors of new data
+		*	featureNames: names of the features of x to find the representatives by name if the columns are in a different order - nil if x has the same columns as the training data
+	:return
+		*	transformed: copy of x with only the representative features
+*/
+func (r *correlationReducer) Transform(x [][]float64, featureNames []string) [][]float64 {
+	if r.kept == nil {
+		log.Fatalln("Correlation reducer has to be fitted before transforming data")
+	}
+	columns := r.kept
+	if featureNames != nil {
+		nameIdx := make(map[string]int, len(featureNames))
+		for ci, i := range featureNames {
+			nameIdx[i] = ci
+		}
+		columns = make([]int, len(r.keptNames))
+		for ci, i := range r.keptNames {
+			idx, ok := nameIdx[i]
+			if !ok {
+				log.Fatalln(fmt.Sprintf("Feature [%s] is missing in the data", i))
+			}
+			columns[ci] = idx
+		}
+	}
+	transformed := make([][]float64, len(x))
+	for ci, i := range x {
+		transformed[ci] = subsetRows(i, columns)
+	}
+	return transformed
+}
+
+/*
+Fit the reducer on the training data and transform it
+
+	:parameter
+		*	x: vectors representing the training data
+		*	featureNames: names of the features - nil to use their indices
+	:return
+		*	transformed: x with only the representative features
+		*	keptNames: names of the representative features
+*/
+func (r *correlationReducer) FitTransform(x [][]float64, featureNames []string) ([][]float64, []string) {
+	r.Fit(x, featureNames)
+	return r.Transform(x, nil), r.keptNames
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+/*
+Data with a cluster of the features 0 to 2 around a central feature 1, the high variance feature 2 and the independent feature 3
+*/
+func testCorrelatedFeatures(n int, seed int64) [][]float64 {
+	rng := rand.New(rand.NewSource(seed))
+	x := make([][]float64, n)
+	for i := range x {
+		z := rng.NormFloat64()
+		x[i] = []float64{z + 0.3*rng.NormFloat64(), z, 10 * (z + 0.3*rng.NormFloat64()), rng.NormFloat64()}
+	}
+	return x
+}
+
+func TestCorrelationReducerStrategies(t *testing.T) {
+	x := testCorrelatedFeatures(500, 83)
+	minCorr := 0.8
+	maxIter := 10
+	corrType := "pearson"
+	for strategy, want := range map[string][]int{"correlation": {1, 3}, "pca": {1, 3}, "variance": {2, 3}, "unknown": {1, 3}} {
+		reducer := newCorrelationReducer(&minCorr, &maxIter, &corrType, &strategy)
+		transformed, names := reducer.FitTransform(x, nil)
+		if len(reducer.clusters) != 2 || len(reducer.kept) != 2 || reducer.kept[0] != want[0] || reducer.kept[1] != want[1] {
+			t.Errorf("%s: clusters %v keep %v, want %v", strategy, reducer.clusters, reducer.kept, want)
+			continue
+		}
+		if names[0] != reducer.keptNames[0] || transformed[5][0] != x[5][want[0]] || transformed[5][1] != x[5][3] {
+			t.Errorf("%s: transformed sample %v, want the features %v of %v", strategy, transformed[5], want, x[5])
+		}
+	}
+	// new data with the columns in a different order is transformed by the feature names
+	strategy := "variance"
+	reducer := newCorrelationReducer(&minCorr, &maxIter, &corrType, &strategy)
+	reducer.Fit(x, []string{"a", "b", "c", "d"})
+	reordered := reducer.Transform([][]float64{{4, 3, 2, 1}}, []string{"d", "c", "b", "a"})
+	if reordered[0][0] != 3 || reordered[0][1] != 4 {
+		t.Errorf("transforming by name gives %v, want the columns c and d [3 4]", reordered[0])
+	}
+}
+
+func TestFirstComponentLoadings(t *testing.T) {
+	corrMat := [][]float64{{1, 0.9, 0.2}, {0.9, 1, 0.5}, {0.2, 0.5, 1}}
+	loadings := firstComponentLoadings(corrMat)
+	// the loadings are the unit eigenvector with the largest eigenvalue
+	eigenvalue := 0.0
+	for ci, i := range corrMat {
+		for cj, j := range i {
+			eigenvalue += loadings[ci] * j * loadings[cj]
+		}
+	}
+	norm := 0.0
+	for ci, i := range corrMat {
+		product := 0.0
+		for cj, j := range i {
+			product += j * loadings[cj]
+		}
+		norm += loadings[ci] * loadings[ci]
+		if math.Abs(product-eigenvalue*loadings[ci]) > 1e-8 {
+			t.Errorf("loadings %v aren't an eigenvector of the correlation matrix", loadings)
+		}
+	}
+	// the trace 3 bounds the largest eigenvalue from above and the Rayleigh quotient 1.9 of the first two features from below
+	if math.Abs(norm-1) > 1e-12 || eigenvalue < 1.9 || eigenvalue > 3 || loadings[1] < loadings[0] || loadings[0] < loadings[2] {
+		t.Errorf("loadings %v with the eigenvalue %g, want a unit vector of the largest eigenvalue with feature 1 first", loadings, eigenvalue)
+	}
+}
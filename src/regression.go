@@ -0,0 +1,530 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+/*
+Report of all regression metrics
+*/
+type regressionReport struct {
+	MAE               float64
+	MSE               float64
+	RMSE              float64
+	R2                float64
+	AdjustedR2        float64
+	ExplainedVariance float64
+	MedianAbsoluteErr float64
+	MAPE              float64
+	SMAPE             float64
+	MaxError          float64
+	Huber             float64
+	MeanPoissonDev    float64
+	MeanGammaDeviance float64
+	NumSamples        int
+}
+
+/*
+Check prediction, ground truth and sample weights for equal length and create uniform weights if none are given
+
+	:parameter
+		* prediction: predicted values as returned by a regressor
+		* groundTruth: ground truth (correct) values
+		* weights: weight of each sample - nil to weight all samples equally
+	:return
+		* sampleWeights: the weights of all samples
+		* err: error if the lengths don't match, there are no samples or the weights are invalid
+*/
+func regressionWeights(prediction, groundTruth, weights []float64) ([]float64, error) {
+	pSize := len(prediction)
+	if gTSize := len(groundTruth); pSize != gTSize {
+		return nil, fmt.Errorf("Prediction size [%d] doesn't match the ground truth size [%d]", pSize, gTSize)
+	}
+	if pSize == 0 {
+		return nil, fmt.Errorf("Can't calculate a regression metric without samples")
+	}
+	if weights == nil {
+		sampleWeights := make([]float64, pSize)
+		for i := range sampleWeights {
+			sampleWeights[i] = 1
+		}
+		return sampleWeights, nil
+	}
+	if wSize := len(weights); wSize != pSize {
+		return nil, fmt.Errorf("Number of sample weights [%d] doesn't match the prediction size [%d]", wSize, pSize)
+	}
+	weightSum := 0.0
+	for ci, i := range weights {
+		if i < 0 {
+			return nil, fmt.Errorf("Sample weight [%f] of sample [%d] is negative", i, ci)
+		}
+		weightSum += i
+	}
+	if weightSum == 0 {
+		return nil, fmt.Errorf("Sample weights sum up to 0")
+	}
+	return weights, nil
+}
+
+/*
+Calculate the weighted mean of a per sample loss
+
+	:parameter
+		* prediction: predicted values as returned by a regressor
+		* groundTruth: ground truth (correct) values
+		* weights: weight of each sample - nil to weight all samples equally
+		* loss: loss of a single sample given its prediction and ground truth
+	:return
+		* meanLoss: weighted mean of the losses
+		* err: error if the input is invalid
+*/
+func weightedMeanLoss(prediction, groundTruth, weights []float64, loss func(pred, truth float64) float64) (float64, error) {
+	sampleWeights, err := regressionWeights(prediction, groundTruth, weights)
+	if err != nil {
+		return math.NaN(), err
+	}
+	lossSum := 0.0
+	weightSum := 0.0
+	for ci, i := range prediction {
+		lossSum += sampleWeights[ci] * loss(i, groundTruth[ci])
+		weightSum += sampleWeights[ci]
+	}
+	return lossSum / weightSum, nil
+}
+
+/*
+Calculate the weighted mean absolute error
+
+	:parameter
+		* prediction: predicted values as returned by a regressor
+		* groundTruth: ground truth (correct) values
+		* weights: weight of each sample - nil to weight all samples equally
+	:return
+		* mae: mean absolute error
+		* err: error if the input is invalid
+*/
+func meanAbsoluteError(prediction, groundTruth, weights []float64) (float64, error) {
+	return weightedMeanLoss(prediction, groundTruth, weights, func(pred, truth float64) float64 {
+		return math.Abs(pred - truth)
+	})
+}
+
+/*
+Calculate the weighted mean squared error
+
+	:parameter
+		* prediction: predicted values as returned by a regressor
+		* groundTruth: ground truth (correct) values
+		* weights: weight of each sample - nil to weight all samples equally
+	:return
+		* mse: mean squared error
+		* err: error if the input is invalid
+*/
+func meanSquaredError(prediction, groundTruth, weights []float64) (float64, error) {
+	return weightedMeanLoss(prediction, groundTruth, weights, func(pred, truth float64) float64 {
+		return (pred - truth) * (pred - truth)
+	})
+}
+
+/*
+Calculate the weighted root mean squared error
+
+	:parameter
+		* prediction: predicted values as returned by a regressor
+		* groundTruth: ground truth (correct) values
+		* weights: weight of each sample - nil to weight all samples equally
+	:return
+		* rmse: root mean squared error
+		* err: error if the input is invalid
+*/
+func rootMeanSquaredError(prediction, groundTruth, weights []float64) (float64, error) {
+	mse, err := meanSquaredError(prediction, groundTruth, weights)
+	return math.Sqrt(mse), err
+}
+
+/*
+Calculate the weighted mean and variance
+
+	:parameter
+		* values: the values
+		* weights: weight of each value
+	:return
+		* mean: weighted mean
+		* variance: weighted (population) variance
+*/
+func weightedMeanVariance(values, weights []float64) (float64, float64) {
+	weightSum := 0.0
+	mean := 0.0
+	for ci, i := range values {
+		mean += weights[ci] * i
+		weightSum += weights[ci]
+	}
+	mean /= weightSum
+	variance := 0.0
+	for ci, i := range values {
+		variance += weights[ci] * (i - mean) * (i - mean)
+	}
+	return mean, variance / weightSum
+}
+
+/*
+Calculate the weighted coefficient of determination
+
+	:parameter
+		* prediction: predicted values as returned by a regressor
+		* groundTruth: ground truth (correct) values
+		* weights: weight of each sample - nil to weight all samples equally
+	:return
+		* r2: 1 - residual sum of squares / total sum of squares (1 for a perfect and 0 for an imperfect prediction of a constant ground truth)
+		* err: error if the input is invalid
+*/
+func r2Score(prediction, groundTruth, weights []float64) (float64, error) {
+	sampleWeights, err := regressionWeights(prediction, groundTruth, weights)
+	if err != nil {
+		return math.NaN(), err
+	}
+	mse, _ := meanSquaredError(prediction, groundTruth, sampleWeights)
+	_, variance := weightedMeanVariance(groundTruth, sampleWeights)
+	if variance == 0 {
+		if mse == 0 {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 1 - mse/variance, nil
+}
+
+/*
+Calculate the coefficient of determination adjusted for the number of features used by the model
+
+	:parameter
+		* prediction: predicted values as returned by a regressor
+		* groundTruth: ground truth (correct) values
+		* weights: weight of each sample - nil to weight all samples equally
+		* numFeatures: number of features the model used
+	:return
+		* adjR2: 1 - (1 - r2) * (n - 1) / (n - numFeatures - 1)
+		* err: error if the input is invalid or there are not more samples than features + 1
+*/
+func adjustedR2Score(prediction, groundTruth, weights []float64, numFeatures *int) (float64, error) {
+	r2, err := r2Score(prediction, groundTruth, weights)
+	if err != nil {
+		return math.NaN(), err
+	}
+	n := len(prediction)
+	if n-*numFeatures-1 <= 0 {
+		return math.NaN(), fmt.Errorf("Adjusted R2 needs more than [%d] samples for [%d] features but got [%d]", *numFeatures+1, *numFeatures, n)
+	}
+	return 1 - (1-r2)*float64(n-1)/float64(n-*numFeatures-1), nil
+}
+
+/*
+Calculate the weighted explained variance
+
+	:parameter
+		* prediction: predicted values as returned by a regressor
+		* groundTruth: ground truth (correct) values
+		* weights: weight of each sample - nil to weight all samples equally
+	:return
+		* explVar: 1 - variance of the residuals / variance of the ground truth
+		* err: error if the input is invalid
+*/
+func explainedVariance(prediction, groundTruth, weights []float64) (float64, error) {
+	sampleWeights, err := regressionWeights(prediction, groundTruth, weights)
+	if err != nil {
+		return math.NaN(), err
+	}
+	residuals := make([]float64, len(prediction))
+	for ci, i := range prediction {
+		residuals[ci] = groundTruth[ci] - i
+	}
+	_, residualVariance := weightedMeanVariance(residuals, sampleWeights)
+	_, variance := weightedMeanVariance(groundTruth, sampleWeights)
+	if variance == 0 {
+		if residualVariance == 0 {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 1 - residualVariance/variance, nil
+}
+
+/*
+Calculate the (weighted) median absolute error
+
+	:parameter
+		* prediction: predicted values as returned by a regressor
+		* groundTruth: ground truth (correct) values
+		* weights: weight of each sample - nil for the ordinary median
+	:return
+		* medAE: median of the absolute errors - for weights the smallest error where the cumulative weight reaches half of the total weight
+		* err: error if the input is invalid
+*/
+func medianAbsoluteError(prediction, groundTruth, weights []float64) (float64, error) {
+	sampleWeights, err := regressionWeights(prediction, groundTruth, weights)
+	if err != nil {
+		return math.NaN(), err
+	}
+	absErrors := make([]float64, len(prediction))
+	for ci, i := range prediction {
+		absErrors[ci] = math.Abs(i - groundTruth[ci])
+	}
+	if weights == nil {
+		sort.Float64s(absErrors)
+		return quantileSorted(absErrors, 0.5), nil
+	}
+	order := argsort(absErrors)
+	halfWeight := *sumFloat64(sampleWeights) / 2
+	cumWeight := 0.0
+	for _, i := range order {
+		cumWeight += sampleWeights[i]
+		if cumWeight >= halfWeight {
+			return absErrors[i], nil
+		}
+	}
+	return absErrors[order[len(order)-1]], nil
+}
+
+/*
+Calculate the weighted mean absolute percentage error
+
+	:parameter
+		* prediction: predicted values as returned by a regressor
+		* groundTruth: ground truth (correct) values
+		* weights: weight of each sample - nil to weight all samples equally
+	:return
+		* mape: mean of |truth - pred| / |truth| as fraction (ground truth values of 0 are replaced by the machine epsilon)
+		* err: error if the input is invalid
+*/
+func meanAbsolutePercentageError(prediction, groundTruth, weights []float64) (float64, error) {
+	return weightedMeanLoss(prediction, groundTruth, weights, func(pred, truth float64) float64 {
+		return math.Abs(truth-pred) / math.Max(math.Abs(truth), math.Nextafter(1, 2)-1)
+	})
+}
+
+/*
+Calculate the weighted symmetric mean absolute percentage error
+
+	:parameter
+		* prediction: predicted values as returned by a regressor
+		* groundTruth: ground truth (correct) values
+		* weights: weight of each sample - nil to weight all samples equally
+	:return
+		* smape: mean of 2 * |truth - pred| / (|truth| + |pred|) as fraction between 0 and 2 (0 if truth and pred are 0)
+		* err: error if the input is invalid
+*/
+func symmetricMeanAbsolutePercentageError(prediction, groundTruth, weights []float64) (float64, error) {
+	return weightedMeanLoss(prediction, groundTruth, weights, func(pred, truth float64) float64 {
+		return safeDivide(2*math.Abs(truth-pred), math.Abs(truth)+math.Abs(pred))
+	})
+}
+
+/*
+Calculate the maximum absolute error - weights only need to be valid since they can't change the maximum
+
+	:parameter
+		* prediction: predicted values as returned by a regressor
+		* groundTruth: ground truth (correct) values
+		* weights: weight of each sample - nil to weight all samples equally
+	:return
+		* maxErr: the largest absolute error of a sample with non zero weight
+		* err: error if the input is invalid
+*/
+func maxError(prediction, groundTruth, weights []float64) (float64, error) {
+	sampleWeights, err := regressionWeights(prediction, groundTruth, weights)
+	if err != nil {
+		return math.NaN(), err
+	}
+	maxErr := 0.0
+	for ci, i := range prediction {
+		if sampleWeights[ci] > 0 {
+			maxErr = math.Max(maxErr, math.Abs(i-groundTruth[ci]))
+		}
+	}
+	return maxErr, nil
+}
+
+/*
+Calculate the weighted Huber loss that is quadratic for small and linear for large errors
+
+	:parameter
+		* prediction: predicted values as returned by a regressor
+		* groundTruth: ground truth (correct) values
+		* weights: weight of each sample - nil to weight all samples equally
+		* delta: error size at which the loss changes from quadratic to linear
+	:return
+		* huber: mean Huber loss
+		* err: error if the input is invalid
+*/
+func huberLoss(prediction, groundTruth, weights []float64, delta *float64) (float64, error) {
+	if *delta <= 0 {
+		return math.NaN(), fmt.Errorf("Huber delta [%f] has to be positive", *delta)
+	}
+	return weightedMeanLoss(prediction, groundTruth, weights, func(pred, truth float64) float64 {
+		absErr := math.Abs(truth - pred)
+		if absErr <= *delta {
+			return 0.5 * absErr * absErr
+		}
+		return *delta * (absErr - 0.5**delta)
+	})
+}
+
+/*
+Calculate the weighted mean Poisson deviance
+
+	:parameter
+		* prediction: predicted values as returned by a regressor - have to be positive
+		* groundTruth: ground truth (correct) values - have to be non negative
+		* weights: weight of each sample - nil to weight all samples equally
+	:return
+		* dev: mean of 2 * (truth * log(truth / pred) - truth + pred)
+		* err: error if the input is invalid or outside the domain
+*/
+func meanPoissonDeviance(prediction, groundTruth, weights []float64) (float64, error) {
+	if _, err := regressionWeights(prediction, groundTruth, weights); err != nil {
+		return math.NaN(), err
+	}
+	for ci, i := range prediction {
+		if i <= 0 || groundTruth[ci] < 0 {
+			return math.NaN(), fmt.Errorf("Poisson deviance needs positive predictions and non negative ground truth but got [%f] and [%f] at [%d]", i, groundTruth[ci], ci)
+		}
+	}
+	return weightedMeanLoss(prediction, groundTruth, weights, func(pred, truth float64) float64 {
+		dev := pred - truth
+		if truth > 0 {
+			dev += truth * math.Log(truth/pred)
+		}
+		return 2 * dev
+	})
+}
+
+/*
+Calculate the weighted mean Gamma deviance
+
+	:parameter
+		* prediction: predicted values as returned by a regressor - have to be positive
+		* groundTruth: ground truth (correct) values - have to be positive
+		* weights: weight of each sample - nil to weight all samples equally
+	:return
+		* dev: mean of 2 * (log(pred / truth) + truth / pred - 1)
+		* err: error if the input is invalid or outside the domain
+*/
+func meanGammaDeviance(prediction, groundTruth, weights []float64) (float64, error) {
+	if _, err := regressionWeights(prediction, groundTruth, weights); err != nil {
+		return math.NaN(), err
+	}
+	for ci, i := range prediction {
+		if i <= 0 || groundTruth[ci] <= 0 {
+			return math.NaN(), fmt.Errorf("Gamma deviance needs positive predictions and ground truth but got [%f] and [%f] at [%d]", i, groundTruth[ci], ci)
+		}
+	}
+	return weightedMeanLoss(prediction, groundTruth, weights, func(pred, truth float64) float64 {
+		return 2 * (math.Log(pred/truth) + truth/pred - 1)
+	})
+}
+
+/*
+Calculate all regression metrics - deviances and adjusted R2 that are not defined for the data are NaN
+
+	:parameter
+		* prediction: predicted values as returned by a regressor
+		* groundTruth: ground truth (correct) values
+		* weights: weight of each sample - nil to weight all samples equally
+		* numFeatures: number of features the model used (for the adjusted R2)
+		* huberDelta: error size at which the Huber loss changes from quadratic to linear
+	:return
+		* report: all regression metrics
+		* err: error if the input is invalid
+*/
+func newRegressionReport(prediction, groundTruth, weights []float64, numFeatures *int, huberDelta *float64) (regressionReport, error) {
+	if _, err := regressionWeights(prediction, groundTruth, weights); err != nil {
+		return regressionReport{}, err
+	}
+	report := regressionReport{NumSamples: len(prediction)}
+	// errors can only come from the domain of the metric since the input was already checked
+	var err error
+	report.MAE, _ = meanAbsoluteError(prediction, groundTruth, weights)
+	report.MSE, _ = meanSquaredError(prediction, groundTruth, weights)
+	report.RMSE, _ = rootMeanSquaredError(prediction, groundTruth, weights)
+	report.R2, _ = r2Score(prediction, groundTruth, weights)
+	report.AdjustedR2, _ = adjustedR2Score(prediction, groundTruth, weights, numFeatures)
+	report.ExplainedVariance, _ = explainedVariance(prediction, groundTruth, weights)
+	report.MedianAbsoluteErr, _ = medianAbsoluteError(prediction, groundTruth, weights)
+	report.MAPE, _ = meanAbsolutePercentageError(prediction, groundTruth, weights)
+	report.SMAPE, _ = symmetricMeanAbsolutePercentageError(prediction, groundTruth, weights)
+	report.MaxError, _ = maxError(prediction, groundTruth, weights)
+	if report.Huber, err = huberLoss(prediction, groundTruth, weights, huberDelta); err != nil {
+		return report, err
+	}
+	report.MeanPoissonDev, _ = meanPoissonDeviance(prediction, groundTruth, weights)
+	report.MeanGammaDeviance, _ = meanGammaDeviance(prediction, groundTruth, weights)
+	return report, nil
+}
+
+/*
+Name, JSON key and value of every metric in the report
+*/
+func (r regressionReport) metrics() []struct {
+	name    string
+	jsonKey string
+	value   float64
+} {
+	return []struct {
+		name    string
+		jsonKey string
+		value   float64
+	}{
+		{"mean absolute error", "mae", r.MAE},
+		{"mean squared error", "mse", r.MSE},
+		{"root mean squared error", "rmse", r.RMSE},
+		{"r2", "r2", r.R2},
+		{"adjusted r2", "adjusted_r2", r.AdjustedR2},
+		{"explained variance", "explained_variance", r.ExplainedVariance},
+		{"median absolute error", "median_absolute_error", r.MedianAbsoluteErr},
+		{"mean absolute percentage error", "mape", r.MAPE},
+		{"symmetric mean absolute percentage error", "smape", r.SMAPE},
+		{"max error", "max_error", r.MaxError},
+		{"huber loss", "huber", r.Huber},
+		{"mean poisson deviance", "mean_poisson_deviance", r.MeanPoissonDev},
+		{"mean gamma deviance", "mean_gamma_deviance", r.MeanGammaDeviance},
+	}
+}
+
+/*
+Format the report as text table
+*/
+func (r regressionReport) String() string {
+	builder := &strings.Builder{}
+	writer := tabwriter.NewWriter(builder, 0, 0, 2, ' ', 0)
+	for _, i := range r.metrics() {
+		fmt.Fprintf(writer, "%s\t%.6g\n", i.name, i.value)
+	}
+	fmt.Fprintf(writer, "samples\t%d\n", r.NumSamples)
+	writer.Flush()
+	return builder.String()
+}
+
+/*
+Format the report as indented JSON - metrics that are not defined (NaN) are written as null
+*/
+func (r regressionReport) json() string {
+	values := map[string]interface{}{"num_samples": r.NumSamples}
+	for _, i := range r.metrics() {
+		if math.IsNaN(i.value) || math.IsInf(i.value, 0) {
+			values[i.jsonKey] = nil
+		} else {
+			values[i.jsonKey] = i.value
+		}
+	}
+	jsonReport, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		log.Fatalln("Couldn't convert the regression report to JSON\n", err)
+	}
+	return string(jsonReport)
+}
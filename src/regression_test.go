@@ -0,0 +1,88 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWeightedRegressionMetrics(t *testing.T) {
+	prediction := []float64{1, 2, 3}
+	groundTruth := []float64{2, 2, 5}
+	weights := []float64{1, 2, 1}
+	numFeatures := 1
+	huberDelta := 1.0
+	report, err := newRegressionReport(prediction, groundTruth, weights, &numFeatures, &huberDelta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// weighted ground truth mean (2 + 2 * 2 + 5) / 4 = 2.75 and variance 6.75 / 4
+	for _, i := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"MAE", report.MAE, 3. / 4},
+		{"MSE", report.MSE, 5. / 4},
+		{"RMSE", report.RMSE, math.Sqrt(5. / 4)},
+		{"R2", report.R2, 1 - 1.25/1.6875},
+		{"median absolute error", report.MedianAbsoluteErr, 0},
+		{"max error", report.MaxError, 2},
+		{"Huber", report.Huber, (0.5 + 1.5) / 4},
+		{"MAPE", report.MAPE, (1./2 + 2./5) / 4},
+		{"Poisson deviance", report.MeanPoissonDev, (2*(-1+2*math.Log(2)) + 2*(-2+5*math.Log(5./3))) / 4},
+	} {
+		if math.Abs(i.got-i.want) > 1e-12 {
+			t.Errorf("%s %.16g, want %.16g", i.name, i.got, i.want)
+		}
+	}
+}
+
+func TestWeightsEqualRepeatedSamples(t *testing.T) {
+	// an integer weight has to act like repeating the sample
+	weighted := []func(prediction, groundTruth, weights []float64) (float64, error){
+		meanAbsoluteError, meanSquaredError, rootMeanSquaredError, r2Score, explainedVariance,
+		meanAbsolutePercentageError, symmetricMeanAbsolutePercentageError, maxError, meanPoissonDeviance, meanGammaDeviance,
+	}
+	prediction := []float64{1.5, 2, 4, 3.5}
+	groundTruth := []float64{1, 3, 4.5, 2}
+	weights := []float64{3, 1, 2, 1}
+	repeatedPred, repeatedTruth := []float64{}, []float64{}
+	for ci, i := range weights {
+		for j := 0; j < int(i); j++ {
+			repeatedPred = append(repeatedPred, prediction[ci])
+			repeatedTruth = append(repeatedTruth, groundTruth[ci])
+		}
+	}
+	for ci, metric := range weighted {
+		got, err := metric(prediction, groundTruth, weights)
+		want, _ := metric(repeatedPred, repeatedTruth, nil)
+		if err != nil || math.Abs(got-want) > 1e-12 {
+			t.Errorf("metric %d: weighted %g, repeated %g (%v)", ci, got, want, err)
+		}
+	}
+}
+
+func TestRegressionWeightErrors(t *testing.T) {
+	prediction := []float64{1, 2}
+	groundTruth := []float64{1, 3}
+	for _, i := range []struct {
+		name        string
+		groundTruth []float64
+		weights     []float64
+	}{
+		{"length", []float64{1}, nil},
+		{"weight length", groundTruth, []float64{1}},
+		{"negative weight", groundTruth, []float64{1, -1}},
+		{"zero weights", groundTruth, []float64{0, 0}},
+	} {
+		if _, err := meanAbsoluteError(prediction, i.groundTruth, i.weights); err == nil {
+			t.Errorf("%s: no error", i.name)
+		}
+	}
+	numFeatures := 1
+	if _, err := adjustedR2Score(prediction, groundTruth, nil, &numFeatures); err == nil {
+		t.Errorf("adjusted R2 of 2 samples and 1 feature: no error")
+	}
+	if _, err := meanGammaDeviance([]float64{1, 2}, []float64{0, 1}, nil); err == nil {
+		t.Errorf("gamma deviance of a ground truth of 0: no error")
+	}
+}
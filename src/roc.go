@@ -0,0 +1,382 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+)
+
+// probabilities are clipped to [probabilityClip, 1-probabilityClip] for the log loss
+const probabilityClip = 1e-15
+
+/*
+Receiver operating characteristic curve with one point per distinct threshold
+*/
+type rocCurve struct {
+	fpr        []float64
+	tpr        []float64
+	thresholds []float64
+}
+
+/*
+Precision-recall curve with one point per distinct threshold
+*/
+type prCurve struct {
+	precision  []float64
+	recall     []float64
+	thresholds []float64
+}
+
+/*
+Extract the predicted probability of one class for all samples
+
+	:parameter
+		*	proba: percentages for all classes per sample as returned by kNNClassifier
+		*	class: the class whose probabilities should be extracted
+	:return
+		*	scores: probability of class for every sample (0 if the class is not in the map)
+*/
+func probaOfClass(proba []map[int]float64, class int) []float64 {
+	scores := make([]float64, len(proba))
+	for ci, i := range proba {
+		scores[ci] = i[class]
+	}
+	return scores
+}
+
+/*
+Count true and false positives for every distinct threshold going from the highest to the lowest score
+
+	:parameter
+		*	scores: score of being positive for every sample
+		*	isPositive: whether a sample is actually positive
+	:return
+		*	truePos: number of true positives when everything with a score >= thresholds[i] is called positive
+		*	falsePos: number of false positives when everything with a score >= thresholds[i] is called positive
+		*	thresholds: the distinct scores in decreasing order
+*/
+func thresholdCounts(scores []float64, isPositive []bool) ([]float64, []float64, []float64) {
+	if sSize, pSize := len(scores), len(isPositive); sSize != pSize {
+		log.Fatalln(fmt.Sprintf("Number of scores [%d] doesn't match the number of labels [%d]", sSize, pSize))
+	}
+	if len(scores) == 0 {
+		log.Fatalln("Curves need at least one sample")
+	}
+	order := argsort(scores)
+	truePos := []float64{}
+	falsePos := []float64{}
+	thresholds := []float64{}
+	tp, fp := 0.0, 0.0
+	for i := len(order) - 1; i >= 0; i-- {
+		if isPositive[order[i]] {
+			tp++
+		} else {
+			fp++
+		}
+		// only emit a point after the last sample with the same score
+		if i == 0 || scores[order[i-1]] != scores[order[i]] {
+			truePos = append(truePos, tp)
+			falsePos = append(falsePos, fp)
+			thresholds = append(thresholds, scores[order[i]])
+		}
+	}
+	return truePos, falsePos, thresholds
+}
+
+/*
+Calculate the ROC curve for a binary problem
+
+	:parameter
+		*	scores: score of being positive for every sample
+		*	isPositive: whether a sample is actually positive
+	:return
+		*	curve: false and true positive rates starting at (0, 0) for an infinite threshold
+*/
+func newROCCurve(scores []float64, isPositive []bool) rocCurve {
+	truePos, falsePos, thresholds := thresholdCounts(scores, isPositive)
+	numPos := truePos[len(truePos)-1]
+	numNeg := falsePos[len(falsePos)-1]
+	if numPos == 0 || numNeg == 0 {
+		log.Fatalln(fmt.Sprintf("ROC curve is not defined with [%d] positive and [%d] negative samples", int(numPos), int(numNeg)))
+	}
+	curve := rocCurve{fpr: []float64{0}, tpr: []float64{0}, thresholds: []float64{math.Inf(1)}}
+	for ci := range thresholds {
+		curve.fpr = append(curve.fpr, falsePos[ci]/numNeg)
+		curve.tpr = append(curve.tpr, truePos[ci]/numPos)
+		curve.thresholds = append(curve.thresholds, thresholds[ci])
+	}
+	return curve
+}
+
+/*
+Calculate the precision-recall curve for a binary problem
+
+	:parameter
+		*	scores: score of being positive for every sample
+		*	isPositive: whether a sample is actually positive
+	:return
+		*	curve: precision and recall starting at a recall of 0 and a precision of 1 for an infinite threshold
+*/
+func newPRCurve(scores []float64, isPositive []bool) prCurve {
+	truePos, falsePos, thresholds := thresholdCounts(scores, isPositive)
+	numPos := truePos[len(truePos)-1]
+	numNeg := falsePos[len(falsePos)-1]
+	if numPos == 0 || numNeg == 0 {
+		log.Fatalln(fmt.Sprintf("Precision-recall curve is not defined with [%d] positive and [%d] negative samples", int(numPos), int(numNeg)))
+	}
+	curve := prCurve{precision: []float64{1}, recall: []float64{0}, thresholds: []float64{math.Inf(1)}}
+	for ci := range thresholds {
+		curve.precision = append(curve.precision, truePos[ci]/(truePos[ci]+falsePos[ci]))
+		curve.recall = append(curve.recall, truePos[ci]/numPos)
+		curve.thresholds = append(curve.thresholds, thresholds[ci])
+	}
+	return curve
+}
+
+/*
+Calculate the area under a curve with the trapezoidal rule
+
+	:parameter
+		*	x, y: coordinates of the points of the curve
+	:return
+		*	area: area under the curve
+*/
+func trapezoidArea(x, y []float64) float64 {
+	assertEqualLengthFloat(x, y)
+	area := 0.0
+	for i := 1; i < len(x); i++ {
+		area += (x[i] - x[i-1]) * (y[i] + y[i-1]) / 2
+	}
+	return math.Abs(area)
+}
+
+/*
+Area under the ROC curve
+*/
+func (c rocCurve) auc() float64 {
+	return trapezoidArea(c.fpr, c.tpr)
+}
+
+/*
+Average precision as the precision at each threshold weighted by the increase in recall
+*/
+func (c prCurve) averagePrecision() float64 {
+	ap := 0.0
+	for i := 1; i < len(c.recall); i++ {
+		ap += (c.recall[i] - c.recall[i-1]) * c.precision[i]
+	}
+	return ap
+}
+
+/*
+Write columns of equal length with a header to a csv file
+
+	:parameter
+		*	filePath: path of the csv file to be created
+		*	header: name of each column
+		*	columns: the values of each column
+	:return
+		None
+*/
+func writeColumnsCSV(filePath *string, header []string, columns ...[]float64) {
+	file, err := os.Create(*filePath)
+	if err != nil {
+		log.Fatalln(fmt.Sprintf("Couldn't create file at [%s]\n", *filePath), err)
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	writer.Write(header)
+	for i := range columns[0] {
+		line := make([]string, len(columns))
+		for cj, j := range columns {
+			line[cj] = strconv.FormatFloat(j[i], 'g', -1, 64)
+		}
+		writer.Write(line)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Fatalln(fmt.Sprintf("Couldn't write to csv at [%s]\n", *filePath), err)
+	}
+}
+
+/*
+Export the curve points as csv with the columns threshold, fpr and tpr
+*/
+func (c rocCurve) writeCSV(filePath *string) {
+	writeColumnsCSV(filePath, []string{"threshold", "fpr", "tpr"}, c.thresholds, c.fpr, c.tpr)
+}
+
+/*
+Export the curve points as csv with the columns threshold, recall and precision
+*/
+func (c prCurve) writeCSV(filePath *string) {
+	writeColumnsCSV(filePath, []string{"threshold", "recall", "precision"}, c.thresholds, c.recall, c.precision)
+}
+
+/*
+Mark the samples that belong to the positive class
+
+	:parameter
+		*	groundTruth: ground truth (correct) labels
+		*	positive: the positive class
+	:return
+		*	isPositive: true for all samples of the positive class
+*/
+func isClass(groundTruth []int, positive int) []bool {
+	isPositive := make([]bool, len(groundTruth))
+	for ci, i := range groundTruth {
+		isPositive[ci] = i == positive
+	}
+	return isPositive
+}
+
+/*
+Calculate the ROC curve of a binary problem from the class percentages of a classifier
+
+	:parameter
+		*	proba: percentages for all classes per sample as returned by kNNClassifier
+		*	groundTruth: ground truth (correct) labels
+		*	positive: the class that is regarded as positive
+	:return
+		*	curve: the ROC curve of the positive class
+*/
+func binaryROC(proba []map[int]float64, groundTruth []int, positive *int) rocCurve {
+	return newROCCurve(probaOfClass(proba, *positive), isClass(groundTruth, *positive))
+}
+
+/*
+Calculate the precision-recall curve of a binary problem from the class percentages of a classifier
+
+	:parameter
+		*	proba: percentages for all classes per sample as returned by kNNClassifier
+		*	groundTruth: ground truth (correct) labels
+		*	positive: the class that is regarded as positive
+	:return
+		*	curve: the precision-recall curve of the positive class
+*/
+func binaryPR(proba []map[int]float64, groundTruth []int, positive *int) prCurve {
+	return newPRCurve(probaOfClass(proba, *positive), isClass(groundTruth, *positive))
+}
+
+/*
+Calculate the one-vs-rest ROC AUC of every class and their macro and support weighted average
+
+	:parameter
+		*	proba: percentages for all classes per sample as returned by kNNClassifier
+		*	groundTruth: ground truth (correct) labels
+	:return
+		*	classAUC: AUC of every class against all others
+		*	macroAUC: unweighted mean of the class AUCs
+		*	weightedAUC: mean of the class AUCs weighted by the number of samples per class
+*/
+func oneVsRestAUC(proba []map[int]float64, groundTruth []int) (map[int]float64, float64, float64) {
+	support := make(map[int]float64)
+	for _, i := range groundTruth {
+		support[i]++
+	}
+	classes := uniqueInts(groundTruth)
+	classAUC := make(map[int]float64, len(classes))
+	macroAUC := 0.0
+	weightedAUC := 0.0
+	for _, class := range classes {
+		classAUC[class] = binaryROC(proba, groundTruth, &class).auc()
+		macroAUC += classAUC[class] / float64(len(classes))
+		weightedAUC += classAUC[class] * support[class] / float64(len(groundTruth))
+	}
+	return classAUC, macroAUC, weightedAUC
+}
+
+/*
+Calculate the average precision of every class against all others and their macro average
+
+	:parameter
+		*	proba: percentages for all classes per sample as returned by kNNClassifier
+		*	groundTruth: ground truth (correct) labels
+	:return
+		*	classAP: average precision of every class against all others
+		*	macroAP: unweighted mean of the class average precisions
+*/
+func oneVsRestAveragePrecision(proba []map[int]float64, groundTruth []int) (map[int]float64, float64) {
+	classes := uniqueInts(groundTruth)
+	classAP := make(map[int]float64, len(classes))
+	macroAP := 0.0
+	for _, class := range classes {
+		classAP[class] = binaryPR(proba, groundTruth, &class).averagePrecision()
+		macroAP += classAP[class] / float64(len(classes))
+	}
+	return classAP, macroAP
+}
+
+/*
+Calculate the log loss (cross-entropy) of the predicted class percentages
+
+	:parameter
+		*	proba: percentages for all classes per sample as returned by kNNClassifier
+		*	groundTruth: ground truth (correct) labels
+	:return
+		*	loss: mean negative log probability of the true class
+*/
+func logLoss(proba []map[int]float64, groundTruth []int) float64 {
+	if pSize, gTSize := len(proba), len(groundTruth); pSize != gTSize {
+		log.Fatalln(fmt.Sprintf("Prediction size [%d] doesn't match the ground truth size [%d]", pSize, gTSize))
+	}
+	loss := 0.0
+	for ci, i := range proba {
+		p := math.Min(math.Max(i[groundTruth[ci]], probabilityClip), 1-probabilityClip)
+		loss -= math.Log(p)
+	}
+	return loss / float64(len(proba))
+}
+
+/*
+Calculate the Brier score of a binary problem
+
+	:parameter
+		*	proba: percentages for all classes per sample as returned by kNNClassifier
+		*	groundTruth: ground truth (correct) labels
+		*	positive: the class that is regarded as positive
+	:return
+		*	score: mean squared difference between the probability of the positive class and the outcome
+*/
+func brierScore(proba []map[int]float64, groundTruth []int, positive *int) float64 {
+	if pSize, gTSize := len(proba), len(groundTruth); pSize != gTSize {
+		log.Fatalln(fmt.Sprintf("Prediction size [%d] doesn't match the ground truth size [%d]", pSize, gTSize))
+	}
+	score := 0.0
+	for ci, i := range proba {
+		outcome := 0.0
+		if groundTruth[ci] == *positive {
+			outcome = 1
+		}
+		score += math.Pow(i[*positive]-outcome, 2)
+	}
+	return score / float64(len(proba))
+}
+
+/*
+Calculate the multiclass Brier score summed over all classes
+
+	:parameter
+		*	proba: percentages for all classes per sample as returned by kNNClassifier
+		*	groundTruth: ground truth (correct) labels
+	:return
+		*	score: mean over samples of the squared differences between class percentages and the one-hot outcome
+*/
+func multiclassBrierScore(proba []map[int]float64, groundTruth []int) float64 {
+	if pSize, gTSize := len(proba), len(groundTruth); pSize != gTSize {
+		log.Fatalln(fmt.Sprintf("Prediction size [%d] doesn't match the ground truth size [%d]", pSize, gTSize))
+	}
+	score := 0.0
+	for ci, i := range proba {
+		for class, p := range i {
+			if class != groundTruth[ci] {
+				score += p * p
+			}
+		}
+		score += math.Pow(1-i[groundTruth[ci]], 2)
+	}
+	return score / float64(len(proba))
+}
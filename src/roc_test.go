@@ -0,0 +1,49 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestROCAndPRDegenerateScores(t *testing.T) {
+	isPositive := []bool{true, false, true, false, false, true}
+	for _, i := range []struct {
+		name   string
+		scores []float64
+		auc    float64
+		ap     float64
+	}{
+		// all scores tied - a single threshold that calls everything positive
+		{"tied", []float64{0.5, 0.5, 0.5, 0.5, 0.5, 0.5}, 0.5, 0.5},
+		{"perfect", []float64{0.9, 0.1, 0.8, 0.2, 0.3, 0.7}, 1, 1},
+		{"inverted", []float64{0.1, 0.9, 0.2, 0.8, 0.7, 0.3}, 0, (1./4 + 2./5 + 3./6) / 3},
+		// kNN vote fractions with ties between classes
+		{"partly tied", []float64{1, 0, 0.5, 0.5, 0, 1}, 0.9444444444444444, 0.9166666666666666},
+	} {
+		roc := newROCCurve(i.scores, isPositive)
+		pr := newPRCurve(i.scores, isPositive)
+		if got := roc.auc(); math.Abs(got-i.auc) > 1e-12 {
+			t.Errorf("%s: AUC %.16g, want %.16g", i.name, got, i.auc)
+		}
+		if got := pr.averagePrecision(); math.Abs(got-i.ap) > 1e-12 {
+			t.Errorf("%s: average precision %.16g, want %.16g", i.name, got, i.ap)
+		}
+		if roc.fpr[len(roc.fpr)-1] != 1 || roc.tpr[len(roc.tpr)-1] != 1 || pr.recall[len(pr.recall)-1] != 1 {
+			t.Errorf("%s: curves don't end at a rate of 1", i.name)
+		}
+	}
+}
+
+func TestOneVsRestAUC(t *testing.T) {
+	proba := []map[int]float64{{0: 1}, {1: 0.6, 2: 0.4}, {2: 1}, {0: 0.5, 1: 0.5}, {1: 1}, {2: 0.6, 0: 0.4}}
+	groundTruth := []int{0, 1, 2, 0, 1, 2}
+	classAUC, macroAUC, weightedAUC := oneVsRestAUC(proba, groundTruth)
+	for class, auc := range classAUC {
+		if auc != 1 {
+			t.Errorf("AUC of class [%d] is %g, want 1", class, auc)
+		}
+	}
+	if macroAUC != 1 || math.Abs(weightedAUC-1) > 1e-12 {
+		t.Errorf("macro AUC %g and weighted AUC %g, want 1", macroAUC, weightedAUC)
+	}
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+/*
+Parameters of a kNN model
+*/
+type kNNParams struct {
+	k          int
+	distType   string
+	scaleDist  bool
+	scalerType string
+}
+
+/*
+Values of the kNN parameters that should be searched
+*/
+type kNNParamSpace struct {
+	k          []int
+	distType   []string
+	scaleDist  []bool
+	scalerType []string
+}
+
+/*
+Cross-validation result of one parameter combination and its rank (1 is the best)
+*/
+type searchResult struct {
+	params kNNParams
+	cv     cvResult
+	rank   int
+}
+
+/*
+All combinations of the parameters in the space
+
+	:parameter
+		*	space: the values of each parameter
+	:return
+		*	candidates: every combination of the parameter values
+*/
+func (space kNNParamSpace) grid() []kNNParams {
+	candidates := []kNNParams{}
+	for _, scalerType := range space.scalerType {
+		for _, distType := range space.distType {
+			for _, scaleDist := range space.scaleDist {
+				for _, k := range space.k {
+					candidates = append(candidates, kNNParams{k: k, distType: distType, scaleDist: scaleDist, scalerType: scalerType})
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+/*
+Randomly sample distinct combinations of the parameters in the space
+
+	:parameter
+		*	space: the values of each parameter
+		*	nIter: number of combinations to sample
+	:return
+		*	candidates: nIter (or all if there are less) random combinations of the parameter values
+*/
+func (space kNNParamSpace) sample(nIter *int) []kNNParams {
+	candidates := space.grid()
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	if *nIter < len(candidates) {
+		candidates = candidates[:*nIter]
+	}
+	return candidates
+}
+
+/*
+Cross-validate all parameter candidates of a kNN model where the distances of every test sample are only computed
+once per distance metric and scaler and then shared between all k and distance weightings
+
+	:parameter
+		*	x: vectors representing the data
+		*	y: labels of the data
+		*	candidates: the parameter combinations to be evaluated
+		*	folds: the folds used for the cross-validation
+		*	metric: function scoring a prediction against the ground truth
+		*	vote: function predicting the label of a sample from the sorted distances to the training samples
+		*	greaterIsBetter: true if a higher score of the metric is better
+	:return
+		*	results: cross-validation result of each candidate sorted from best to worst
+*/
+func kNNSearch[T label](x [][]float64, y []T, candidates []kNNParams, folds []fold, metric func(prediction, groundTruth []T) float64, vote func(y []T, dists []float64, sortedDistIdx []int, k *int, scaleDist *bool) T, greaterIsBetter *bool) []searchResult {
+	if len(candidates) == 0 {
+		log.Fatalln("No parameter candidates to search")
+	}
+	// record the distance metric and scaler that are actually used
+	candidates = append([]kNNParams{}, candidates...)
+	for ci, i := range candidates {
+		if _, ok := distanceFunctions[i.distType]; !ok {
+			selectDistanceFunction(&i.distType)
+			candidates[ci].distType = "euclidean"
+		}
+		if selectScaler(&i.scalerType) == nil {
+			candidates[ci].scalerType = "none"
+		}
+	}
+	// group the candidates that share the same distances
+	type distKey struct {
+		distType   string
+		scalerType string
+	}
+	groups := make(map[distKey][]int)
+	groupOrder := []distKey{}
+	for ci, i := range candidates {
+		key := distKey{distType: i.distType, scalerType: i.scalerType}
+		if _, ok := groups[key]; !ok {
+			groupOrder = append(groupOrder, key)
+		}
+		groups[key] = append(groups[key], ci)
+	}
+	scores := make([][]float64, len(candidates))
+	for i := range scores {
+		scores[i] = make([]float64, len(folds))
+	}
+	for cf, f := range folds {
+		trainX, trainY := subsetRows(x, f.trainIdx), subsetRows(y, f.trainIdx)
+		testX, testY := subsetRows(x, f.testIdx), subsetRows(y, f.testIdx)
+		for _, key := range groupOrder {
+			members := groups[key]
+			groupTrainX, groupTestX := trainX, testX
+			if newScaler := selectScaler(&key.scalerType); newScaler != nil {
+				groupTrainX, groupTestX = copyFeatures(trainX), copyFeatures(testX)
+				scaler := newScaler(groupTrainX)
+				scaler(groupTrainX)
+				scaler(groupTestX)
+			}
+			distanceFunction := selectDistanceFunction(&key.distType)
+			// predictions of each candidate in the group for the test samples
+			preds := make([][]T, len(members))
+			for i := range preds {
+				preds[i] = make([]T, len(testX))
+			}
+			parallelFor(len(groupTestX), func(i int) {
+				dists := distanceFunction(groupTrainX, groupTestX[i])
+				sortedDistIdx := argsort(dists)
+				for cj, j := range members {
+					preds[cj][i] = vote(trainY, dists, sortedDistIdx, &candidates[j].k, &candidates[j].scaleDist)
+				}
+			})
+			for cj, j := range members {
+				scores[j][cf] = metric(preds[cj], testY)
+			}
+		}
+	}
+	results := make([]searchResult, len(candidates))
+	for ci, i := range candidates {
+		results[ci] = searchResult{params: i, cv: newCVResult(scores[ci])}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if *greaterIsBetter {
+			return results[i].cv.mean > results[j].cv.mean
+		}
+		return results[i].cv.mean < results[j].cv.mean
+	})
+	for i := range results {
+		results[i].rank = i + 1
+	}
+	return results
+}
+
+/*
+Vote of a kNN classifier used by kNNSearch
+*/
+func kNNClassifierSearchVote(y []int, dists []float64, sortedDistIdx []int, k *int, scaleDist *bool) int {
+	return argmaxClass(kNNClassVote(y, dists, sortedDistIdx, k, scaleDist, -1))
+}
+
+/*
+Vote of a kNN regressor used by kNNSearch
+*/
+func kNNRegressorSearchVote(y []float64, dists []float64, sortedDistIdx []int, k *int, scaleDist *bool) float64 {
+	return kNNRegressionVote(y, dists, sortedDistIdx, k, scaleDist, -1)
+}
+
+/*
+Cross-validate the given kNN classifier parameter candidates and refit the best one on all data
+
+	:parameter
+		*	x: vectors representing the data
+		*	y: classes of the data
+		*	candidates: the parameter combinations to be evaluated
+		*	folds: the folds used for the cross-validation
+		*	metric: function scoring a prediction against the ground truth like multiclassAccuracy
+		*	greaterIsBetter: true if a higher score of the metric is better
+	:return
+		*	results: cross-validation result of each candidate sorted from best to worst
+		*	best: model with the best parameters fitted on x and y
+*/
+func kNNClassifierSearch(x [][]float64, y []int, candidates []kNNParams, folds []fold, metric func(prediction, groundTruth []int) float64, greaterIsBetter *bool) ([]searchResult, *kNNClassifierModel) {
+	results := kNNSearch(x, y, candidates, folds, metric, kNNClassifierSearchVote, greaterIsBetter)
+	bestParams := results[0].params
+	best := newKNNClassifierModel(&bestParams.k, &bestParams.distType, &bestParams.scaleDist, &bestParams.scalerType)
+	best.Fit(x, y)
+	return results, best
+}
+
+/*
+Cross-validate the given kNN regressor parameter candidates and refit the best one on all data
+
+	:parameter
+		*	x: vectors representing the data
+		*	y: values of the data
+		*	candidates: the parameter combinations to be evaluated
+		*	folds: the folds used for the cross-validation
+		*	metric: function scoring a prediction against the ground truth
+		*	greaterIsBetter: true if a higher score of the metric is better
+	:return
+		*	results: cross-validation result of each candidate sorted from best to worst
+		*	best: model with the best parameters fitted on x and y
+*/
+func kNNRegressorSearch(x [][]float64, y []float64, candidates []kNNParams, folds []fold, metric func(prediction, groundTruth []float64) float64, greaterIsBetter *bool) ([]searchResult, *kNNRegressorModel) {
+	results := kNNSearch(x, y, candidates, folds, metric, kNNRegressorSearchVote, greaterIsBetter)
+	bestParams := results[0].params
+	best := newKNNRegressorModel(&bestParams.k, &bestParams.distType, &bestParams.scaleDist, &bestParams.scalerType)
+	best.Fit(x, y)
+	return results, best
+}
+
+/*
+Grid search over all parameter combinations of a kNN classifier
+
+	:parameter
+		*	x: vectors representing the data
+		*	y: classes of the data
+		*	space: the values of each parameter
+		*	folds: the folds used for the cross-validation
+		*	metric: function scoring a prediction against the ground truth like multiclassAccuracy
+		*	greaterIsBetter: true if a higher score of the metric is better
+	:return
+		*	results: cross-validation result of each combination sorted from best to worst
+		*	best: model with the best parameters fitted on x and y
+*/
+func kNNClassifierGridSearch(x [][]float64, y []int, space kNNParamSpace, folds []fold, metric func(prediction, groundTruth []int) float64, greaterIsBetter *bool) ([]searchResult, *kNNClassifierModel) {
+	return kNNClassifierSearch(x, y, space.grid(), folds, metric, greaterIsBetter)
+}
+
+/*
+Random search over nIter parameter combinations of a kNN classifier
+
+	:parameter
+		*	x: vectors representing the data
+		*	y: classes of the data
+		*	space: the values of each parameter
+		*	nIter: number of parameter combinations to evaluate
+		*	folds: the folds used for the cross-validation
+		*	metric: function scoring a prediction against the ground truth like multiclassAccuracy
+		*	greaterIsBetter: true if a higher score of the metric is better
+	:return
+		*	results: cross-validation result of each combination sorted from best to worst
+		*	best: model with the best parameters fitted on x and y
+*/
+func kNNClassifierRandomSearch(x [][]float64, y []int, space kNNParamSpace, nIter *int, folds []fold, metric func(prediction, groundTruth []int) float64, greaterIsBetter *bool) ([]searchResult, *kNNClassifierModel) {
+	return kNNClassifierSearch(x, y, space.sample(nIter), folds, metric, greaterIsBetter)
+}
+
+/*
+Grid search over all parameter combinations of a kNN regressor
+
+	:parameter
+		*	x: vectors representing the data
+		*	y: values of the data
+		*	space: the values of each parameter
+		*	folds: the folds used for the cross-validation
+		*	metric: function scoring a prediction against the ground truth
+		*	greaterIsBetter: true if a higher score of the metric is better
+	:return
+		*	results: cross-validation result of each combination sorted from best to worst
+		*	best: model with the best parameters fitted on x and y
+*/
+func kNNRegressorGridSearch(x [][]float64, y []float64, space kNNParamSpace, folds []fold, metric func(prediction, groundTruth []float64) float64, greaterIsBetter *bool) ([]searchResult, *kNNRegressorModel) {
+	return kNNRegressorSearch(x, y, space.grid(), folds, metric, greaterIsBetter)
+}
+
+/*
+Random search over nIter parameter combinations of a kNN regressor
+
+	:parameter
+		*	x: vectors representing the data
+		*	y: values of the data
+		*	space: the values of each parameter
+		*	nIter: number of parameter combinations to evaluate
+		*	folds: the folds used for the cross-validation
+		*	metric: function scoring a prediction against the ground truth
+		*	greaterIsBetter: true if a higher score of the metric is better
+	:return
+		*	results: cross-validation result of each combination sorted from best to worst
+		*	best: model with the best parameters fitted on x and y
+*/
+func kNNRegressorRandomSearch(x [][]float64, y []float64, space kNNParamSpace, nIter *int, folds []fold, metric func(prediction, groundTruth []float64) float64, greaterIsBetter *bool) ([]searchResult, *kNNRegressorModel) {
+	return kNNRegressorSearch(x, y, space.sample(nIter), folds, metric, greaterIsBetter)
+}
+
+/*
+Print the search results as a table
+
+	:parameter
+		*	results: the results as returned by one of the searches
+	:return
+		None
+*/
+func printSearchResults(results []searchResult) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "rank\tk\tdistType\tscaleDist\tscalerType\tmean\tstd")
+	for _, i := range results {
+		fmt.Fprintf(writer, "%d\t%d\t%s\t%t\t%s\t%.4f\t%.4f\n", i.rank, i.params.k, i.params.distType, i.params.scaleDist, i.params.scalerType, i.cv.mean, i.cv.std)
+	}
+	writer.Flush()
+}
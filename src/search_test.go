@@ -0,0 +1,117 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+/*
+Two classes that only differ in the first feature and a second feature of pure noise on a much larger scale
+*/
+func testScaledNoise(n int, seed int64) ([][]float64, []int) {
+	rng := rand.New(rand.NewSource(seed))
+	x := make([][]float64, n)
+	y := make([]int, n)
+	for i := range x {
+		y[i] = i % 2
+		x[i] = []float64{float64(y[i]) + rng.NormFloat64()*0.2, rng.NormFloat64() * 100}
+	}
+	return x, y
+}
+
+func TestKNNGridSearchMatchesCrossValidate(t *testing.T) {
+	x, y := testScaledNoise(60, 73)
+	space := kNNParamSpace{k: []int{1, 5}, distType: []string{"euclidean", "manhattan"}, scaleDist: []bool{false, true}, scalerType: []string{"none", "minmax", "standard"}}
+	k := 5
+	shuffle := true
+	folds := stratifiedKFold(y, &k, &shuffle)
+	greaterIsBetter := true
+	results, best := kNNClassifierGridSearch(x, y, space, folds, multiclassAccuracy, &greaterIsBetter)
+	if len(results) != 24 {
+		t.Fatalf("%d results, want 24", len(results))
+	}
+	// the shared distances have to give the same scores as fitting every candidate on its own
+	for ci, i := range results {
+		model := newKNNClassifierModel(&i.params.k, &i.params.distType, &i.params.scaleDist, &i.params.scalerType)
+		if want := crossValidate[int](model, x, y, folds, multiclassAccuracy); math.Abs(i.cv.mean-want.mean) > 1e-12 {
+			t.Errorf("%+v: search score %g, cross-validation gives %g", i.params, i.cv.mean, want.mean)
+		}
+		if i.rank != ci+1 || (ci > 0 && i.cv.mean > results[ci-1].cv.mean) {
+			t.Errorf("result %d with rank %d and score %g is out of order", ci, i.rank, i.cv.mean)
+		}
+	}
+	// only scaling removes the noise feature that dominates the distances
+	if results[0].params.scalerType == "none" || results[0].cv.mean < 0.9 {
+		t.Errorf("best candidate %+v with accuracy %g, want a scaler and an accuracy of at least 0.9", results[0].params, results[0].cv.mean)
+	}
+	if best.k != results[0].params.k || best.scalerType != results[0].params.scalerType || best.distType != results[0].params.distType {
+		t.Errorf("refitted model has k %d, %s and %s, want %+v", best.k, best.distType, best.scalerType, results[0].params)
+	}
+}
+
+func TestKNNSearchConstantFoldFeature(t *testing.T) {
+	// a feature that is constant in the training part of a fold must not turn the min max distances into NaN
+	x, y := testScaledNoise(20, 79)
+	flagged := make([][]float64, len(x))
+	for ci, i := range x {
+		flag := 0.0
+		if ci == 0 {
+			flag = 1
+		}
+		flagged[ci] = append(append([]float64{}, i...), flag)
+	}
+	candidates := []kNNParams{{k: 3, distType: "euclidean", scalerType: "minmax"}}
+	k := 4
+	shuffle := false
+	folds := kFold(len(x), &k, &shuffle)
+	greaterIsBetter := true
+	want, _ := kNNClassifierSearch(x, y, candidates, folds, multiclassAccuracy, &greaterIsBetter)
+	got, _ := kNNClassifierSearch(flagged, y, candidates, folds, multiclassAccuracy, &greaterIsBetter)
+	// the constant feature adds the same distance to every training sample
+	for ci, i := range want[0].cv.foldScores {
+		if got[0].cv.foldScores[ci] != i {
+			t.Errorf("fold scores %v with a feature constant in the training part, want %v", got[0].cv.foldScores, want[0].cv.foldScores)
+			break
+		}
+	}
+	scaled := [][]float64{{1, 0}, {1, 1}}
+	minMaxScaler(scaled)(scaled)
+	if scaled[0][0] != 0 || scaled[1][0] != 0 || scaled[1][1] != 1 {
+		t.Errorf("min max scaling of a constant column gives %v, want [[0 0] [0 1]]", scaled)
+	}
+}
+
+func TestKNNSearchNormalizesParameters(t *testing.T) {
+	x, y := testScaledNoise(20, 83)
+	candidates := []kNNParams{{k: 1, distType: "chebyshev", scalerType: "robust"}}
+	k := 2
+	shuffle := false
+	greaterIsBetter := true
+	results := kNNSearch(x, y, candidates, kFold(len(x), &k, &shuffle), multiclassAccuracy, kNNClassifierSearchVote, &greaterIsBetter)
+	if got := results[0].params; got.distType != "euclidean" || got.scalerType != "none" {
+		t.Errorf("recorded %s and %s, want euclidean and none", got.distType, got.scalerType)
+	}
+	if candidates[0].distType != "chebyshev" {
+		t.Errorf("search changed the candidates of the caller")
+	}
+}
+
+func TestLeaveOneOutScalerPerFold(t *testing.T) {
+	// the scaler of a leave-one-out prediction must not see the left out sample
+	x, y := testScaledNoise(30, 89)
+	k := 1
+	distType := "euclidean"
+	scaleDist := false
+	for _, scalerType := range []string{"minmax", "standard"} {
+		model := newKNNClassifierModel(&k, &distType, &scaleDist, &scalerType)
+		fast := model.leaveOneOutPredict(x, y)
+		for _, f := range leaveOneOut(len(x)) {
+			refit := newKNNClassifierModel(&k, &distType, &scaleDist, &scalerType)
+			refit.Fit(subsetRows(x, f.trainIdx), subsetRows(y, f.trainIdx))
+			if want := refit.Predict(subsetRows(x, f.testIdx))[0]; fast[f.testIdx[0]] != want {
+				t.Errorf("%s: leave-one-out prediction of sample %d is %d, refitting gives %d", scalerType, f.testIdx[0], fast[f.testIdx[0]], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,488 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+/*
+Variance of every feature
+
+	:parameter
+		*	x: vectors representing the data
+	:return
+		*	scores: population variance of every feature
+*/
+func varianceScores(x [][]float64) []float64 {
+	scores := make([]float64, len(x[0]))
+	for ci, i := range columnMoments(x) {
+		scores[ci] = i.variance(0)
+	}
+	return scores
+}
+
+/*
+ANOVA F statistic of every feature between the classes
+
+	:parameter
+		*	x: vectors representing the data
+		*	y: classes of the data
+	:return
+		*	scores: F statistic of every feature - 0 for constant features and +Inf for features that are constant within all classes but differ between them
+*/
+func anovaFScores(x [][]float64, y []int) []float64 {
+	testType := "anova"
+	results := featureClassTests(x, y, &testType)
+	scores := make([]float64, len(results))
+	for ci, i := range results {
+		scores[ci] = i.statistic
+	}
+	return scores
+}
+
+/*
+Chi-square statistic of every non negative feature (e.g. counts or frequencies) between the classes - the sum of a feature per class is compared with the sum expected from the class frequencies
+
+	:parameter
+		*	x: vectors representing the data with non negative features
+		*	y: classes of the data
+	:return
+		*	scores: chi-square statistic of every feature
+*/
+func chiSquareScores(x [][]float64, y []int) []float64 {
+	if len(x) != len(y) {
+		log.Fatalln(fmt.Sprintf("Size of x [%d] not equal to size of y [%d]", len(x), len(y)))
+	}
+	classes := uniqueInts(y)
+	classIdx := make(map[int]int)
+	for ci, i := range classes {
+		classIdx[i] = ci
+	}
+	numFeatures := len(x[0])
+	// observed sum of every feature per class
+	observed := make([][]float64, len(classes))
+	for i := range observed {
+		observed[i] = make([]float64, numFeatures)
+	}
+	classCounts := make([]float64, len(classes))
+	featureSums := make([]float64, numFeatures)
+	for ci, i := range x {
+		classCounts[classIdx[y[ci]]]++
+		for cj, j := range i {
+			if j < 0 {
+				log.Fatalln(fmt.Sprintf("Chi-square scores need non negative features but feature [%d] of sample [%d] is [%f]", cj, ci, j))
+			}
+			observed[classIdx[y[ci]]][cj] += j
+			featureSums[cj] += j
+		}
+	}
+	scores := make([]float64, numFeatures)
+	for j := 0; j < numFeatures; j++ {
+		for ci, i := range observed {
+			expected := classCounts[ci] / float64(len(y)) * featureSums[j]
+			if expected > 0 {
+				scores[j] += (i[j] - expected) * (i[j] - expected) / expected
+			}
+		}
+	}
+	return scores
+}
+
+/*
+Standardize a feature and add tiny noise to break ties as needed by the nearest neighbor estimators of the mutual information
+*/
+func jitteredStandardized(values []float64) []float64 {
+	mean, std := meanStd(values)
+	if std == 0 {
+		std = 1
+	}
+	jittered := make([]float64, len(values))
+	meanAbs := 0.0
+	for ci, i := range values {
+		jittered[ci] = (i - mean) / std
+		meanAbs += math.Abs(jittered[ci])
+	}
+	noise := 1e-10 * math.Max(1, meanAbs/float64(len(values)))
+	for ci := range jittered {
+		jittered[ci] += noise * rand.NormFloat64()
+	}
+	return jittered
+}
+
+/*
+Count the sorted values within a distance strictly smaller than radius around center
+*/
+func countWithin(sortedValues []float64, center, radius float64) int {
+	lower := sort.SearchFloat64s(sortedValues, math.Nextafter(center-radius, math.Inf(1)))
+	upper := sort.SearchFloat64s(sortedValues, center+radius)
+	return upper - lower
+}
+
+/*
+Distance of every sorted value to its k-th nearest neighbor among the sorted values
+*/
+func kthNeighborDistances(sortedValues []float64, k int) []float64 {
+	dists := make([]float64, len(sortedValues))
+	for ci, i := range sortedValues {
+		left, right := ci-1, ci+1
+		dist := 0.0
+		for found := 0; found < k; found++ {
+			if right >= len(sortedValues) || left >= 0 && i-sortedValues[left] <= sortedValues[right]-i {
+				dist = i - sortedValues[left]
+				left--
+			} else {
+				dist = sortedValues[right] - i
+				right++
+			}
+		}
+		dists[ci] = dist
+	}
+	return dists
+}
+
+/*
+Digamma function psi(x) for x > 0
+*/
+func digamma(x float64) float64 {
+	result := 0.0
+	// recurrence psi(x) = psi(x + 1) - 1 / x until the asymptotic expansion is accurate
+	for x < 6 {
+		result -= 1 / x
+		x++
+	}
+	inv := 1 / (x * x)
+	return result + math.Log(x) - 0.5/x - inv*(1.0/12-inv*(1.0/120-inv*(1.0/252-inv*(1.0/240-inv/132))))
+}
+
+/*
+Mutual information between every continuous feature and the classes with the nearest neighbor estimator of Ross (2014)
+
+	:parameter
+		*	x: vectors representing the data
+		*	y: classes of the data
+		*	numNeighbors: number of neighbors used for the estimate e.g. 3
+	:return
+		*	scores: estimated mutual information in nats of every feature (at least 0)
+*/
+func mutualInfoClassification(x [][]float64, y []int, numNeighbors *int) []float64 {
+	if len(x) != len(y) {
+		log.Fatalln(fmt.Sprintf("Size of x [%d] not equal to size of y [%d]", len(x), len(y)))
+	}
+	classMembers := make(map[int][]int)
+	for ci, i := range y {
+		classMembers[i] = append(classMembers[i], ci)
+	}
+	scores := make([]float64, len(x[0]))
+	parallelFor(len(scores), func(f int) {
+		values := jitteredStandardized(column(x, f))
+		// samples whose class has only one member can't be used
+		used := []float64{}
+		kSum, classCountSum, mSum := 0.0, 0.0, 0.0
+		radii := []float64{}
+		for _, members := range classMembers {
+			if len(members) < 2 {
+				continue
+			}
+			k := *numNeighbors
+			if k > len(members)-1 {
+				k = len(members) - 1
+			}
+			classValues := subsetRows(values, members)
+			sort.Float64s(classValues)
+			for _, j := range kthNeighborDistances(classValues, k) {
+				radii = append(radii, j)
+				kSum += digamma(float64(k))
+				classCountSum += digamma(float64(len(members)))
+			}
+			used = append(used, classValues...)
+		}
+		if len(used) == 0 {
+			return
+		}
+		sortedUsed := append([]float64{}, used...)
+		sort.Float64s(sortedUsed)
+		for cj, j := range used {
+			mSum += digamma(float64(countWithin(sortedUsed, j, radii[cj])))
+		}
+		n := float64(len(used))
+		scores[f] = math.Max(0, digamma(n)+(kSum-classCountSum-mSum)/n)
+	})
+	return scores
+}
+
+/*
+Mutual information between every continuous feature and a continuous target with the nearest neighbor estimator of Kraskov et al. (2004)
+
+	:parameter
+		*	x: vectors representing the data
+		*	y: target values of the data
+		*	numNeighbors: number of neighbors used for the estimate e.g. 3
+	:return
+		*	scores: estimated mutual information in nats of every feature (at least 0)
+*/
+func mutualInfoRegression(x [][]float64, y []float64, numNeighbors *int) []float64 {
+	if len(x) != len(y) {
+		log.Fatalln(fmt.Sprintf("Size of x [%d] not equal to size of y [%d]", len(x), len(y)))
+	}
+	n := len(y)
+	k := *numNeighbors
+	if k > n-1 {
+		k = n - 1
+	}
+	target := jitteredStandardized(y)
+	sortedTarget := append([]float64{}, target...)
+	sort.Float64s(sortedTarget)
+	scores := make([]float64, len(x[0]))
+	parallelFor(len(scores), func(f int) {
+		values := jitteredStandardized(column(x, f))
+		order := argsort(values)
+		sortedValues := subsetRows(values, order)
+		countSum := 0.0
+		for ci, i := range order {
+			// k nearest neighbors in the joint space with the maximum norm by searching outwards along the feature
+			nearest := make([]float64, 0, k+1)
+			for _, step := range []int{-1, 1} {
+				for j := ci + step; j >= 0 && j < n; j += step {
+					dx := math.Abs(sortedValues[j] - sortedValues[ci])
+					if len(nearest) == k && dx >= nearest[k-1] {
+						break
+					}
+					dist := math.Max(dx, math.Abs(target[order[j]]-target[i]))
+					pos := sort.SearchFloat64s(nearest, dist)
+					if pos < k {
+						if len(nearest) < k {
+							nearest = append(nearest, 0)
+						}
+						copy(nearest[pos+1:], nearest[pos:])
+						nearest[pos] = dist
+					}
+				}
+			}
+			radius := nearest[k-1]
+			// neighbors strictly closer than the k-th neighbor without the sample itself
+			countSum += digamma(float64(countWithin(sortedValues, sortedValues[ci], radius))) +
+				digamma(float64(countWithin(sortedTarget, target[i], radius)))
+		}
+		scores[f] = math.Max(0, digamma(float64(n))+digamma(float64(k))-countSum/float64(n))
+	})
+	return scores
+}
+
+/*
+Absolute Pearson correlation of every feature with a continuous target
+
+	:parameter
+		*	x: vectors representing the data
+		*	y: target values of the data
+	:return
+		*	scores: absolute correlation of every feature - 0 for constant features
+*/
+func correlationScores(x [][]float64, y []float64) []float64 {
+	if len(x) != len(y) {
+		log.Fatalln(fmt.Sprintf("Size of x [%d] not equal to size of y [%d]", len(x), len(y)))
+	}
+	scores := make([]float64, len(x[0]))
+	featureCol, targetCol := 0, 1
+	for ci, i := range columnMoments(x) {
+		if i.max-i.min < float64EqualityThreshold {
+			continue
+		}
+		pairs := make([][]float64, len(y))
+		for cj, j := range x {
+			pairs[cj] = []float64{j[ci], y[cj]}
+		}
+		scores[ci] = math.Abs(corrCoef(pairs, &featureCol, &targetCol))
+	}
+	return scores
+}
+
+/*
+Scores for classification tasks that can be selected by their name
+*/
+var classificationScores = map[string]func(x [][]float64, y []int) []float64{
+	"variance": func(x [][]float64, y []int) []float64 { return varianceScores(x) },
+	"anova":    anovaFScores,
+	"chi2":     chiSquareScores,
+	"mutualinfo": func(x [][]float64, y []int) []float64 {
+		numNeighbors := 3
+		return mutualInfoClassification(x, y, &numNeighbors)
+	},
+}
+
+/*
+Scores for regression tasks that can be selected by their name
+*/
+var regressionScores = map[string]func(x [][]float64, y []float64) []float64{
+	"variance":    func(x [][]float64, y []float64) []float64 { return varianceScores(x) },
+	"correlation": correlationScores,
+	"mutualinfo": func(x [][]float64, y []float64) []float64 {
+		numNeighbors := 3
+		return mutualInfoRegression(x, y, &numNeighbors)
+	},
+}
+
+/*
+Filter style feature selector that scores every feature on the training data and keeps the selected columns
+*/
+type featureSelector[T label] struct {
+	scoreFunc func(x [][]float64, y []T) []float64
+	// chooses the indices of the kept features from the scores
+	rule func(scores []float64) []int
+	// set by Fit
+	scores       []float64
+	support      []int
+	featureNames []string
+}
+
+/*
+Rank features by their score from best to worst - NaN scores are ranked last
+*/
+func rankScores(scores []float64) []int {
+	order := make([]int, len(scores))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := scores[order[i]], scores[order[j]]
+		if math.IsNaN(b) {
+			return !math.IsNaN(a)
+		}
+		return a > b
+	})
+	return order
+}
+
+/*
+Create a selector keeping the k features with the highest scores
+
+	:parameter
+		*	scoreFunc: scores the features like anovaFScores or an entry of classificationScores or regressionScores
+		*	k: number of features to keep
+	:return
+		*	selector: the unfitted selector
+*/
+func newSelectKBest[T label](scoreFunc func(x [][]float64, y []T) []float64, k *int) *featureSelector[T] {
+	if *k < 0 {
+		log.Fatalln(fmt.Sprintf("Number of features to keep [%d] can't be negative", *k))
+	}
+	wanted := *k
+	return &featureSelector[T]{scoreFunc: scoreFunc, rule: func(scores []float64) []int {
+		// clamped per fit so that refitting on more features keeps k of them again
+		numKept := wanted
+		if numKept > len(scores) {
+			fmt.Printf("Keeping all [%d] features since k [%d] is bigger than the number of features\n", len(scores), numKept)
+			numKept = len(scores)
+		}
+		kept := append([]int{}, rankScores(scores)[:numKept]...)
+		sort.Ints(kept)
+		return kept
+	}}
+}
+
+/*
+Create a selector keeping the given percentage of features with the highest scores
+
+	:parameter
+		*	scoreFunc: scores the features like anovaFScores or an entry of classificationScores or regressionScores
+		*	percentile: percentage of features to keep between 0 and 100
+	:return
+		*	selector: the unfitted selector
+*/
+func newSelectPercentile[T label](scoreFunc func(x [][]float64, y []T) []float64, percentile *float64) *featureSelector[T] {
+	if *percentile < 0 || *percentile > 100 {
+		log.Fatalln(fmt.Sprintf("Percentile [%f] has to be between 0 and 100", *percentile))
+	}
+	fraction := *percentile / 100
+	return &featureSelector[T]{scoreFunc: scoreFunc, rule: func(scores []float64) []int {
+		kept := append([]int{}, rankScores(scores)[:int(float64(len(scores))*fraction)]...)
+		sort.Ints(kept)
+		return kept
+	}}
+}
+
+/*
+Create a selector keeping all features whose variance is above a threshold
+
+	:parameter
+		*	threshold: minimal variance a feature needs to be kept - 0 removes constant features
+	:return
+		*	selector: the unfitted selector
+*/
+func newVarianceThreshold[T label](threshold *float64) *featureSelector[T] {
+	minVariance := *threshold
+	return &featureSelector[T]{
+		scoreFunc: func(x [][]float64, y []T) []float64 { return varianceScores(x) },
+		rule: func(scores []float64) []int {
+			kept := []int{}
+			for ci, i := range scores {
+				if i > minVariance {
+					kept = append(kept, ci)
+				}
+			}
+			return kept
+		}}
+}
+
+/*
+Score the features on the training data and choose the kept features
+
+	:parameter
+		*	x: vectors representing the training data
+		*	y: labels of the training data
+		*	featureNames: names of the features - nil to use their indices
+	:return
+		None
+*/
+func (s *featureSelector[T]) Fit(x [][]float64, y []T, featureNames []string) {
+	if featureNames == nil {
+		featureNames = make([]string, len(x[0]))
+		for i := range featureNames {
+			featureNames[i] = strconv.Itoa(i)
+		}
+	}
+	if len(featureNames) != len(x[0]) {
+		log.Fatalln(fmt.Sprintf("Number of feature names [%d] not equal to the number of features [%d]", len(featureNames), len(x[0])))
+	}
+	s.scores = s.scoreFunc(x, y)
+	s.support = s.rule(s.scores)
+	s.featureNames = subsetRows(featureNames, s.support)
+}
+
+/*
+Keep only the selected features
+
+	:parameter
+		*	x: vectors with the same features as the training data
+	:return
+		*	transformed: copy of x with only the selected features
+*/
+func (s *featureSelector[T]) Transform(x [][]float64) [][]float64 {
+	if s.support == nil {
+		log.Fatalln("Feature selector has to be fitted before transforming data")
+	}
+	transformed := make([][]float64, len(x))
+	for ci, i := range x {
+		transformed[ci] = subsetRows(i, s.support)
+	}
+	return transformed
+}
+
+/*
+Fit the selector on the training data and transform it
+
+	:parameter
+		*	x: vectors representing the training data
+		*	y: labels of the training data
+		*	featureNames: names of the features - nil to use their indices
+	:return
+		*	transformed: x with only the selected features
+		*	keptNames: names of the selected features
+*/
+func (s *featureSelector[T]) FitTransform(x [][]float64, y []T, featureNames []string) ([][]float64, []string) {
+	s.Fit(x, y, featureNames)
+	return s.Transform(x), s.featureNames
+}
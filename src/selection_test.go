@@ -0,0 +1,94 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestClassificationScores(t *testing.T) {
+	// the first feature separates the classes, the second varies only within and the third is constant
+	x := [][]float64{{1, 0, 2}, {2, 1, 2}, {3, 2, 2}, {4, 0, 2}, {5, 1, 2}, {6, 2, 2}}
+	y := []int{0, 0, 0, 1, 1, 1}
+	// means 2 and 5 with 13.5 between and 4 within the classes
+	for _, i := range []struct {
+		name      string
+		got, want []float64
+	}{
+		{"variance", varianceScores(x), []float64{35. / 12, 2. / 3, 0}},
+		{"anova", anovaFScores(x, y), []float64{13.5, 0, 0}},
+		// class sums 6 and 15 with the expected sum 10.5 each
+		{"chi2", chiSquareScores(x, y), []float64{27. / 7, 0, 0}},
+	} {
+		for ci, j := range i.want {
+			if math.Abs(i.got[ci]-j) > 1e-12 {
+				t.Errorf("%s scores %v, want %v", i.name, i.got, i.want)
+				break
+			}
+		}
+	}
+}
+
+func TestMutualInformation(t *testing.T) {
+	rng := rand.New(rand.NewSource(79))
+	n := 400
+	x := make([][]float64, n)
+	y := make([]int, n)
+	target := make([]float64, n)
+	for i := range x {
+		y[i] = i % 2
+		a := rng.NormFloat64()
+		// separated by class, pure noise and correlated with the target
+		x[i] = []float64{float64(y[i])*10 + rng.Float64(), rng.NormFloat64(), a}
+		target[i] = a + 0.5*rng.NormFloat64()
+	}
+	numNeighbors := 3
+	classification := mutualInfoClassification(x, y, &numNeighbors)
+	// a feature that determines one of two balanced classes carries ln(2) nats
+	if math.Abs(classification[0]-math.Ln2) > 0.05 || classification[1] > 0.05 || classification[2] > 0.05 {
+		t.Errorf("mutual information with the classes %v, want about [%g 0 0]", classification, math.Ln2)
+	}
+	regression := mutualInfoRegression(x, target, &numNeighbors)
+	// bivariate normal with the squared correlation 1 / 1.25 carries -ln(1 - 0.8) / 2 nats
+	want := math.Log(5) / 2
+	if math.Abs(regression[2]-want) > 0.1 || regression[0] > 0.05 || regression[1] > 0.05 {
+		t.Errorf("mutual information with the target %v, want about [0 0 %g]", regression, want)
+	}
+	correlation := correlationScores([][]float64{{1, 3, 1}, {2, 1, 1}, {3, -1, 1}}, []float64{2, 4, 6})
+	if math.Abs(correlation[0]-1) > 1e-12 || math.Abs(correlation[1]-1) > 1e-12 || correlation[2] != 0 {
+		t.Errorf("correlation scores %v, want [1 1 0]", correlation)
+	}
+}
+
+func TestFeatureSelectors(t *testing.T) {
+	scoreFunc := func(x [][]float64, y []int) []float64 { return x[0] }
+	x := [][]float64{{3, 9, math.NaN(), 5, 1}}
+	k := 2
+	selector := newSelectKBest[int](scoreFunc, &k)
+	transformed, names := selector.FitTransform(x, nil, []string{"a", "b", "c", "d", "e"})
+	// the kept features stay in their original order
+	if len(names) != 2 || names[0] != "b" || names[1] != "d" || transformed[0][0] != 9 || transformed[0][1] != 5 {
+		t.Errorf("select k best keeps %v with %v, want [b d]", names, transformed)
+	}
+	// refitting on fewer and then on more features than k has to keep k features again
+	selector.Fit([][]float64{{1}}, nil, nil)
+	if len(selector.support) != 1 {
+		t.Errorf("select k best keeps %v of 1 feature", selector.support)
+	}
+	selector.Fit(x, nil, nil)
+	if len(selector.support) != 2 {
+		t.Errorf("select k best keeps %v after refitting, want 2 features", selector.support)
+	}
+	percentile := 60.0
+	selector = newSelectPercentile[int](scoreFunc, &percentile)
+	selector.Fit(x, nil, nil)
+	if len(selector.support) != 3 || selector.support[0] != 0 || selector.support[1] != 1 || selector.support[2] != 3 {
+		t.Errorf("select percentile keeps %v, want [0 1 3]", selector.support)
+	}
+	threshold := 0.0
+	selector = newVarianceThreshold[int](&threshold)
+	transformed, names = selector.FitTransform([][]float64{{1, 2, 3}, {1, 5, 3}, {1, 2, 4}}, nil, nil)
+	if len(names) != 2 || names[0] != "1" || names[1] != "2" || len(transformed[0]) != 2 {
+		t.Errorf("variance threshold keeps %v, want the non constant features [1 2]", names)
+	}
+}
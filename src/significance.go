@@ -0,0 +1,336 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+/*
+Correlation coefficient with its two sided p-value and confidence interval
+*/
+type correlationTest struct {
+	corr    float64
+	pValue  float64
+	ciLower float64
+	ciUpper float64
+	n       int
+}
+
+/*
+Correlations between all features with their p-values, p-values corrected for multiple testing and confidence intervals
+*/
+type correlationSignificance struct {
+	corr            [][]float64
+	pValues         [][]float64
+	adjustedPValues [][]float64
+	ciLower         [][]float64
+	ciUpper         [][]float64
+}
+
+/*
+Tie statistics of a feature needed for the variance of Kendall's tau
+*/
+type tieStats struct {
+	// sum of t(t-1)/2 over all groups of t tied values
+	pairs float64
+	// sum of t(t-1)(t-2)
+	triples float64
+	// sum of t(t-1)(2t+5)
+	varTerm float64
+}
+
+/*
+Calculate the tie statistics of a feature
+
+	:parameter
+		*	values: the values of the feature
+	:return
+		*	stats: the tie statistics
+*/
+func newTieStats(values []float64) tieStats {
+	sortedValues := append([]float64{}, values...)
+	sort.Float64s(sortedValues)
+	stats := tieStats{}
+	run := 1.0
+	for i := 1; i <= len(sortedValues); i++ {
+		if i < len(sortedValues) && sortedValues[i] == sortedValues[i-1] {
+			run++
+		} else {
+			stats.pairs += run * (run - 1) / 2
+			stats.triples += run * (run - 1) * (run - 2)
+			stats.varTerm += run * (run - 1) * (2*run + 5)
+			run = 1
+		}
+	}
+	return stats
+}
+
+/*
+Two sided p-value of Kendall's tau-b with the tie corrected normal approximation
+
+	:parameter
+		*	tau: Kendall's tau-b
+		*	n: number of samples
+		*	tiesX, tiesY: tie statistics of both features
+	:return
+		*	p: two sided p-value
+*/
+func kendallPValue(tau float64, n int, tiesX, tiesY tieStats) float64 {
+	fn := float64(n)
+	totalPairs := fn * (fn - 1) / 2
+	concordantMinusDiscordant := tau * math.Sqrt((totalPairs-tiesX.pairs)*(totalPairs-tiesY.pairs))
+	variance := (fn*(fn-1)*(2*fn+5)-tiesX.varTerm-tiesY.varTerm)/18 +
+		2*tiesX.pairs*tiesY.pairs/(fn*(fn-1)) +
+		tiesX.triples*tiesY.triples/(9*fn*(fn-1)*(fn-2))
+	z := concordantMinusDiscordant / math.Sqrt(variance)
+	return 2 * normalCDF(-math.Abs(z))
+}
+
+/*
+Two sided p-value of a Pearson or Spearman correlation with the t-distribution with n - 2 degrees of freedom
+
+	:parameter
+		*	corr: the correlation coefficient
+		*	n: number of samples
+	:return
+		*	p: two sided p-value
+*/
+func correlationTPValue(corr float64, n int) float64 {
+	if n < 3 {
+		return math.NaN()
+	}
+	if math.Abs(corr) >= 1 {
+		return 0
+	}
+	df := float64(n - 2)
+	t := corr * math.Sqrt(df/(1-corr*corr))
+	return studentTTwoSidedP(t, df)
+}
+
+/*
+Confidence interval of a correlation coefficient with the Fisher z-transformation
+
+	:parameter
+		*	corr: the correlation coefficient
+		*	n: number of samples
+		*	corrType: which correlation was used (pearson, spearman, kendall) to select the standard error (Fieller et al. for the rank correlations)
+		*	confidence: confidence level e.g. 0.95
+	:return
+		*	lower, upper: bounds of the confidence interval
+*/
+func fisherConfidenceInterval(corr float64, n int, corrType *string, confidence *float64) (float64, float64) {
+	stdErr := 0.0
+	switch *corrType {
+	case "spearman":
+		stdErr = math.Sqrt(1.06 / float64(n-3))
+	case "kendall":
+		stdErr = math.Sqrt(0.437 / float64(n-4))
+	default:
+		stdErr = 1 / math.Sqrt(float64(n-3))
+	}
+	if n < 5 || math.IsNaN(corr) {
+		return math.NaN(), math.NaN()
+	}
+	z := math.Atanh(corr)
+	zCrit := normalQuantile(1 - (1-*confidence)/2)
+	return math.Tanh(z - zCrit*stdErr), math.Tanh(z + zCrit*stdErr)
+}
+
+/*
+Test the correlation between two vectors for significance
+
+	:parameter
+		*	x, y: the vectors
+		*	corrType: which correlation should be used
+			-	pearson: p-value from the t-distribution
+			-	spearman: p-value from the t-distribution
+			-	kendall: p-value from the tie corrected normal approximation
+		*	confidence: confidence level of the confidence interval e.g. 0.95
+	:return
+		*	test: the correlation with its p-value and confidence interval
+*/
+func corrTestVec(x, y []float64, corrType *string, confidence *float64) correlationTest {
+	assertEqualLengthFloat(x, y)
+	n := len(x)
+	test := correlationTest{n: n}
+	switch *corrType {
+	case "spearman":
+		test.corr = spearmanVec(x, y)
+		test.pValue = correlationTPValue(test.corr, n)
+	case "kendall":
+		test.corr = kendallVec(x, y)
+		test.pValue = kendallPValue(test.corr, n, newTieStats(x), newTieStats(y))
+	default:
+		if *corrType != "pearson" {
+			fmt.Printf("Using default correlation ['pearson'] instead of the not implementd ['%s']\n", *corrType)
+		}
+		test.corr = pearsonVec(x, y)
+		test.pValue = correlationTPValue(test.corr, n)
+	}
+	test.ciLower, test.ciUpper = fisherConfidenceInterval(test.corr, n, corrType, confidence)
+	return test
+}
+
+/*
+Test the correlation between two (colIndX and colIndY) columns in inSlice for significance
+
+	:parameter
+		*	inSlice: slice containing the data
+		*	colIndX, colIndY: indices (zero indexed) of the columns for which the correlation should be tested
+		*	corrType: which correlation should be used (pearson, spearman, kendall)
+		*	confidence: confidence level of the confidence interval e.g. 0.95
+	:return
+		*	test: the correlation with its p-value and confidence interval
+*/
+func corrTest(inSlice [][]float64, colIndX, colIndY *int, corrType *string, confidence *float64) correlationTest {
+	return corrTestVec(column(inSlice, *colIndX), column(inSlice, *colIndY), corrType, confidence)
+}
+
+/*
+Bonferroni correction of p-values
+
+	:parameter
+		*	pValues: the uncorrected p-values
+	:return
+		*	adjusted: p-values multiplied by the number of tests (at most 1)
+*/
+func bonferroni(pValues []float64) []float64 {
+	adjusted := make([]float64, len(pValues))
+	for ci, i := range pValues {
+		adjusted[ci] = math.Min(i*float64(len(pValues)), 1)
+	}
+	return adjusted
+}
+
+/*
+Benjamini-Hochberg correction of p-values controlling the false discovery rate
+
+	:parameter
+		*	pValues: the uncorrected p-values
+	:return
+		*	adjusted: the adjusted p-values (q-values) in the order of pValues
+*/
+func benjaminiHochberg(pValues []float64) []float64 {
+	m := float64(len(pValues))
+	order := argsort(pValues)
+	adjusted := make([]float64, len(pValues))
+	// step up from the largest p-value and keep the adjusted values monotone
+	minAdjusted := 1.0
+	for i := len(order) - 1; i >= 0; i-- {
+		minAdjusted = math.Min(minAdjusted, pValues[order[i]]*m/float64(i+1))
+		adjusted[order[i]] = minAdjusted
+	}
+	return adjusted
+}
+
+/*
+Correct p-values for multiple testing
+
+	:parameter
+		*	pValues: the uncorrected p-values
+		*	correction: which correction should be used
+			-	bonferroni
+			-	bh: Benjamini-Hochberg
+			-	none
+	:return
+		*	adjusted: the corrected p-values
+*/
+func adjustPValues(pValues []float64, correction *string) []float64 {
+	switch *correction {
+	case "bonferroni":
+		return bonferroni(pValues)
+	case "bh":
+		return benjaminiHochberg(pValues)
+	case "none":
+		return append([]float64{}, pValues...)
+	default:
+		fmt.Printf("Using default correction ['bh'] instead of the not implementd ['%s']\n", *correction)
+		return benjaminiHochberg(pValues)
+	}
+}
+
+/*
+Test the correlations between all features for significance and correct the p-values for multiple testing
+
+	:parameter
+		*	inSlice: slice containing the data with one vector per sample
+		*	corrType: which correlation should be used (pearson, spearman, kendall)
+		*	confidence: confidence level of the confidence intervals e.g. 0.95
+		*	correction: correction for multiple testing over all feature pairs (bonferroni, bh, none)
+	:return
+		*	significance: correlation matrix with the p-values, corrected p-values and confidence intervals of every pair
+*/
+func correlationMatrixSignificance(inSlice [][]float64, corrType *string, confidence *float64, correction *string) correlationSignificance {
+	corrMat := CorrelationMatrix(inSlice, corrType)
+	n := len(inSlice)
+	numFeatures := len(corrMat)
+	ties := make([]tieStats, numFeatures)
+	if *corrType == "kendall" {
+		parallelFor(numFeatures, func(i int) {
+			ties[i] = newTieStats(column(inSlice, i))
+		})
+	}
+	newMatrix := func() [][]float64 {
+		mat := make([][]float64, numFeatures)
+		for i := range mat {
+			mat[i] = make([]float64, numFeatures)
+		}
+		return mat
+	}
+	significance := correlationSignificance{corr: corrMat, pValues: newMatrix(), adjustedPValues: newMatrix(), ciLower: newMatrix(), ciUpper: newMatrix()}
+	// p-values of the upper triangle that are defined
+	testedPairs := [][2]int{}
+	testedPValues := []float64{}
+	for i := 0; i < numFeatures; i++ {
+		for j := i; j < numFeatures; j++ {
+			corr := corrMat[i][j]
+			pValue := 0.0
+			lower, upper := corr, corr
+			if i != j {
+				if *corrType == "kendall" {
+					pValue = kendallPValue(corr, n, ties[i], ties[j])
+				} else {
+					pValue = correlationTPValue(corr, n)
+				}
+				lower, upper = fisherConfidenceInterval(corr, n, corrType, confidence)
+			}
+			if math.IsNaN(corr) {
+				pValue = math.NaN()
+			} else if i != j {
+				testedPairs = append(testedPairs, [2]int{i, j})
+				testedPValues = append(testedPValues, pValue)
+			}
+			significance.pValues[i][j], significance.pValues[j][i] = pValue, pValue
+			significance.adjustedPValues[i][j], significance.adjustedPValues[j][i] = pValue, pValue
+			significance.ciLower[i][j], significance.ciLower[j][i] = lower, lower
+			significance.ciUpper[i][j], significance.ciUpper[j][i] = upper, upper
+		}
+	}
+	for ci, i := range adjustPValues(testedPValues, correction) {
+		pair := testedPairs[ci]
+		significance.adjustedPValues[pair[0]][pair[1]] = i
+		significance.adjustedPValues[pair[1]][pair[0]] = i
+	}
+	return significance
+}
+
+/*
+Find all feature pairs whose corrected p-value is below alpha
+
+	:parameter
+		*	alpha: significance level e.g. 0.05
+	:return
+		*	pairs: indices of the significantly correlated features (first index < second index)
+*/
+func (s correlationSignificance) significantPairs(alpha *float64) [][2]int {
+	pairs := [][2]int{}
+	for i := range s.adjustedPValues {
+		for j := i + 1; j < len(s.adjustedPValues); j++ {
+			if s.adjustedPValues[i][j] < *alpha {
+				pairs = append(pairs, [2]int{i, j})
+			}
+		}
+	}
+	return pairs
+}
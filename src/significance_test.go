@@ -0,0 +1,61 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCorrTestReferenceValues(t *testing.T) {
+	// reference p-values from SciPy's spearmanr and kendalltau
+	confidence := 0.95
+	for _, i := range []struct {
+		corrType string
+		x, y     []float64
+		corr     float64
+		pValue   float64
+	}{
+		{"spearman", []float64{1, 2, 3, 4, 5}, []float64{5, 6, 7, 8, 7}, 0.8207826816681233, 0.08858700531354381},
+		{"kendall", []float64{12, 2, 1, 12, 2}, []float64{1, 4, 7, 1, 0}, -0.47140452079103173, 0.2827454599327748},
+	} {
+		test := corrTestVec(i.x, i.y, &i.corrType, &confidence)
+		if math.Abs(test.corr-i.corr) > 1e-12 || math.Abs(test.pValue-i.pValue) > 1e-9 {
+			t.Errorf("%s: correlation %.16g with p-value %.16g, want %.16g with %.16g", i.corrType, test.corr, test.pValue, i.corr, i.pValue)
+		}
+	}
+}
+
+func TestFisherConfidenceInterval(t *testing.T) {
+	confidence := 0.95
+	for _, i := range []struct {
+		corrType     string
+		lower, upper float64
+	}{
+		{"pearson", 0.17043136511180007, 0.7289585563883555},
+		{"spearman", 0.15958407351004938, 0.7341417973316954},
+	} {
+		lower, upper := fisherConfidenceInterval(0.5, 30, &i.corrType, &confidence)
+		if math.Abs(lower-i.lower) > 1e-9 || math.Abs(upper-i.upper) > 1e-9 {
+			t.Errorf("%s: interval [%g, %g], want [%g, %g]", i.corrType, lower, upper, i.lower, i.upper)
+		}
+	}
+}
+
+func TestAdjustPValues(t *testing.T) {
+	pValues := []float64{0.01, 0.04, 0.03, 0.005, 0.5}
+	for _, i := range []struct {
+		correction string
+		want       []float64
+	}{
+		{"bonferroni", []float64{0.05, 0.2, 0.15, 0.025, 1}},
+		{"bh", []float64{0.025, 0.05, 0.05, 0.025, 0.5}},
+		{"none", pValues},
+	} {
+		got := adjustPValues(pValues, &i.correction)
+		for ci, j := range i.want {
+			if math.Abs(got[ci]-j) > 1e-12 {
+				t.Errorf("%s: adjusted p-values %v, want %v", i.correction, got, i.want)
+				break
+			}
+		}
+	}
+}
@@ -119,3 +119,38 @@ func mse(prediction, groundTruth []float64) *float64 {
 	mae := sumError / float64(pSize)
 	return &mae
 }
+
+/*
+Calculate the mean and the (population) standard deviation of inSlice
+
+	:parameter
+		* inSlice: the values
+	:return
+		* mean: mean of the values
+		* std: standard deviation of the values
+*/
+func meanStd(inSlice []float64) (float64, float64) {
+	n := float64(len(inSlice))
+	mean := *sumFloat64(inSlice) / n
+	variance := 0.0
+	for _, i := range inSlice {
+		variance += (i - mean) * (i - mean)
+	}
+	return mean, math.Sqrt(variance / n)
+}
+
+/*
+Calculate the q-th quantile of already sorted values with linear interpolation between the closest ranks
+
+	:parameter
+		* sortedSlice: the values sorted from small to big
+		* q: the quantile between 0 and 1
+	:return
+		* quant: the q-th quantile
+*/
+func quantileSorted(sortedSlice []float64, q float64) float64 {
+	pos := q * float64(len(sortedSlice)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	return sortedSlice[lower] + (pos-float64(lower))*(sortedSlice[upper]-sortedSlice[lower])
+}
@@ -0,0 +1,710 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+/*
+Single pass accumulator of count, mean, variance, minimum and maximum (Welford) - NaN values are skipped and the zero value is ready to use
+*/
+type welford struct {
+	n    int
+	mean float64
+	// sum of squared differences from the mean
+	m2  float64
+	min float64
+	max float64
+}
+
+/*
+Add a value to the accumulator
+
+	:parameter
+		* x: the value
+	:return
+		None
+*/
+func (w *welford) add(x float64) {
+	if math.IsNaN(x) {
+		return
+	}
+	w.n++
+	if w.n == 1 {
+		w.min, w.max = x, x
+	} else {
+		w.min = math.Min(w.min, x)
+		w.max = math.Max(w.max, x)
+	}
+	delta := x - w.mean
+	w.mean += delta / float64(w.n)
+	w.m2 += delta * (x - w.mean)
+}
+
+/*
+Merge the partial result of another accumulator into this one (Chan et al.)
+
+	:parameter
+		* other: accumulator of a different part of the data
+	:return
+		None
+*/
+func (w *welford) merge(other welford) {
+	if other.n == 0 {
+		return
+	}
+	if w.n == 0 {
+		*w = other
+		return
+	}
+	n := float64(w.n + other.n)
+	delta := other.mean - w.mean
+	w.mean += delta * float64(other.n) / n
+	w.m2 += other.m2 + delta*delta*float64(w.n)*float64(other.n)/n
+	w.n += other.n
+	w.min = math.Min(w.min, other.min)
+	w.max = math.Max(w.max, other.max)
+}
+
+/*
+Variance of the added values
+
+	:parameter
+		* ddof: delta degrees of freedom - 0 for the population and 1 for the sample variance
+	:return
+		* variance: the variance - NaN if there are not more than ddof values
+*/
+func (w welford) variance(ddof int) float64 {
+	if w.n <= ddof {
+		return math.NaN()
+	}
+	return w.m2 / float64(w.n-ddof)
+}
+
+/*
+Standard deviation of the added values
+
+	:parameter
+		* ddof: delta degrees of freedom - 0 for the population and 1 for the sample standard deviation
+	:return
+		* std: the standard deviation - NaN if there are not more than ddof values
+*/
+func (w welford) std(ddof int) float64 {
+	return math.Sqrt(w.variance(ddof))
+}
+
+/*
+Single pass accumulator of the covariance matrix of feature vectors - vectors containing NaN are skipped
+*/
+type covarianceAccumulator struct {
+	n    int
+	mean []float64
+	// sum of the products of the differences from the means
+	comoment [][]float64
+}
+
+/*
+Create an empty covariance accumulator
+
+	:parameter
+		* numFeatures: number of features per vector
+	:return
+		* acc: the accumulator
+*/
+func newCovarianceAccumulator(numFeatures int) *covarianceAccumulator {
+	comoment := make([][]float64, numFeatures)
+	for i := range comoment {
+		comoment[i] = make([]float64, numFeatures)
+	}
+	return &covarianceAccumulator{mean: make([]float64, numFeatures), comoment: comoment}
+}
+
+/*
+Add a feature vector to the accumulator
+
+	:parameter
+		* x: the feature vector
+	:return
+		None
+*/
+func (c *covarianceAccumulator) add(x []float64) {
+	for _, i := range x {
+		if math.IsNaN(i) {
+			return
+		}
+	}
+	c.n++
+	delta := make([]float64, len(x))
+	for ci, i := range x {
+		delta[ci] = i - c.mean[ci]
+		c.mean[ci] += delta[ci] / float64(c.n)
+	}
+	for ci := range x {
+		for cj, j := range x {
+			// old difference of i times new difference of j
+			c.comoment[ci][cj] += delta[ci] * (j - c.mean[cj])
+		}
+	}
+}
+
+/*
+Merge the partial result of another accumulator into this one
+
+	:parameter
+		* other: accumulator of a different part of the data
+	:return
+		None
+*/
+func (c *covarianceAccumulator) merge(other *covarianceAccumulator) {
+	if other.n == 0 {
+		return
+	}
+	n := float64(c.n + other.n)
+	factor := float64(c.n) * float64(other.n) / n
+	delta := make([]float64, len(c.mean))
+	for ci := range c.mean {
+		delta[ci] = other.mean[ci] - c.mean[ci]
+	}
+	for ci := range c.comoment {
+		for cj := range c.comoment[ci] {
+			c.comoment[ci][cj] += other.comoment[ci][cj] + delta[ci]*delta[cj]*factor
+		}
+	}
+	for ci := range c.mean {
+		c.mean[ci] += delta[ci] * float64(other.n) / n
+	}
+	c.n += other.n
+}
+
+/*
+Covariance matrix of the added vectors
+
+	:parameter
+		* ddof: delta degrees of freedom - 0 for the population and 1 for the sample covariance
+	:return
+		* cov: the covariance matrix - NaN if there are not more than ddof vectors
+*/
+func (c *covarianceAccumulator) covariance(ddof int) [][]float64 {
+	cov := make([][]float64, len(c.comoment))
+	for ci, i := range c.comoment {
+		cov[ci] = make([]float64, len(i))
+		for cj, j := range i {
+			if c.n <= ddof {
+				cov[ci][cj] = math.NaN()
+			} else {
+				cov[ci][cj] = j / float64(c.n-ddof)
+			}
+		}
+	}
+	return cov
+}
+
+/*
+Cluster of values summarized by their mean and number
+*/
+type digestCentroid struct {
+	mean   float64
+	weight float64
+}
+
+/*
+Merging t-digest (Dunning) to approximate quantiles in a single pass with bounded memory - NaN values are skipped
+*/
+type tDigest struct {
+	// bigger values give more centroids and more accurate quantiles
+	compression float64
+	centroids   []digestCentroid
+	// values that are not yet merged into the centroids
+	buffer []digestCentroid
+	count  float64
+	min    float64
+	max    float64
+}
+
+/*
+Create an empty t-digest
+
+	:parameter
+		* compression: bounds the number of centroids - 100 is a good default
+	:return
+		* digest: the t-digest
+*/
+func newTDigest(compression *float64) *tDigest {
+	if *compression <= 0 {
+		log.Fatalln(fmt.Sprintf("Compression of the t-digest has to be > 0 but is [%f]", *compression))
+	}
+	return &tDigest{compression: *compression, min: math.Inf(1), max: math.Inf(-1)}
+}
+
+/*
+Add a value to the digest
+
+	:parameter
+		* x: the value
+	:return
+		None
+*/
+func (t *tDigest) add(x float64) {
+	if math.IsNaN(x) {
+		return
+	}
+	t.buffer = append(t.buffer, digestCentroid{x, 1})
+	t.count++
+	t.min = math.Min(t.min, x)
+	t.max = math.Max(t.max, x)
+	if float64(len(t.buffer)) >= 5*t.compression {
+		t.compress()
+	}
+}
+
+/*
+Merge the partial result of another digest into this one
+
+	:parameter
+		* other: digest of a different part of the data
+	:return
+		None
+*/
+func (t *tDigest) merge(other *tDigest) {
+	t.buffer = append(t.buffer, other.centroids...)
+	t.buffer = append(t.buffer, other.buffer...)
+	t.count += other.count
+	t.min = math.Min(t.min, other.min)
+	t.max = math.Max(t.max, other.max)
+	t.compress()
+}
+
+/*
+Scale function k1 that allows small centroids at the tails and big ones in the center
+*/
+func (t *tDigest) scale(q float64) float64 {
+	return t.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+/*
+Merge the buffered values into the centroids
+*/
+func (t *tDigest) compress() {
+	if len(t.buffer) == 0 {
+		return
+	}
+	all := make([]digestCentroid, 0, len(t.centroids)+len(t.buffer))
+	all = append(append(all, t.centroids...), t.buffer...)
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+	merged := []digestCentroid{all[0]}
+	// weight of all centroids before the last merged one
+	cumulative := 0.0
+	kLower := t.scale(0)
+	for _, i := range all[1:] {
+		last := &merged[len(merged)-1]
+		if t.scale((cumulative+last.weight+i.weight)/t.count)-kLower <= 1 {
+			last.weight += i.weight
+			last.mean += (i.mean - last.mean) * i.weight / last.weight
+		} else {
+			cumulative += last.weight
+			kLower = t.scale(cumulative / t.count)
+			merged = append(merged, i)
+		}
+	}
+	t.centroids = merged
+	t.buffer = t.buffer[:0]
+}
+
+/*
+Approximate quantile of the added values by interpolating between the centroids
+
+	:parameter
+		* q: the quantile between 0 and 1
+	:return
+		* value: the approximated quantile - NaN if no values were added
+*/
+func (t *tDigest) quantile(q float64) float64 {
+	t.compress()
+	if t.count == 0 {
+		return math.NaN()
+	}
+	if q <= 0 || len(t.centroids) == 1 && t.min == t.max {
+		return t.min
+	}
+	if q >= 1 {
+		return t.max
+	}
+	index := q * t.count
+	first := t.centroids[0]
+	// between the minimum and the center of the first centroid
+	if index < first.weight/2 {
+		return t.min + (first.mean-t.min)*index/(first.weight/2)
+	}
+	cumulative := first.weight / 2
+	for i := 0; i < len(t.centroids)-1; i++ {
+		step := (t.centroids[i].weight + t.centroids[i+1].weight) / 2
+		if cumulative+step > index {
+			return t.centroids[i].mean + (t.centroids[i+1].mean-t.centroids[i].mean)*(index-cumulative)/step
+		}
+		cumulative += step
+	}
+	// between the center of the last centroid and the maximum
+	last := t.centroids[len(t.centroids)-1]
+	return math.Min(t.max, last.mean+(t.max-last.mean)*(index-cumulative)/(last.weight/2))
+}
+
+/*
+Read a csv file line by line and process chunks of lines in parallel without holding the whole file in memory
+
+	:parameter
+		* filePath: path to the csv file
+		* header: true if there is a header
+		* chunkSize: number of lines per chunk
+		* numWorkers: number of goroutines processing the chunks
+		* process: called for every chunk with the index of the worker processing it so that every worker can use its own accumulators
+	:return
+		* headLine: the header of the file - empty if there is none
+*/
+func streamCsvChunks(filePath *string, header *bool, chunkSize *int, numWorkers int, process func(worker int, chunk [][]string)) []string {
+	f, err := os.Open(*filePath)
+	if err != nil {
+		log.Fatalln(fmt.Sprintf("Unable to open input file [%s]\n", *filePath), err)
+	}
+	defer f.Close()
+
+	csvReader := csv.NewReader(f)
+	headLine := []string{}
+	if *header {
+		firstLine, err := csvReader.Read()
+		if err != nil {
+			log.Fatalln(fmt.Sprintf("Couldn't read header of [%s]\n", *filePath), err)
+		}
+		headLine = append(headLine, firstLine...)
+	}
+	chunks := make(chan [][]string, numWorkers)
+	var wg sync.WaitGroup
+	wg.Add(numWorkers)
+	for w := 0; w < numWorkers; w++ {
+		go func(worker int) {
+			for chunk := range chunks {
+				process(worker, chunk)
+			}
+			wg.Done()
+		}(w)
+	}
+	chunk := make([][]string, 0, *chunkSize)
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalln(fmt.Sprintf("Unable to parse file as CSV for [%s]\n", *filePath), err)
+		}
+		chunk = append(chunk, record)
+		if len(chunk) == *chunkSize {
+			chunks <- chunk
+			chunk = make([][]string, 0, *chunkSize)
+		}
+	}
+	if len(chunk) > 0 {
+		chunks <- chunk
+	}
+	close(chunks)
+	wg.Wait()
+	return headLine
+}
+
+/*
+Convert the features of a csv line (all entries after the label in the first column) to float64 - empty entries are NaN
+
+	:parameter
+		* record: the line of the csv file
+	:return
+		* features: the converted features
+*/
+func parseFeatureRecord(record []string) []float64 {
+	features := make([]float64, len(record)-1)
+	for ci, i := range record[1:] {
+		if len(i) == 0 {
+			features[ci] = math.NaN()
+			continue
+		}
+		convFloat, err := strconv.ParseFloat(i, 64)
+		if err != nil {
+			log.Fatalln(fmt.Sprintf("Couldn't convert [%s] to float64\n", i), err)
+		}
+		features[ci] = convFloat
+	}
+	return features
+}
+
+/*
+Statistics of every feature that can be accumulated in a single pass and merged across chunks
+*/
+type streamStats struct {
+	moments []welford
+	// nil if no quantiles are estimated
+	quantiles []*tDigest
+	missing   []int
+	// nil if the covariance is not tracked
+	cov *covarianceAccumulator
+}
+
+/*
+Create empty statistics
+
+	:parameter
+		* numFeatures: number of features
+		* compression: compression of the t-digests for the quantiles - 0 to not estimate quantiles
+		* trackCov: true to accumulate the covariance matrix (memory grows with the squared number of features)
+	:return
+		* stats: the empty statistics
+*/
+func newStreamStats(numFeatures int, compression *float64, trackCov *bool) *streamStats {
+	stats := &streamStats{moments: make([]welford, numFeatures), missing: make([]int, numFeatures)}
+	if *compression > 0 {
+		stats.quantiles = make([]*tDigest, numFeatures)
+		for i := range stats.quantiles {
+			stats.quantiles[i] = newTDigest(compression)
+		}
+	}
+	if *trackCov {
+		stats.cov = newCovarianceAccumulator(numFeatures)
+	}
+	return stats
+}
+
+/*
+Add a feature vector to the statistics
+
+	:parameter
+		* x: the feature vector
+	:return
+		None
+*/
+func (s *streamStats) add(x []float64) {
+	if len(x) != len(s.moments) {
+		log.Fatalln(fmt.Sprintf("Feature vector has [%d] features but [%d] are expected", len(x), len(s.moments)))
+	}
+	for ci, i := range x {
+		if math.IsNaN(i) {
+			s.missing[ci]++
+			continue
+		}
+		s.moments[ci].add(i)
+		if s.quantiles != nil {
+			s.quantiles[ci].add(i)
+		}
+	}
+	if s.cov != nil {
+		s.cov.add(x)
+	}
+}
+
+/*
+Merge the partial statistics of another chunk into these statistics
+
+	:parameter
+		* other: statistics of a different part of the data
+	:return
+		None
+*/
+func (s *streamStats) merge(other *streamStats) {
+	for ci := range s.moments {
+		s.moments[ci].merge(other.moments[ci])
+		s.missing[ci] += other.missing[ci]
+		if s.quantiles != nil {
+			s.quantiles[ci].merge(other.quantiles[ci])
+		}
+	}
+	if s.cov != nil {
+		s.cov.merge(other.cov)
+	}
+}
+
+/*
+Accumulate the statistics of all features (all columns after the label in the first column) of a csv file in one parallel pass
+
+	:parameter
+		* filePath: path to the csv file
+		* header: true if there is a header
+		* chunkSize: number of lines processed at once by one worker
+		* compression: compression of the t-digests for the quantiles - 0 to not estimate quantiles
+		* trackCov: true to accumulate the covariance matrix
+	:return
+		* headLine: the header of the file - empty if there is none
+		* stats: the merged statistics - nil if the file contains no data
+*/
+func streamFeatureStats(filePath *string, header *bool, chunkSize *int, compression *float64, trackCov *bool) ([]string, *streamStats) {
+	numWorkers := runtime.NumCPU()
+	// one accumulator per worker that is created with the first chunk
+	workerStats := make([]*streamStats, numWorkers)
+	headLine := streamCsvChunks(filePath, header, chunkSize, numWorkers, func(worker int, chunk [][]string) {
+		for _, i := range chunk {
+			features := parseFeatureRecord(i)
+			if workerStats[worker] == nil {
+				workerStats[worker] = newStreamStats(len(features), compression, trackCov)
+			}
+			workerStats[worker].add(features)
+		}
+	})
+	var stats *streamStats
+	for _, i := range workerStats {
+		if i == nil {
+			continue
+		}
+		if stats == nil {
+			stats = i
+		} else {
+			stats.merge(i)
+		}
+	}
+	return headLine, stats
+}
+
+/*
+Create a min max scaler from the features of a csv file without reading the whole file into memory
+
+	:parameter
+		* filePath: path to the csv file with the labels in the first column
+		* header: true if there is a header
+		* chunkSize: number of lines processed at once by one worker
+	:return
+		* scaler: function that scales a slice based on the minimum and maximum values of the features in the file
+*/
+func streamingMinMaxScaler(filePath *string, header *bool, chunkSize *int) func([][]float64) {
+	noQuantiles := 0.0
+	trackCov := false
+	_, stats := streamFeatureStats(filePath, header, chunkSize, &noQuantiles, &trackCov)
+	if stats == nil {
+		log.Fatalln(fmt.Sprintf("No data in [%s]", *filePath))
+	}
+	return minMaxScalerFromMoments(stats.moments)
+}
+
+/*
+Create a standard scaler from the features of a csv file without reading the whole file into memory
+
+	:parameter
+		* filePath: path to the csv file with the labels in the first column
+		* header: true if there is a header
+		* chunkSize: number of lines processed at once by one worker
+	:return
+		* scaler: function that scales a slice based on the mean and standard deviation of the features in the file
+*/
+func streamingStandardScaler(filePath *string, header *bool, chunkSize *int) func([][]float64) {
+	noQuantiles := 0.0
+	trackCov := false
+	_, stats := streamFeatureStats(filePath, header, chunkSize, &noQuantiles, &trackCov)
+	if stats == nil {
+		log.Fatalln(fmt.Sprintf("No data in [%s]", *filePath))
+	}
+	return standardScalerFromMoments(stats.moments)
+}
+
+/*
+Accumulator that decides whether a column of a csv file is constant - numbers are accumulated by their value and all other entries are compared as strings
+*/
+type constantTracker struct {
+	// value range of the numeric entries
+	moments welford
+	seen    bool
+	// first entry that is no number
+	firstText string
+	// true as soon as two entries that are no numbers differ
+	textDiffers bool
+}
+
+/*
+Add an entry of the column
+*/
+func (c *constantTracker) add(entry string) {
+	if number, err := strconv.ParseFloat(entry, 64); err == nil && !math.IsNaN(number) {
+		c.moments.add(number)
+		return
+	}
+	if !c.seen {
+		c.seen, c.firstText = true, entry
+	} else if entry != c.firstText {
+		c.textDiffers = true
+	}
+}
+
+/*
+Merge the tracker of a different part of the column
+*/
+func (c *constantTracker) merge(other constantTracker) {
+	c.moments.merge(other.moments)
+	if !other.seen {
+		return
+	}
+	if !c.seen {
+		c.seen, c.firstText, c.textDiffers = true, other.firstText, other.textDiffers
+		return
+	}
+	c.textDiffers = c.textDiffers || other.textDiffers || c.firstText != other.firstText
+}
+
+/*
+Whether all entries are the same - either all numbers of the same value (1 and 1.0 are equal) or all the same string
+*/
+func (c constantTracker) constant() bool {
+	if c.moments.n > 0 {
+		// a mix of numbers and other entries like missing values is never constant
+		return !c.seen && c.moments.min == c.moments.max
+	}
+	return !c.textDiffers
+}
+
+/*
+Find the constant columns of a csv file in one parallel pass without reading the whole file into memory
+
+	:parameter
+		* filePath: path to the csv file
+		* header: true if there is a header
+		* chunkSize: number of lines processed at once by one worker
+	:return
+		* headLine: the header of the file - empty if there is none
+		* constant: true for every column that is constant
+*/
+func streamConstantColumns(filePath *string, header *bool, chunkSize *int) ([]string, []bool) {
+	numWorkers := runtime.NumCPU()
+	workerTrackers := make([][]constantTracker, numWorkers)
+	headLine := streamCsvChunks(filePath, header, chunkSize, numWorkers, func(worker int, chunk [][]string) {
+		trackers := make([]constantTracker, len(chunk[0]))
+		for _, i := range chunk {
+			for cj, j := range i {
+				trackers[cj].add(j)
+			}
+		}
+		if workerTrackers[worker] == nil {
+			workerTrackers[worker] = trackers
+			return
+		}
+		for ci := range trackers {
+			workerTrackers[worker][ci].merge(trackers[ci])
+		}
+	})
+	var merged []constantTracker
+	for _, i := range workerTrackers {
+		if i == nil {
+			continue
+		}
+		if merged == nil {
+			merged = i
+			continue
+		}
+		for cj := range merged {
+			merged[cj].merge(i[cj])
+		}
+	}
+	constant := make([]bool, len(merged))
+	for ci, i := range merged {
+		constant[ci] = i.constant()
+	}
+	return headLine, constant
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWelfordMerge(t *testing.T) {
+	rng := rand.New(rand.NewSource(97))
+	values := make([]float64, 1000)
+	for i := range values {
+		// an offset that costs the naive sum of squares most of its precision
+		values[i] = 1e6 + rng.NormFloat64()
+	}
+	values[10] = math.NaN()
+	var sequential welford
+	for _, i := range values {
+		sequential.add(i)
+	}
+	// merge uneven chunks including an empty one
+	var merged welford
+	for _, bounds := range [][2]int{{0, 3}, {3, 3}, {3, 400}, {400, 1000}} {
+		var chunk welford
+		for _, i := range values[bounds[0]:bounds[1]] {
+			chunk.add(i)
+		}
+		merged.merge(chunk)
+	}
+	clean := append(append([]float64{}, values[:10]...), values[11:]...)
+	// two pass reference
+	mean := *sumFloat64(clean) / float64(len(clean))
+	std := 0.0
+	for _, i := range clean {
+		std += (i - mean) * (i - mean) / float64(len(clean))
+	}
+	std = math.Sqrt(std)
+	sort.Float64s(clean)
+	for _, i := range []welford{sequential, merged} {
+		if i.n != 999 || math.Abs(i.mean-mean) > 1e-9 || math.Abs(i.std(0)-std)/std > 1e-9 || i.min != clean[0] || i.max != clean[998] {
+			t.Errorf("accumulated n %d, mean %g, std %g, min %g and max %g, want 999, %g, %g, %g and %g", i.n, i.mean, i.std(0), i.min, i.max, mean, std, clean[0], clean[998])
+		}
+	}
+	if math.Abs(merged.variance(1)-sequential.variance(1)) > 1e-9 {
+		t.Errorf("merged sample variance %g, sequential %g", merged.variance(1), sequential.variance(1))
+	}
+	if !math.IsNaN((&welford{}).variance(1)) {
+		t.Errorf("variance of no values isn't NaN")
+	}
+}
+
+func TestCovarianceAccumulatorMerge(t *testing.T) {
+	rng := rand.New(rand.NewSource(101))
+	x := make([][]float64, 300)
+	for i := range x {
+		a := rng.NormFloat64()
+		x[i] = []float64{a, 2*a + rng.NormFloat64(), rng.NormFloat64() + 5}
+	}
+	full := newCovarianceAccumulator(3)
+	first, second := newCovarianceAccumulator(3), newCovarianceAccumulator(3)
+	for ci, i := range x {
+		full.add(i)
+		if ci < 120 {
+			first.add(i)
+		} else {
+			second.add(i)
+		}
+	}
+	first.merge(second)
+	want := make([][]float64, 3)
+	for i := range want {
+		want[i] = make([]float64, 3)
+		for j := range want[i] {
+			mi, _ := meanStd(column(x, i))
+			mj, _ := meanStd(column(x, j))
+			for _, k := range x {
+				want[i][j] += (k[i] - mi) * (k[j] - mj) / float64(len(x)-1)
+			}
+		}
+	}
+	for _, acc := range []*covarianceAccumulator{full, first} {
+		cov := acc.covariance(1)
+		for i := range want {
+			for j := range want[i] {
+				if math.Abs(cov[i][j]-want[i][j]) > 1e-9 {
+					t.Errorf("covariance [%d][%d] %g, want %g", i, j, cov[i][j], want[i][j])
+				}
+			}
+		}
+	}
+}
+
+func TestTDigestQuantiles(t *testing.T) {
+	rng := rand.New(rand.NewSource(103))
+	compression := 100.0
+	single := newTDigest(&compression)
+	parts := []*tDigest{newTDigest(&compression), newTDigest(&compression), newTDigest(&compression)}
+	values := make([]float64, 20000)
+	for i := range values {
+		values[i] = rng.ExpFloat64()
+		single.add(values[i])
+		parts[i%3].add(values[i])
+	}
+	parts[0].merge(parts[1])
+	parts[0].merge(parts[2])
+	sort.Float64s(values)
+	for _, digest := range []*tDigest{single, parts[0]} {
+		if digest.quantile(0) != values[0] || digest.quantile(1) != values[len(values)-1] {
+			t.Errorf("extreme quantiles %g and %g, want the minimum %g and maximum %g", digest.quantile(0), digest.quantile(1), values[0], values[len(values)-1])
+		}
+		for _, q := range []float64{0.01, 0.1, 0.25, 0.5, 0.75, 0.9, 0.99} {
+			// the error is measured as rank since the tails of the exponential distribution are sparse
+			rank := float64(sort.SearchFloat64s(values, digest.quantile(q))) / float64(len(values))
+			if math.Abs(rank-q) > 0.005 {
+				t.Errorf("quantile %g has the rank %g", q, rank)
+			}
+		}
+	}
+	if !math.IsNaN(newTDigest(&compression).quantile(0.5)) {
+		t.Errorf("quantile of an empty digest isn't NaN")
+	}
+}
+
+/*
+Write lines to a csv file in a temporary directory and return its path
+*/
+func testWriteCSV(t *testing.T, lines []string) string {
+	t.Helper()
+	filePath := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(filePath, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filePath
+}
+
+func TestStreamingScalersMatchInMemory(t *testing.T) {
+	rng := rand.New(rand.NewSource(107))
+	lines := []string{"label,a,b"}
+	x := [][]float64{}
+	for i := 0; i < 250; i++ {
+		row := []float64{rng.NormFloat64()*3 + 1, rng.Float64() * 50}
+		x = append(x, row)
+		lines = append(lines, fmt.Sprintf("%d,%v,%v", i%2, row[0], row[1]))
+	}
+	filePath := testWriteCSV(t, lines)
+	header := true
+	// small chunks so that several workers merge their statistics
+	chunkSize := 7
+	for name, scalers := range map[string][2]func([][]float64){
+		"minmax":   {streamingMinMaxScaler(&filePath, &header, &chunkSize), minMaxScaler(x)},
+		"standard": {streamingStandardScaler(&filePath, &header, &chunkSize), standardScaler(x)},
+	} {
+		got, want := copyFeatures(x), copyFeatures(x)
+		scalers[0](got)
+		scalers[1](want)
+		for ci, i := range want {
+			for cj, j := range i {
+				if math.Abs(got[ci][cj]-j) > 1e-9 {
+					t.Fatalf("%s: streamed scaling of [%d][%d] is %g, in memory %g", name, ci, cj, got[ci][cj], j)
+				}
+			}
+		}
+	}
+}
+
+func TestStreamConstantColumns(t *testing.T) {
+	lines := []string{"number,formatted,text,varying,missing,mixed"}
+	for i := 0; i < 40; i++ {
+		formatted, missing, mixed := "1", "3", "2"
+		if i%2 == 1 {
+			formatted = "1.0"
+		}
+		if i == 25 {
+			missing = ""
+		}
+		if i == 31 {
+			mixed = "NA"
+		}
+		lines = append(lines, fmt.Sprintf("5,%s,a,%d,%s,%s", formatted, i%3, missing, mixed))
+	}
+	filePath := testWriteCSV(t, lines)
+	header := true
+	chunkSize := 3
+	headLine, constant := streamConstantColumns(&filePath, &header, &chunkSize)
+	want := []bool{true, true, true, false, false, false}
+	for ci, i := range want {
+		if constant[ci] != i {
+			t.Errorf("column [%s] constant %t, want %t", headLine[ci], constant[ci], i)
+		}
+	}
+}
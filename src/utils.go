@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
 )
 
 /*
@@ -109,3 +112,108 @@ func isinString(inSlice []string, target string) bool {
 	}
 	return isin
 }
+
+/*
+Run fn for every index in [0, n) distributed over as many goroutines as there are CPUs
+
+	:parameter
+		* n: number of indices
+		* fn: function that is called once for every index
+	:return
+		None
+*/
+func parallelFor(n int, fn func(i int)) {
+	numWorkers := runtime.NumCPU()
+	if numWorkers > n {
+		numWorkers = n
+	}
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(numWorkers)
+	for w := 0; w < numWorkers; w++ {
+		go func() {
+			for i := range indices {
+				fn(i)
+			}
+			wg.Done()
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
+
+/*
+Select the rows of inSlice at the given indices
+
+	:parameter
+		* inSlice: the slice to select from
+		* indices: indices of the rows that should be selected
+	:return
+		* subset: the selected rows (not copied)
+*/
+func subsetRows[T any](inSlice []T, indices []int) []T {
+	subset := make([]T, len(indices))
+	for ci, i := range indices {
+		subset[ci] = inSlice[i]
+	}
+	return subset
+}
+
+/*
+Create a deep copy of a slice of feature vectors
+
+	:parameter
+		* inSlice: the slice to be copied
+	:return
+		* newSlice: the copy
+*/
+func copyFeatures(inSlice [][]float64) [][]float64 {
+	newSlice := make([][]float64, len(inSlice))
+	for ci, i := range inSlice {
+		newSlice[ci] = append([]float64{}, i...)
+	}
+	return newSlice
+}
+
+/*
+Find the unique values of inSlice
+
+	:parameter
+		* inSlice: the slice to search in
+	:return
+		* unique: the unique values in increasing order
+*/
+func uniqueInts(inSlice []int) []int {
+	seen := make(map[int]bool)
+	unique := []int{}
+	for _, i := range inSlice {
+		if !seen[i] {
+			seen[i] = true
+			unique = append(unique, i)
+		}
+	}
+	sort.Ints(unique)
+	return unique
+}
+
+/*
+Convert row-major data (one slice per sample) to column-major data (one slice per feature)
+
+	:parameter
+		* inSlice: the row-major data
+	:return
+		* columns: the column-major data
+*/
+func columnMajor(inSlice [][]float64) [][]float64 {
+	columns := make([][]float64, len(inSlice[0]))
+	for cj := range columns {
+		columns[cj] = make([]float64, len(inSlice))
+		for ci, i := range inSlice {
+			columns[cj][ci] = i[cj]
+		}
+	}
+	return columns
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+)
+
+/*
+Well separated gaussian blobs around (10 * blob, 10 * blob) with the given sizes and the blob of every vector
+*/
+func testBlobs(sizes []int, dim int, seed int64) ([][]float64, []int) {
+	rng := rand.New(rand.NewSource(seed))
+	x := [][]float64{}
+	labels := []int{}
+	for ci, i := range sizes {
+		for j := 0; j < i; j++ {
+			vec := make([]float64, dim)
+			for k := range vec {
+				vec[k] = 10*float64(ci) + rng.NormFloat64()*0.5
+			}
+			x = append(x, vec)
+			labels = append(labels, ci)
+		}
+	}
+	return x, labels
+}
+
+/*
+Whether the labels split the vectors exactly like the blobs - a permutation of the blob numbers and no noise
+*/
+func testMatchesBlobs(labels []int, blobs []int) bool {
+	blobOf := map[int]int{}
+	labelOf := map[int]int{}
+	for ci, i := range labels {
+		if i < 0 {
+			return false
+		}
+		if blob, ok := blobOf[i]; ok && blob != blobs[ci] {
+			return false
+		}
+		if label, ok := labelOf[blobs[ci]]; ok && label != i {
+			return false
+		}
+		blobOf[i], labelOf[blobs[ci]] = blobs[ci], i
+	}
+	return true
+}
+
+/*
+Sizes of the clusters sorted from small to large
+*/
+func testClusterSizes(clusters [][]int) []int {
+	sizes := make([]int, len(clusters))
+	for ci, i := range clusters {
+		sizes[ci] = len(i)
+	}
+	sort.Ints(sizes)
+	return sizes
+}